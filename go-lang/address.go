@@ -0,0 +1,282 @@
+// Package main 管理手机端访问地址：当前选中的网卡 IP 及其运行时切换
+// Package main manages the mobile access address: the selected interface IP and switching it at runtime
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"airinputlan/internal/config"
+	"airinputlan/internal/netif"
+	"airinputlan/internal/network"
+)
+
+// NetworkPollInterval 不支持 netlink 时检查网卡变化的间隔
+// NetworkPollInterval is how often interfaces are rescanned where netlink is unavailable
+const NetworkPollInterval = 5 * time.Second
+
+var (
+	addressMu   sync.RWMutex
+	localIPs    []netif.IpInfo      // 扫描到的 IP，当前访问地址排在首位
+	connectBase network.ConnectInfo // 访问地址的协议、端口和证书指纹（IP 使用当前访问地址）
+)
+
+// activeIPRequest 表示切换访问地址的请求体，ip 和 iface 二选一（链路本地 IPv6 的 ip 需带 Zone）
+// activeIPRequest is the request body for switching the access address; give either ip or iface (link-local IPv6 needs its zone)
+type activeIPRequest struct {
+	IP    string `json:"ip"`
+	Iface string `json:"iface"`
+}
+
+// setLocalIPs 设置扫描到的 IP 列表（首个为当前访问地址）
+// setLocalIPs sets the scanned IP list (the first entry is the access address)
+func setLocalIPs(ips []netif.IpInfo) {
+	addressMu.Lock()
+	defer addressMu.Unlock()
+	localIPs = ips
+}
+
+// setConnectBase 在端口确定后设置访问地址的其余部分
+// setConnectBase sets the rest of the access address once the port is known
+func setConnectBase(info network.ConnectInfo) {
+	addressMu.Lock()
+	defer addressMu.Unlock()
+	connectBase = info
+}
+
+// activeAddress 返回当前访问地址
+// activeAddress returns the current access address
+func activeAddress() netif.IpInfo {
+	addressMu.RLock()
+	defer addressMu.RUnlock()
+	return localIPs[0]
+}
+
+// listLocalIPs 返回供 /api/ip 使用的 IP 列表（当前访问地址排在首位）
+// listLocalIPs returns the IP list for /api/ip (the access address comes first)
+func listLocalIPs() []interface{} {
+	addressMu.RLock()
+	defer addressMu.RUnlock()
+	return convertIps(localIPs)
+}
+
+// connectInfo 返回使用当前访问地址的连接信息（不含配对码）
+// connectInfo returns the connection info for the current access address (without the PIN)
+func connectInfo() network.ConnectInfo {
+	addressMu.RLock()
+	defer addressMu.RUnlock()
+	info := connectBase
+	info.IP = localIPs[0].IP
+	return info
+}
+
+// selectAddress 将指定网卡或 IP 设为当前访问地址，切换后通知电脑端并重新显示二维码
+// remember 为 true 时将网卡名称写入配置文件，下次启动时继续使用
+// selectAddress makes the given interface or IP the access address, then notifies the PC page and reprints the QR code
+// With remember set, the interface name is written to the config file for the next launch
+func selectAddress(iface, ip string, remember bool) (netif.IpInfo, error) {
+	addressMu.Lock()
+	ips, err := netif.PreferIP(localIPs, iface, ip)
+	if err != nil {
+		addressMu.Unlock()
+		return netif.IpInfo{}, err
+	}
+	changed := ips[0] != localIPs[0]
+	localIPs = ips
+	active := ips[0]
+	addressMu.Unlock()
+
+	if remember {
+		if err := rememberIface(active.IfaceName); err != nil {
+			network.LogInfo("保存网卡选择失败: %v", err)
+		}
+	}
+	if changed {
+		network.LogInfo("访问地址已切换: %s（网卡: %s）", active.Host(), active.IfaceName)
+		broadcastActiveIP(active)
+		updateMDNS()
+		printConnectInfo()
+	}
+	return active, nil
+}
+
+// rememberIface 将网卡选择写入配置文件（清除 server.ip，以网卡名称为准）
+// 同时更新当前配置，避免配置热重载把这次保存当作新的修改
+// rememberIface writes the interface choice to the config file (clearing server.ip so the name wins)
+// It also updates the current config so hot reload does not treat this save as a new change
+func rememberIface(iface string) error {
+	fileConfig, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	if fileConfig.Server.Iface == iface && fileConfig.Server.IP == "" {
+		return nil
+	}
+	fileConfig.Server.Iface = iface
+	fileConfig.Server.IP = ""
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if err := config.Save(configPath, fileConfig); err != nil {
+		return err
+	}
+	if !overridden("iface") && !overridden("ip") {
+		appConfig.Server.Iface = iface
+		appConfig.Server.IP = ""
+	}
+	return nil
+}
+
+// broadcastActiveIP 通知电脑端访问地址已切换
+// broadcastActiveIP tells the PC pages that the access address was switched
+func broadcastActiveIP(active netif.IpInfo) {
+	payload, _ := json.Marshal(active)
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type:    network.TypeActiveIP,
+		Data:    active.Host(),
+		Payload: payload,
+	})
+	network.LogFormat("发送", "SSE", "服务端 --> PC端", "访问地址已切换: %s", active.Host())
+}
+
+// printConnectInfo 在终端显示当前访问地址（及 mDNS 主机名地址）、配对码和二维码
+// printConnectInfo prints the current access address (plus the mDNS host name address), PIN and QR code in the terminal
+func printConnectInfo() {
+	info := connectInfo()
+	info.PIN, _ = pairingManager.CurrentPIN()
+	settingsMu.RLock()
+	pinTTL := time.Duration(appConfig.Security.PINTTL)
+	useHostname := qrHostname
+	settingsMu.RUnlock()
+
+	// 二维码使用 .local 主机名时，IP 地址作为备用显示 / With the .local name in the QR code, the IP address is shown as a fallback
+	host := mdnsHost()
+	alternative := info
+	if host != "" && useHostname {
+		info.Hostname = host
+	} else {
+		alternative.Hostname = host
+	}
+
+	qrData := network.GenerateQRCodeData(info)
+	fmt.Println("=== 连接信息 ===")
+	fmt.Printf("手机端访问地址: %s\n", qrData["url"])
+	if host != "" {
+		fmt.Printf("备用地址: %s\n", alternative.URL())
+	}
+	fmt.Printf("配对码: %s（%v 内有效，使用一次后自动更换）\n", info.PIN, pinTTL)
+	fmt.Println("扫描二维码连接手机端:")
+	fmt.Print(qrData["text"])
+	fmt.Println()
+}
+
+// handleActiveIP 处理 /api/ip/active：GET 返回当前访问地址，POST 切换访问地址（仅限本机）
+// handleActiveIP handles /api/ip/active: GET returns the access address, POST switches it (local only)
+func handleActiveIP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, activeAddress())
+
+	case http.MethodPost:
+		if !network.IsLocalRequest(r) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		var req activeIPRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.IP == "" && req.Iface == "") {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		active, err := selectAddress(req.Iface, req.IP, true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, active)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// watchNetwork 开始监听网卡变化，地址变化后自动刷新访问地址
+// watchNetwork starts monitoring interfaces and refreshes the access address when addresses change
+func watchNetwork() *netif.Watcher {
+	addressMu.RLock()
+	current := append([]netif.IpInfo(nil), localIPs...)
+	addressMu.RUnlock()
+
+	watcher := netif.Watch(current, NetworkPollInterval, handleNetworkChange)
+	network.LogInfo("网络变化监听方式: %s", watcher.Mode())
+	return watcher
+}
+
+// handleNetworkChange 网卡地址变化时更新 IP 列表，尽量保留当前访问地址，并通过 SSE 推送新的地址列表和 URL
+// handleNetworkChange updates the IP list when addresses change, keeping the access address where possible,
+// and pushes the new list and URL over SSE
+func handleNetworkChange(ips []netif.IpInfo, events []netif.Event) {
+	for _, e := range events {
+		if e.Old != nil {
+			network.LogInfo("网络变化: %s %s → %s（网卡: %s）", e.Kind, e.Old.Host(), e.IP.Host(), e.IP.IfaceName)
+		} else {
+			network.LogInfo("网络变化: %s %s（网卡: %s）", e.Kind, e.IP.Host(), e.IP.IfaceName)
+		}
+	}
+	if len(ips) == 0 {
+		network.LogInfo("未找到有效网卡，暂时保留原访问地址")
+		return
+	}
+
+	settingsMu.RLock()
+	configIface, configIP := appConfig.Server.Iface, appConfig.Server.IP
+	settingsMu.RUnlock()
+
+	addressMu.Lock()
+	old := localIPs[0]
+	// 依次尝试：原地址、原网卡（换了新地址）、配置中的网卡或 IP，都不存在时使用优先级最高的地址
+	// Try the old address, then the old interface (with a new address), then the configured interface or IP,
+	// and fall back to the highest-priority address
+	ordered := ips
+	for _, prefer := range [][2]string{{"", old.Host()}, {old.IfaceName, ""}, {configIface, configIP}} {
+		if prefer[0] == "" && prefer[1] == "" {
+			continue
+		}
+		if result, err := netif.PreferIP(ips, prefer[0], prefer[1]); err == nil {
+			ordered = result
+			break
+		}
+	}
+	localIPs = ordered
+	active := ordered[0]
+	addressMu.Unlock()
+
+	if tlsMode {
+		for _, e := range events {
+			if e.Kind != netif.EventRemoved {
+				network.LogInfo("HTTPS 证书不包含新地址 %s，重启后会重新生成证书", e.IP.IP)
+			}
+		}
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"ips":    convertIps(ordered),
+		"active": active,
+		"url":    connectInfo().URL(),
+		"events": events,
+	})
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type:    network.TypeNetworkChange,
+		Data:    active.Host(),
+		Payload: payload,
+	})
+	network.LogFormat("发送", "SSE", "服务端 --> PC端", "网络已变化，当前访问地址: %s", active.Host())
+	updateMDNS()
+
+	if active != old {
+		network.LogInfo("访问地址已切换: %s（网卡: %s）", active.Host(), active.IfaceName)
+		printConnectInfo()
+	}
+}
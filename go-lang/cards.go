@@ -0,0 +1,133 @@
+// Package main 提供历史卡片的 REST API（服务端为历史记录的唯一数据源）
+// Package main provides the REST API for history cards (the server is the source of truth for the history)
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"airinputlan/internal/network"
+	"airinputlan/internal/state"
+)
+
+// cardRequest 表示创建或修改卡片的请求体
+// cardRequest is the request body for creating or updating a card
+type cardRequest struct {
+	Text string `json:"text"`
+}
+
+// handleCards 处理 /api/cards：GET 列出全部卡片，POST 创建卡片（仅限电脑端页面）
+// handleCards handles /api/cards: GET lists all cards, POST creates a card (PC page only)
+func handleCards(w http.ResponseWriter, r *http.Request) {
+	if !allowCardRequest(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, contentState.GetHistoryCards())
+
+	case http.MethodPost:
+		var req cardRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		cards, err := contentState.CreateCard(req.Text, state.CardSource{
+			IP:    network.ClientIP(r),
+			Cause: state.CauseManual,
+		})
+		if err != nil {
+			writeCardError(w, err)
+			return
+		}
+		for _, card := range cards {
+			sseServer.SendToRole(network.RolePC, newCardMessage(network.TypeCardCreated, card))
+		}
+		network.LogFormat("处理", "HTTP", "服务端", "创建卡片: %s", joinCardTexts(cards))
+		writeJSON(w, http.StatusCreated, cards)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCard 处理 /api/cards/{id}：GET 读取、PATCH 修改、DELETE 删除（修改和删除仅限电脑端页面）
+// handleCard handles /api/cards/{id}: GET reads, PATCH updates, DELETE deletes (updates and deletes from the PC page only)
+func handleCard(w http.ResponseWriter, r *http.Request) {
+	if !allowCardRequest(w, r) {
+		return
+	}
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		card, err := contentState.GetCard(id)
+		if err != nil {
+			writeCardError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, card)
+
+	case http.MethodPatch:
+		var req cardRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		card, err := contentState.UpdateCard(id, req.Text)
+		if err != nil {
+			writeCardError(w, err)
+			return
+		}
+		sseServer.SendToRole(network.RolePC, newCardMessage(network.TypeCardUpdated, card))
+		network.LogFormat("处理", "HTTP", "服务端", "修改卡片 %s: %s", id, card.Text)
+		writeJSON(w, http.StatusOK, card)
+
+	case http.MethodDelete:
+		if err := contentState.DeleteCard(id); err != nil {
+			writeCardError(w, err)
+			return
+		}
+		sseServer.SendToRole(network.RolePC, network.Message{
+			Type: network.TypeCardDeleted,
+			Data: id,
+		})
+		network.LogFormat("处理", "HTTP", "服务端", "删除卡片 %s", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// allowCardRequest 历史卡片只在电脑端页面编辑：已配对的手机可以读取，修改请求必须来自本机，否则返回 403
+// allowCardRequest keeps history editing on the PC page: paired phones may read, but changes must come from this machine, otherwise 403
+func allowCardRequest(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || network.IsLocalRequest(r) {
+		return true
+	}
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return false
+}
+
+// writeCardError 将卡片操作错误转换为 HTTP 状态码
+// writeCardError maps card operation errors to HTTP status codes
+func writeCardError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, state.ErrCardNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, state.ErrEmptyCard):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeJSON 以 JSON 格式写入响应
+// writeJSON writes the response as JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"airinputlan/internal/state"
+)
+
+// cardRequestFrom 以 remote 的身份调用卡片接口 / cardRequestFrom calls the card API as remote
+func cardRequestFrom(remote, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:5000"
+	r.RemoteAddr = remote
+	if id, ok := strings.CutPrefix(path, "/api/cards/"); ok {
+		r.SetPathValue("id", id)
+	}
+	w := httptest.NewRecorder()
+	if path == "/api/cards" {
+		handleCards(w, r)
+	} else {
+		handleCard(w, r)
+	}
+	return w
+}
+
+func TestCardMutationsArePCOnly(t *testing.T) {
+	setupTestServer(t)
+	cards := contentState.AddCard("原始内容", state.CardSource{IP: "192.168.1.20", Cause: state.CauseMobile})
+	path := "/api/cards/" + cards[0].ID
+
+	tests := []struct {
+		remote string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		// 已配对的手机只能读取 / A paired phone may only read
+		{fromPhone, http.MethodGet, "/api/cards", "", http.StatusOK},
+		{fromPhone, http.MethodGet, path, "", http.StatusOK},
+		{fromPhone, http.MethodPost, "/api/cards", `{"text":"新卡片"}`, http.StatusForbidden},
+		{fromPhone, http.MethodPatch, path, `{"text":"改写"}`, http.StatusForbidden},
+		{fromPhone, http.MethodDelete, path, "", http.StatusForbidden},
+		// 电脑端页面可以编辑 / The PC page may edit
+		{fromPC, http.MethodPost, "/api/cards", `{"text":"新卡片"}`, http.StatusCreated},
+		{fromPC, http.MethodPatch, path, `{"text":"修改后"}`, http.StatusOK},
+		{fromPC, http.MethodDelete, path, "", http.StatusNoContent},
+		{fromPC, http.MethodGet, path, "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := cardRequestFrom(tt.remote, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s from %s: status = %d, want %d", tt.method, tt.path, tt.remote, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package main 提供服务端系统剪贴板接口（/api/copy）及新卡片自动复制
+// Package main provides the server-side system clipboard endpoint (/api/copy) and automatic copying of new cards
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"airinputlan/internal/clipboard"
+	"airinputlan/internal/network"
+	"airinputlan/internal/state"
+)
+
+// handleCopy 处理复制请求：将文本写入运行服务端的电脑的系统剪贴板，仅允许电脑端页面调用
+// handleCopy handles copy requests: writes the text to the system clipboard of the machine running the server; PC page only
+func handleCopy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !network.IsLocalRequest(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	backend, _ := outputSettings()
+	if backend == nil {
+		http.Error(w, clipboard.ErrUnavailable.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := backend.WriteText(req.Text); err != nil {
+		network.LogInfo("写入剪贴板失败: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	network.LogFormat("处理", "剪贴板", "服务端", "已复制到系统剪贴板（%s）: %s", backend.Name(), req.Text)
+	w.WriteHeader(http.StatusOK)
+}
+
+// autoCopyCards 启用自动复制时，将新卡片复制到系统剪贴板
+// autoCopyCards copies new cards to the system clipboard when auto-copy is enabled
+func autoCopyCards(cards []state.Card) {
+	backend, enabled := outputSettings()
+	if !enabled || backend == nil || len(cards) == 0 {
+		return
+	}
+	text := joinCardTexts(cards)
+	if err := backend.WriteText(text); err != nil {
+		network.LogInfo("自动复制失败: %v", err)
+		return
+	}
+	network.LogDebug("已自动复制新卡片: %s", text)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"airinputlan/internal/clipboard"
+	"airinputlan/internal/network"
+	"airinputlan/internal/session"
+	"airinputlan/internal/state"
+)
+
+// setupTestServer 初始化处理函数用到的全局状态，测试结束后恢复
+// setupTestServer initializes the global state the handlers use and restores it when the test ends
+func setupTestServer(t *testing.T) {
+	t.Helper()
+	oldContent, oldSession, oldSSE := contentState, inputSession, sseServer
+	oldBackend, oldAutoCopy := clipboardBackend, autoCopy
+	t.Cleanup(func() {
+		contentState, inputSession, sseServer = oldContent, oldSession, oldSSE
+		clipboardBackend, autoCopy = oldBackend, oldAutoCopy
+	})
+
+	contentState = state.NewContentState(time.Second, 50, 1000)
+	inputSession = session.New(contentState)
+	sseServer = network.NewSSEServer()
+	go sseServer.Run()
+	clipboardBackend, autoCopy = nil, false
+}
+
+const (
+	fromPC    = "127.0.0.1:40000"    // 电脑端页面 / The PC page
+	fromPhone = "192.168.1.20:40000" // 已配对的手机 / A paired phone
+)
+
+// postJSON 以 POST 方式从 remote 调用处理函数并返回响应
+// postJSON calls the handler with a POST request from remote and returns the response
+func postJSON(handler http.HandlerFunc, remote, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:5000"
+	r.RemoteAddr = remote
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestHandleCopy(t *testing.T) {
+	setupTestServer(t)
+	memory := clipboard.NewMemory()
+	clipboardBackend = memory
+
+	if w := postJSON(handleCopy, fromPC, "/api/copy", `{"text":"你好，世界"}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := postJSON(handleCopy, fromPC, "/api/copy", `{"text":"second"}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := memory.History(), []string{"你好，世界", "second"}; !slices.Equal(got, want) {
+		t.Errorf("clipboard history = %q, want %q", got, want)
+	}
+}
+
+func TestHandleCopyErrors(t *testing.T) {
+	setupTestServer(t)
+
+	if w := postJSON(handleCopy, fromPC, "/api/copy", `{"text":"x"}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a backend: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	memory := clipboard.NewMemory()
+	clipboardBackend = memory
+	if w := postJSON(handleCopy, fromPC, "/api/copy", `not json`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	w := httptest.NewRecorder()
+	handleCopy(w, httptest.NewRequest(http.MethodGet, "/api/copy", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	// 手机端不能改写电脑的剪贴板 / A phone cannot overwrite the PC's clipboard
+	if w := postJSON(handleCopy, fromPhone, "/api/copy", `{"text":"x"}`); w.Code != http.StatusForbidden {
+		t.Errorf("from a phone: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if history := memory.History(); len(history) != 0 {
+		t.Errorf("failed requests wrote %q to the clipboard", history)
+	}
+}
+
+func TestAutoCopyNewCards(t *testing.T) {
+	tests := []struct {
+		name     string
+		autoCopy bool
+		want     []string
+	}{
+		{"enabled", true, []string{"今天天气很好。", "明天见"}},
+		{"disabled", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestServer(t)
+			memory := clipboard.NewMemory()
+			clipboardBackend, autoCopy = memory, tt.autoCopy
+
+			for _, content := range []string{"今天天气很好。", "明天见"} {
+				if w := postJSON(handleSegmentRequest, fromPhone, "/api/segment", `{"content":"`+content+`"}`); w.Code != http.StatusOK {
+					t.Fatalf("segment %q: status = %d, want %d", content, w.Code, http.StatusOK)
+				}
+			}
+			// 内容为空的分段不生成卡片，也不写剪贴板 / An empty segment creates no card and writes nothing
+			postJSON(handleSegmentRequest, fromPhone, "/api/segment", `{"content":"   "}`)
+
+			if got := memory.History(); !slices.Equal(got, tt.want) {
+				t.Errorf("clipboard history = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
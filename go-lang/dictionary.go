@@ -0,0 +1,179 @@
+// Package main 提供用户词典的加载、热重载和 REST API（词典文件为规则的唯一数据源）
+// Package main provides loading, hot reloading and the REST API of the user dictionary (the dictionary file is the source of truth for the rules)
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"airinputlan/internal/dictionary"
+	"airinputlan/internal/filewatch"
+	"airinputlan/internal/network"
+)
+
+var (
+	userDictionary *dictionary.Dictionary // 当前生效的用户词典
+	dictionaryMu   sync.Mutex             // 保护 userDictionary，串行化规则的读-改-写
+)
+
+// dictionaryResponse 表示 /api/dictionary 的响应体
+// dictionaryResponse is the response body of /api/dictionary
+type dictionaryResponse struct {
+	File  string            `json:"file"`  // 词典文件路径 / Dictionary file path
+	Live  bool              `json:"live"`  // 实时输入是否也应用词典 / Whether live input is replaced too
+	Rules []dictionary.Rule `json:"rules"` // 按顺序执行的规则 / Rules, applied in order
+}
+
+// dictionaryRequest 表示整体替换规则的请求体
+// dictionaryRequest is the request body for replacing all rules
+type dictionaryRequest struct {
+	Rules []dictionary.Rule `json:"rules"`
+}
+
+// loadDictionary 启动时读取用户词典（文件不存在时为空词典，通过 API 添加规则后创建）
+// loadDictionary reads the user dictionary at startup (a missing file means an empty dictionary; it is created once rules are added through the API)
+func loadDictionary() error {
+	if dictionaryFile == "" {
+		path, err := dictionary.DefaultPath()
+		if err != nil {
+			return err
+		}
+		dictionaryFile = path
+	}
+	d, err := dictionary.Load(dictionaryFile)
+	if err != nil {
+		return err
+	}
+	setDictionary(d)
+	contentState.SetDictionaryLive(dictionaryLive)
+	network.LogInfo("用户词典: %s（%d 条规则，保存后自动重新加载）", dictionaryFile, d.Len())
+	return nil
+}
+
+// watchDictionary 开始监听用户词典文件的修改
+// watchDictionary starts watching the user dictionary file for changes
+func watchDictionary() *filewatch.Poller {
+	return filewatch.Watch(dictionaryFile, ConfigPollInterval, handleDictionaryChange)
+}
+
+// handleDictionaryChange 词典文件变化时在锁内重新加载，避免读到的旧文件覆盖同时通过 API 保存的修改；解析失败时保留之前的规则
+// handleDictionaryChange reloads the dictionary under the lock when its file changes, so a stale read never overwrites
+// a change saved through the API at the same time; the previous rules are kept when parsing fails
+func handleDictionaryChange() {
+	dictionaryMu.Lock()
+	defer dictionaryMu.Unlock()
+	d, err := dictionary.Load(dictionaryFile)
+	if err != nil {
+		network.LogInfo("用户词典重新加载失败: %v", err)
+		return
+	}
+	// 通过 API 保存的修改已经生效 / Changes saved through the API are already in effect
+	if slices.Equal(d.Rules(), userDictionary.Rules()) {
+		return
+	}
+	setDictionary(d)
+	network.LogInfo("用户词典已重新加载: %d 条规则", d.Len())
+}
+
+// setDictionary 使词典生效（调用方需持有 dictionaryMu，启动时除外）
+// setDictionary puts the dictionary into effect (the caller holds dictionaryMu, except at startup)
+func setDictionary(d *dictionary.Dictionary) {
+	userDictionary = d
+	contentState.SetDictionary(d)
+}
+
+// updateDictionary 编译修改后的规则，写入词典文件并使其生效；规则无效时返回 400，写入失败时返回 500
+// updateDictionary compiles the modified rules, writes them to the dictionary file and puts them into effect;
+// it answers 400 for invalid rules and 500 when writing fails
+func updateDictionary(w http.ResponseWriter, status int, rules []dictionary.Rule) {
+	d, err := dictionary.Compile(rules)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := dictionary.Save(dictionaryFile, d); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setDictionary(d)
+	network.LogFormat("处理", "HTTP", "服务端", "用户词典已更新: %d 条规则", d.Len())
+	writeJSON(w, status, dictionarySnapshot())
+}
+
+// dictionarySnapshot 返回当前词典的响应体（调用方需持有 dictionaryMu）
+// dictionarySnapshot returns the response body for the current dictionary (the caller holds dictionaryMu)
+func dictionarySnapshot() dictionaryResponse {
+	settingsMu.RLock()
+	live := appConfig.Dictionary.Live
+	settingsMu.RUnlock()
+	return dictionaryResponse{
+		File:  dictionaryFile,
+		Live:  live,
+		Rules: userDictionary.Rules(),
+	}
+}
+
+// handleDictionary 处理 /api/dictionary：GET 列出规则，POST 追加一条规则，PUT 替换全部规则
+// handleDictionary handles /api/dictionary: GET lists the rules, POST appends a rule, PUT replaces all rules
+func handleDictionary(w http.ResponseWriter, r *http.Request) {
+	dictionaryMu.Lock()
+	defer dictionaryMu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, dictionarySnapshot())
+
+	case http.MethodPost:
+		var rule dictionary.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		updateDictionary(w, http.StatusCreated, append(userDictionary.Rules(), rule))
+
+	case http.MethodPut:
+		var req dictionaryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		updateDictionary(w, http.StatusOK, req.Rules)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDictionaryRule 处理 /api/dictionary/{index}（序号从 0 开始）：PUT 修改、DELETE 删除一条规则
+// handleDictionaryRule handles /api/dictionary/{index} (zero-based): PUT updates and DELETE removes one rule
+func handleDictionaryRule(w http.ResponseWriter, r *http.Request) {
+	dictionaryMu.Lock()
+	defer dictionaryMu.Unlock()
+
+	rules := userDictionary.Rules()
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 || index >= len(rules) {
+		http.Error(w, "规则不存在", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var rule dictionary.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		rules[index] = rule
+		updateDictionary(w, http.StatusOK, rules)
+
+	case http.MethodDelete:
+		updateDictionary(w, http.StatusOK, slices.Delete(rules, index, index+1))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,73 @@
+// Package main 提供从环境变量读取命令行参数默认值的辅助函数
+// 优先级：命令行参数 > 环境变量 > 内置默认值
+// Package main provides helpers that read command line flag defaults from environment variables
+// Precedence: command line flags > environment variables > built-in defaults
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envPrefix 是所有环境变量的前缀
+// envPrefix is the prefix of every environment variable
+const envPrefix = "AIRINPUT_"
+
+// envString 读取字符串环境变量，未设置时返回默认值
+// envString reads a string environment variable, returning def when unset
+func envString(name, def string) string {
+	if value, ok := os.LookupEnv(envPrefix + name); ok {
+		return value
+	}
+	return def
+}
+
+// envInt 读取整数环境变量，格式错误时直接退出
+// envInt reads an integer environment variable and exits on a malformed value
+func envInt(name string, def int) int {
+	value, ok := os.LookupEnv(envPrefix + name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("环境变量 %s%s 格式错误: %v", envPrefix, name, err)
+	}
+	return n
+}
+
+// envBool 读取布尔环境变量（1/true/yes 等），格式错误时直接退出
+// envBool reads a boolean environment variable (1/true/yes...) and exits on a malformed value
+func envBool(name string, def bool) bool {
+	value, ok := os.LookupEnv(envPrefix + name)
+	if !ok {
+		return def
+	}
+	switch value {
+	case "yes", "on":
+		return true
+	case "no", "off":
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("环境变量 %s%s 格式错误: %v", envPrefix, name, err)
+	}
+	return b
+}
+
+// envDuration 读取时长环境变量（如 2s、500ms），格式错误时直接退出
+// envDuration reads a duration environment variable (such as 2s or 500ms) and exits on a malformed value
+func envDuration(name string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(envPrefix + name)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("环境变量 %s%s 格式错误: %v", envPrefix, name, err)
+	}
+	return d
+}
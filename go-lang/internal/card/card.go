@@ -0,0 +1,30 @@
+// Package card 定义结构化的历史卡片；不依赖其他内部包，state 和 network 都可以引用
+// Package card defines structured history cards; it depends on no other internal package so both state and network can use it
+package card
+
+import "time"
+
+// SegmentCause 表示卡片的分段原因
+// SegmentCause represents why a card was segmented
+type SegmentCause string
+
+const (
+	CauseMobile SegmentCause = "mobile" // 手机端触发分段（单次输入模式） / Triggered by mobile (single input mode)
+	CauseTimer  SegmentCause = "timer"  // 服务端定时器分段（连续输入模式） / Triggered by server timer (continuous input mode)
+	CauseManual SegmentCause = "manual" // 通过 REST API 手动创建 / Created manually via the REST API
+)
+
+// Card 表示一张历史卡片
+// Card represents a history card
+type Card struct {
+	ID             string       `json:"id"`                       // 稳定的卡片 ID / Stable card ID
+	Text           string       `json:"text"`                     // 过滤后的文本 / Filtered text
+	RawText        string       `json:"rawText"`                  // 生成该卡片的原始输入（分割时各片段相同） / Raw input that produced the card (shared by split parts)
+	CreatedAt      time.Time    `json:"createdAt"`                // 创建时间 / Creation time
+	UpdatedAt      time.Time    `json:"updatedAt"`                // 更新时间 / Last update time
+	SourceClientID string       `json:"sourceClientId,omitempty"` // 来源客户端 ID / Source client ID
+	SourceIP       string       `json:"sourceIp,omitempty"`       // 来源 IP / Source IP
+	DeviceID       string       `json:"deviceId,omitempty"`       // 来源手机端设备 ID / Source mobile device ID
+	DeviceName     string       `json:"deviceName,omitempty"`     // 来源手机端设备名称 / Source mobile device name
+	Cause          SegmentCause `json:"cause,omitempty"`          // 分段原因 / Segmentation cause
+}
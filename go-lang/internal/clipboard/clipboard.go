@@ -0,0 +1,73 @@
+// Package clipboard 提供可替换后端的系统剪贴板写入功能
+// Package clipboard provides system clipboard writing with pluggable backends
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable 表示当前系统没有可用的剪贴板后端
+// ErrUnavailable indicates no clipboard backend is available on this system
+var ErrUnavailable = errors.New("没有可用的剪贴板后端")
+
+// Backend 表示一个剪贴板后端
+// Backend represents a clipboard backend
+type Backend interface {
+	// Name 返回后端名称，用于日志和命令行参数
+	// Name returns the backend name, used for logging and command line flags
+	Name() string
+	// WriteText 将文本写入系统剪贴板
+	// WriteText writes text to the system clipboard
+	WriteText(text string) error
+}
+
+// New 按名称创建剪贴板后端；名称为空或 "auto" 时自动检测当前平台可用的后端
+// New creates a clipboard backend by name; an empty name or "auto" detects the backend available on this platform
+func New(name string) (Backend, error) {
+	switch name {
+	case "", "auto":
+		return detect()
+	case "memory":
+		return NewMemory(), nil
+	}
+	for _, backend := range platformBackends() {
+		if backend.Name() == name {
+			return backend, nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的剪贴板后端: %s", name)
+}
+
+// commandBackend 通过外部命令（从标准输入读取文本）写入剪贴板
+// commandBackend writes to the clipboard through an external command that reads the text from stdin
+type commandBackend struct {
+	name string
+	args []string
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (b *commandBackend) Name() string {
+	return b.name
+}
+
+// WriteText 运行外部命令并通过标准输入传入文本
+// WriteText runs the external command and passes the text on stdin
+func (b *commandBackend) WriteText(text string) error {
+	cmd := exec.Command(b.args[0], b.args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s 写入剪贴板失败: %w %s", b.name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// available 判断外部命令是否已安装
+// available reports whether the external command is installed
+func (b *commandBackend) available() bool {
+	_, err := exec.LookPath(b.args[0])
+	return err == nil
+}
@@ -0,0 +1,23 @@
+// Package clipboard 提供 macOS 平台的剪贴板后端（pbcopy）
+// Package clipboard provides the clipboard backend for macOS (pbcopy)
+//go:build darwin
+
+package clipboard
+
+// platformBackends 返回 macOS 支持的后端
+// platformBackends returns the backends supported on macOS
+func platformBackends() []Backend {
+	return []Backend{
+		&commandBackend{name: "pbcopy", args: []string{"pbcopy"}},
+	}
+}
+
+// detect 返回 pbcopy 后端（系统自带）
+// detect returns the pbcopy backend (shipped with the system)
+func detect() (Backend, error) {
+	backend := platformBackends()[0].(*commandBackend)
+	if !backend.available() {
+		return nil, ErrUnavailable
+	}
+	return backend, nil
+}
@@ -0,0 +1,39 @@
+// Package clipboard 提供 Linux 平台的剪贴板后端（wl-copy / xclip / xsel）
+// Package clipboard provides clipboard backends for Linux (wl-copy / xclip / xsel)
+//go:build linux
+
+package clipboard
+
+import "os"
+
+// platformBackends 返回 Linux 支持的后端（按优先级排序）
+// platformBackends returns the backends supported on Linux, in priority order
+func platformBackends() []Backend {
+	return []Backend{
+		&commandBackend{name: "wl-copy", args: []string{"wl-copy"}},
+		&commandBackend{name: "xclip", args: []string{"xclip", "-selection", "clipboard"}},
+		&commandBackend{name: "xsel", args: []string{"xsel", "--clipboard", "--input"}},
+	}
+}
+
+// detect 根据当前会话类型选择已安装的剪贴板工具
+// Wayland 会话优先使用 wl-copy，X11 会话使用 xclip 或 xsel
+// detect picks an installed clipboard tool based on the session type
+// Wayland sessions prefer wl-copy; X11 sessions use xclip or xsel
+func detect() (Backend, error) {
+	wayland := os.Getenv("WAYLAND_DISPLAY") != ""
+	x11 := os.Getenv("DISPLAY") != ""
+	for _, backend := range platformBackends() {
+		cb := backend.(*commandBackend)
+		if cb.name == "wl-copy" && !wayland {
+			continue
+		}
+		if cb.name != "wl-copy" && !x11 {
+			continue
+		}
+		if cb.available() {
+			return cb, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
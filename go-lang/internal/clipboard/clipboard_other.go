@@ -0,0 +1,17 @@
+// Package clipboard 在不支持的平台上只提供内存后端
+// Package clipboard offers only the in-memory backend on unsupported platforms
+//go:build !linux && !darwin && !windows
+
+package clipboard
+
+// platformBackends 不支持的平台没有系统后端
+// platformBackends returns no system backends on unsupported platforms
+func platformBackends() []Backend {
+	return nil
+}
+
+// detect 不支持的平台始终返回 ErrUnavailable
+// detect always returns ErrUnavailable on unsupported platforms
+func detect() (Backend, error) {
+	return nil, ErrUnavailable
+}
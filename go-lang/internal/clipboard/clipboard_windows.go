@@ -0,0 +1,115 @@
+// Package clipboard 提供 Windows 平台的剪贴板后端（Win32 API）
+// Package clipboard provides the clipboard backend for Windows (Win32 API)
+//go:build windows
+
+package clipboard
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Windows 常量定义 / Windows constants
+const (
+	CF_UNICODETEXT = 13
+	GMEM_MOVEABLE  = 0x0002
+)
+
+// 动态加载 user32.dll 和 kernel32.dll / Dynamically load user32.dll and kernel32.dll
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procOpenClipboard    = user32.NewProc("OpenClipboard")
+	procCloseClipboard   = user32.NewProc("CloseClipboard")
+	procEmptyClipboard   = user32.NewProc("EmptyClipboard")
+	procSetClipboardData = user32.NewProc("SetClipboardData")
+	procGlobalAlloc      = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree       = kernel32.NewProc("GlobalFree")
+	procGlobalLock       = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock     = kernel32.NewProc("GlobalUnlock")
+	procRtlMoveMemory    = kernel32.NewProc("RtlMoveMemory")
+)
+
+// win32Backend 使用 Win32 剪贴板 API 写入 Unicode 文本
+// win32Backend writes Unicode text using the Win32 clipboard API
+type win32Backend struct{}
+
+// platformBackends 返回 Windows 支持的后端
+// platformBackends returns the backends supported on Windows
+func platformBackends() []Backend {
+	return []Backend{win32Backend{}}
+}
+
+// detect 返回 Win32 后端（系统自带）
+// detect returns the Win32 backend (always available)
+func detect() (Backend, error) {
+	return win32Backend{}, nil
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (win32Backend) Name() string {
+	return "win32"
+}
+
+// WriteText 将文本以 CF_UNICODETEXT 格式写入剪贴板
+// WriteText writes the text to the clipboard as CF_UNICODETEXT
+func (win32Backend) WriteText(text string) error {
+	// 剪贴板的打开与关闭必须在同一线程 / The clipboard must be opened and closed on the same thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// UTF-16 字符串不能包含 NUL / UTF-16 strings cannot contain NUL
+	data, err := syscall.UTF16FromString(strings.ReplaceAll(text, "\x00", ""))
+	if err != nil {
+		return err
+	}
+
+	if err := openClipboard(); err != nil {
+		return err
+	}
+	defer procCloseClipboard.Call()
+
+	if r, _, err := procEmptyClipboard.Call(); r == 0 {
+		return fmt.Errorf("清空剪贴板失败: %w", err)
+	}
+
+	size := uintptr(len(data)) * unsafe.Sizeof(data[0])
+	hMem, _, err := procGlobalAlloc.Call(GMEM_MOVEABLE, size)
+	if hMem == 0 {
+		return fmt.Errorf("分配剪贴板内存失败: %w", err)
+	}
+	ptr, _, err := procGlobalLock.Call(hMem)
+	if ptr == 0 {
+		procGlobalFree.Call(hMem)
+		return fmt.Errorf("锁定剪贴板内存失败: %w", err)
+	}
+	procRtlMoveMemory.Call(ptr, uintptr(unsafe.Pointer(&data[0])), size)
+	procGlobalUnlock.Call(hMem)
+
+	// 成功后内存归系统所有，不能再释放 / On success the system owns the memory; do not free it
+	if r, _, err := procSetClipboardData.Call(CF_UNICODETEXT, hMem); r == 0 {
+		procGlobalFree.Call(hMem)
+		return fmt.Errorf("写入剪贴板失败: %w", err)
+	}
+	return nil
+}
+
+// openClipboard 打开剪贴板，被其他程序占用时短暂重试
+// openClipboard opens the clipboard, retrying briefly while another program holds it
+func openClipboard() error {
+	var err error
+	for i := 0; i < 10; i++ {
+		var r uintptr
+		r, _, err = procOpenClipboard.Call(0)
+		if r != 0 {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fmt.Errorf("打开剪贴板失败: %w", err)
+}
@@ -0,0 +1,54 @@
+// Package clipboard 提供内存剪贴板后端，用于测试和无图形界面的环境
+// Package clipboard provides an in-memory clipboard backend for tests and headless environments
+package clipboard
+
+import "sync"
+
+// Memory 是只在内存中保存文本的剪贴板后端
+// Memory is a clipboard backend that keeps text in memory only
+type Memory struct {
+	mu      sync.Mutex
+	history []string
+}
+
+// NewMemory 创建内存剪贴板后端
+// NewMemory creates an in-memory clipboard backend
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// WriteText 记录写入的文本
+// WriteText records the written text
+func (m *Memory) WriteText(text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = append(m.history, text)
+	return nil
+}
+
+// Text 返回最后一次写入的文本
+// Text returns the most recently written text
+func (m *Memory) Text() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.history) == 0 {
+		return ""
+	}
+	return m.history[len(m.history)-1]
+}
+
+// History 返回全部写入记录的副本
+// History returns a copy of every write
+func (m *Memory) History() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := make([]string, len(m.history))
+	copy(history, m.history)
+	return history
+}
@@ -0,0 +1,313 @@
+// Package config 提供 JSON 配置文件的读取、校验与比较
+// Package config provides loading, validation and comparison of the JSON config file
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"airinputlan/internal/network"
+	"airinputlan/internal/textfilter"
+)
+
+// 默认值 / Defaults
+const (
+	DefaultSegmentInterval  = 2 * time.Second    // 默认分段间隔
+	DefaultMaxCardCount     = 50                 // 默认最大卡片数量
+	DefaultMaxCardLength    = 1000               // 默认最大卡片长度（字符数）
+	DefaultSplit            = "sentence"         // 默认超长卡片分割方式
+	DefaultHistoryRetention = 7 * 24 * time.Hour // 默认历史卡片保留时长
+	DefaultHostname         = "airinput"         // 默认 mDNS 主机名
+	DefaultMaxPhones        = 1                  // 默认同时连接的手机端上限
+)
+
+// Duration 是以字符串（如 "2s"、"5m"）序列化的时长
+// Duration is a time.Duration serialized as a string such as "2s" or "5m"
+type Duration time.Duration
+
+// MarshalJSON 将时长编码为字符串
+// MarshalJSON encodes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON 解析字符串时长（也接受以纳秒为单位的数字）
+// UnmarshalJSON parses a string duration (numbers in nanoseconds are accepted too)
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("无效的时长: %s", data)
+		}
+		*d = Duration(n)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ServerConfig 服务设置（修改后需重启）
+// ServerConfig holds the server settings (restart required)
+type ServerConfig struct {
+	Port       int    `json:"port"`       // 监听端口，0 表示从 5000 开始自动选择
+	Bind       string `json:"bind"`       // 监听地址
+	Iface      string `json:"iface"`      // 默认网卡名称
+	IP         string `json:"ip"`         // 默认访问 IP
+	NoBrowser  bool   `json:"noBrowser"`  // 启动时不自动打开浏览器
+	TLS        bool   `json:"tls"`        // 启用 HTTPS
+	MDNS       bool   `json:"mdns"`       // 通过 mDNS 发布 <hostname>.local 和 _airinputlan._tcp 服务
+	Hostname   string `json:"hostname"`   // mDNS 主机名（不含 .local）
+	QRHostname bool   `json:"qrHostname"` // 二维码和终端地址使用 .local 主机名代替 IP
+	MaxPhones  int    `json:"maxPhones"`  // 同时连接的手机端数量上限，0 表示不限制（可实时生效，只影响新连接）
+}
+
+// SegmentConfig 分段设置（可实时生效）
+// SegmentConfig holds the segmentation settings (applied live)
+type SegmentConfig struct {
+	Interval      Duration `json:"interval"`      // 连续输入模式的分段间隔
+	MaxCards      int      `json:"maxCards"`      // 最大卡片数量
+	MaxCardLength int      `json:"maxCardLength"` // 最大卡片长度（字符数）
+	Split         string   `json:"split"`         // 超长卡片的分割方式: sentence（优先在句子、分句、空白处分割）, fixed（按最大长度截断）
+}
+
+// FilterConfig 内容过滤设置（可实时生效）
+// FilterConfig holds the content filtering settings (applied live)
+type FilterConfig struct {
+	TrimLeadingPunctuation bool                `json:"trimLeadingPunctuation"` // 去除开头的标点（为 false 时从方案中去掉 trim_leading_punct）
+	DropMeaningless        bool                `json:"dropMeaningless"`        // 丢弃单独的标点等无意义内容
+	Profile                string              `json:"profile"`                // 使用的过滤方案: raw, default, clean, chat 或 profiles 中的自定义方案
+	Profiles               map[string][]string `json:"profiles,omitempty"`     // 自定义过滤方案：方案名称 → 按顺序执行的过滤器名称
+}
+
+// Filters 返回所选方案包含的过滤器名称（已按 TrimLeadingPunctuation 调整）
+// Filters returns the filter names of the selected profile (adjusted for TrimLeadingPunctuation)
+func (f FilterConfig) Filters() ([]string, error) {
+	names, err := textfilter.Profile(f.Profile, f.Profiles)
+	if err != nil {
+		return nil, err
+	}
+	if f.TrimLeadingPunctuation {
+		return names, nil
+	}
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != textfilter.TrimLeadingPunct {
+			kept = append(kept, name)
+		}
+	}
+	return kept, nil
+}
+
+// SecurityConfig 安全设置（修改后需重启）
+// SecurityConfig holds the security settings (restart required)
+type SecurityConfig struct {
+	PINTTL     Duration `json:"pinTTL"`     // 配对码有效期
+	SessionTTL Duration `json:"sessionTTL"` // 配对会话有效期
+}
+
+// OutputConfig 输出设置
+// OutputConfig holds the output settings
+type OutputConfig struct {
+	Clipboard     string `json:"clipboard"`     // 剪贴板后端
+	AutoCopy      bool   `json:"autoCopy"`      // 自动复制新卡片
+	Inject        string `json:"inject"`        // 键盘输入模式: off, card, live
+	InjectBackend string `json:"injectBackend"` // 键盘输入后端
+}
+
+// HistoryConfig 历史记录设置（修改后需重启）
+// HistoryConfig holds the history settings (restart required)
+type HistoryConfig struct {
+	Enabled   bool     `json:"enabled"`   // 持久化历史卡片
+	File      string   `json:"file"`      // 历史记录文件路径，空表示默认位置
+	Retention Duration `json:"retention"` // 保留时长，0 表示永久保留
+}
+
+// DictionaryConfig 用户词典设置
+// DictionaryConfig holds the user dictionary settings
+type DictionaryConfig struct {
+	File string `json:"file"` // 词典文件路径，空表示默认位置（修改后需重启）
+	Live bool   `json:"live"` // 实时输入也应用词典（可实时生效）
+}
+
+// Config 表示完整的配置文件
+// Config represents the whole config file
+type Config struct {
+	Server     ServerConfig     `json:"server"`
+	Segment    SegmentConfig    `json:"segment"`
+	Filter     FilterConfig     `json:"filter"`
+	Security   SecurityConfig   `json:"security"`
+	Output     OutputConfig     `json:"output"`
+	History    HistoryConfig    `json:"history"`
+	Dictionary DictionaryConfig `json:"dictionary"`
+}
+
+// Default 返回内置默认配置
+// Default returns the built-in default config
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Bind:      "::",
+			MDNS:      true,
+			Hostname:  DefaultHostname,
+			MaxPhones: DefaultMaxPhones,
+		},
+		Segment: SegmentConfig{
+			Interval:      Duration(DefaultSegmentInterval),
+			MaxCards:      DefaultMaxCardCount,
+			MaxCardLength: DefaultMaxCardLength,
+			Split:         DefaultSplit,
+		},
+		Filter: FilterConfig{
+			TrimLeadingPunctuation: true,
+			DropMeaningless:        true,
+			Profile:                textfilter.DefaultProfile,
+		},
+		Security: SecurityConfig{
+			PINTTL:     Duration(network.DefaultPINTTL),
+			SessionTTL: Duration(network.DefaultSessionTTL),
+		},
+		Output: OutputConfig{
+			Clipboard:     "auto",
+			Inject:        "off",
+			InjectBackend: "auto",
+		},
+		History: HistoryConfig{
+			Retention: Duration(DefaultHistoryRetention),
+		},
+	}
+}
+
+// DefaultPath 返回默认的配置文件路径（用户配置目录下的 airinputlan/config.json）
+// DefaultPath returns the default config file path (airinputlan/config.json under the user config dir)
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户配置目录失败: %w", err)
+	}
+	return filepath.Join(configDir, "airinputlan", "config.json"), nil
+}
+
+// Load 读取配置文件；文件中缺少的字段使用默认值，未知字段视为错误
+// Load reads the config file; missing fields take their defaults and unknown fields are an error
+func Load(path string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Default(), fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
+
+// LoadOrCreate 读取配置文件，文件不存在时写入默认配置便于用户修改
+// LoadOrCreate reads the config file, writing the defaults when it does not exist so users have something to edit
+func LoadOrCreate(path string) (Config, error) {
+	cfg, err := Load(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return cfg, err
+	}
+	if err := Save(path, cfg); err != nil {
+		return cfg, err
+	}
+	network.LogInfo("已创建默认配置文件: %s", path)
+	return cfg, nil
+}
+
+// Save 以缩进 JSON 格式写入配置文件
+// Save writes the config file as indented JSON
+func Save(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// Validate 检查配置取值范围
+// Validate checks the ranges of the config values
+func (c Config) Validate() error {
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("无效的端口: %d", c.Server.Port)
+	}
+	if net.ParseIP(c.Server.Bind) == nil {
+		return fmt.Errorf("无效的监听地址: %s", c.Server.Bind)
+	}
+	if !validHostname(c.Server.Hostname) {
+		return fmt.Errorf("无效的主机名: %q（只能包含字母、数字和连字符，不含 .local）", c.Server.Hostname)
+	}
+	if c.Server.MaxPhones < 0 {
+		return fmt.Errorf("无效的手机端数量上限: %d", c.Server.MaxPhones)
+	}
+	if c.Segment.Interval <= 0 {
+		return fmt.Errorf("无效的分段间隔: %v", time.Duration(c.Segment.Interval))
+	}
+	if c.Segment.MaxCards <= 0 {
+		return fmt.Errorf("无效的最大卡片数量: %d", c.Segment.MaxCards)
+	}
+	if c.Segment.MaxCardLength <= 0 {
+		return fmt.Errorf("无效的最大卡片长度: %d", c.Segment.MaxCardLength)
+	}
+	switch c.Segment.Split {
+	case "sentence", "fixed":
+	default:
+		return fmt.Errorf("无效的卡片分割方式: %s", c.Segment.Split)
+	}
+	for name, filters := range c.Filter.Profiles {
+		if _, err := textfilter.New(filters); err != nil {
+			return fmt.Errorf("过滤方案 %s: %w", name, err)
+		}
+	}
+	if _, err := c.Filter.Filters(); err != nil {
+		return err
+	}
+	if c.Security.PINTTL <= 0 || c.Security.SessionTTL <= 0 {
+		return fmt.Errorf("配对码和会话有效期必须大于 0")
+	}
+	switch c.Output.Inject {
+	case "off", "card", "live":
+	default:
+		return fmt.Errorf("无效的键盘输入模式: %s", c.Output.Inject)
+	}
+	if c.History.Retention < 0 {
+		return fmt.Errorf("无效的历史保留时长: %v", time.Duration(c.History.Retention))
+	}
+	return nil
+}
+
+// validHostname 检查 mDNS 主机名是否为合法的单个 DNS 标签
+// validHostname checks that the mDNS host name is a valid single DNS label
+func validHostname(name string) bool {
+	if name == "" || len(name) > 63 || name[0] == '-' || name[len(name)-1] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   string // 空表示合法 / Empty means valid
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"port zero picks automatically", func(c *Config) { c.Server.Port = 0 }, ""},
+		{"highest port", func(c *Config) { c.Server.Port = 65535 }, ""},
+		{"negative port", func(c *Config) { c.Server.Port = -1 }, "无效的端口"},
+		{"port too large", func(c *Config) { c.Server.Port = 65536 }, "无效的端口"},
+		{"ipv4 bind", func(c *Config) { c.Server.Bind = "0.0.0.0" }, ""},
+		{"bind host name", func(c *Config) { c.Server.Bind = "localhost" }, "无效的监听地址"},
+		{"hostname with hyphen", func(c *Config) { c.Server.Hostname = "air-input2" }, ""},
+		{"hostname with .local", func(c *Config) { c.Server.Hostname = "airinput.local" }, "无效的主机名"},
+		{"hostname starts with hyphen", func(c *Config) { c.Server.Hostname = "-air" }, "无效的主机名"},
+		{"empty hostname", func(c *Config) { c.Server.Hostname = "" }, "无效的主机名"},
+		{"hostname too long", func(c *Config) { c.Server.Hostname = strings.Repeat("a", 64) }, "无效的主机名"},
+		{"unlimited phones", func(c *Config) { c.Server.MaxPhones = 0 }, ""},
+		{"negative phones", func(c *Config) { c.Server.MaxPhones = -1 }, "无效的手机端数量上限"},
+		{"zero interval", func(c *Config) { c.Segment.Interval = 0 }, "无效的分段间隔"},
+		{"zero max cards", func(c *Config) { c.Segment.MaxCards = 0 }, "无效的最大卡片数量"},
+		{"zero max card length", func(c *Config) { c.Segment.MaxCardLength = 0 }, "无效的最大卡片长度"},
+		{"fixed split", func(c *Config) { c.Segment.Split = "fixed" }, ""},
+		{"unknown split", func(c *Config) { c.Segment.Split = "word" }, "无效的卡片分割方式"},
+		{"unknown profile", func(c *Config) { c.Filter.Profile = "nope" }, "nope"},
+		{"custom profile", func(c *Config) {
+			c.Filter.Profiles = map[string][]string{"mine": {"trim_leading_punct"}}
+			c.Filter.Profile = "mine"
+		}, ""},
+		{"unknown filter in profile", func(c *Config) {
+			c.Filter.Profiles = map[string][]string{"mine": {"no_such_filter"}}
+		}, "过滤方案 mine"},
+		{"zero pin ttl", func(c *Config) { c.Security.PINTTL = 0 }, "有效期必须大于 0"},
+		{"zero session ttl", func(c *Config) { c.Security.SessionTTL = 0 }, "有效期必须大于 0"},
+		{"live inject", func(c *Config) { c.Output.Inject = "live" }, ""},
+		{"unknown inject", func(c *Config) { c.Output.Inject = "type" }, "无效的键盘输入模式"},
+		{"keep history forever", func(c *Config) { c.History.Retention = 0 }, ""},
+		{"negative retention", func(c *Config) { c.History.Retention = Duration(-time.Hour) }, "无效的历史保留时长"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	data, err := json.Marshal(Duration(1500 * time.Millisecond))
+	if err != nil || string(data) != `"1.5s"` {
+		t.Errorf("Marshal = %s, %v; want \"1.5s\"", data, err)
+	}
+
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{`"2s"`, 2 * time.Second, false},
+		{`"168h0m0s"`, 7 * 24 * time.Hour, false},
+		{`"500ms"`, 500 * time.Millisecond, false},
+		{`"0s"`, 0, false},
+		{`1000000000`, time.Second, false},
+		{`"2 seconds"`, 0, true},
+		{`true`, 0, true},
+		{`1.5`, 0, true},
+	}
+	for _, tt := range tests {
+		var d Duration
+		err := json.Unmarshal([]byte(tt.in), &d)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && time.Duration(d) != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, time.Duration(d), tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    func(c *Config) // 在默认配置上的修改 / Changes on top of the defaults
+		wantErr string
+	}{
+		{"empty object keeps defaults", `{}`, func(c *Config) {}, ""},
+		{
+			name:    "partial sections merge with defaults",
+			content: `{"segment": {"interval": "3s"}, "server": {"port": 6000}}`,
+			want: func(c *Config) {
+				c.Segment.Interval = Duration(3 * time.Second)
+				c.Server.Port = 6000
+			},
+		},
+		{"unknown field", `{"server": {"prot": 6000}}`, nil, "解析配置文件失败"},
+		{"bad duration", `{"segment": {"interval": "soon"}}`, nil, "解析配置文件失败"},
+		{"invalid value", `{"segment": {"split": "word"}}`, nil, "无效的卡片分割方式"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(got, Default()) {
+					t.Error("Load did not fall back to the defaults on error")
+				}
+				return
+			}
+			want := Default()
+			tt.want(&want)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("Load = %+v, %v; want %+v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.json")
+	if _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	created, err := LoadOrCreate(path)
+	if err != nil || !reflect.DeepEqual(created, Default()) {
+		t.Fatalf("LoadOrCreate = %+v, %v; want the defaults", created, err)
+	}
+
+	cfg := Default()
+	cfg.Segment.Interval = Duration(750 * time.Millisecond)
+	cfg.Filter.Profiles = map[string][]string{"mine": {"trim_leading_punct"}}
+	cfg.History.Retention = 0
+	if err := Save(path, cfg); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"interval": "750ms"`) {
+		t.Errorf("saved file does not spell the interval as a string:\n%s", data)
+	}
+	loaded, err := Load(path)
+	if err != nil || !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("Load = %+v, %v; want %+v", loaded, err, cfg)
+	}
+}
@@ -0,0 +1,63 @@
+// Package config 提供配置比较：区分可实时生效和需要重启的修改
+// Package config provides config comparison: separates changes applied live from those needing a restart
+package config
+
+import "fmt"
+
+// field 描述一个可比较的配置项
+// field describes a comparable config setting
+type field struct {
+	name    string
+	restart bool // 修改后是否需要重启 / Whether a change requires a restart
+	get     func(Config) interface{}
+}
+
+// fields 列出所有配置项；新增配置时需同步添加
+// fields lists every setting; keep it in sync when adding settings
+var fields = []field{
+	{"server.port", true, func(c Config) interface{} { return c.Server.Port }},
+	{"server.bind", true, func(c Config) interface{} { return c.Server.Bind }},
+	{"server.iface", false, func(c Config) interface{} { return c.Server.Iface }},
+	{"server.ip", false, func(c Config) interface{} { return c.Server.IP }},
+	{"server.noBrowser", true, func(c Config) interface{} { return c.Server.NoBrowser }},
+	{"server.tls", true, func(c Config) interface{} { return c.Server.TLS }},
+	{"server.mdns", true, func(c Config) interface{} { return c.Server.MDNS }},
+	{"server.hostname", true, func(c Config) interface{} { return c.Server.Hostname }},
+	{"server.qrHostname", false, func(c Config) interface{} { return c.Server.QRHostname }},
+	{"server.maxPhones", false, func(c Config) interface{} { return c.Server.MaxPhones }},
+	{"segment.interval", false, func(c Config) interface{} { return c.Segment.Interval }},
+	{"segment.maxCards", false, func(c Config) interface{} { return c.Segment.MaxCards }},
+	{"segment.maxCardLength", false, func(c Config) interface{} { return c.Segment.MaxCardLength }},
+	{"segment.split", false, func(c Config) interface{} { return c.Segment.Split }},
+	{"filter.trimLeadingPunctuation", false, func(c Config) interface{} { return c.Filter.TrimLeadingPunctuation }},
+	{"filter.dropMeaningless", false, func(c Config) interface{} { return c.Filter.DropMeaningless }},
+	{"filter.profile", false, func(c Config) interface{} { return c.Filter.Profile }},
+	{"filter.profiles", false, func(c Config) interface{} { return fmt.Sprint(c.Filter.Profiles) }},
+	{"security.pinTTL", true, func(c Config) interface{} { return c.Security.PINTTL }},
+	{"security.sessionTTL", true, func(c Config) interface{} { return c.Security.SessionTTL }},
+	{"output.clipboard", false, func(c Config) interface{} { return c.Output.Clipboard }},
+	{"output.autoCopy", false, func(c Config) interface{} { return c.Output.AutoCopy }},
+	{"output.inject", true, func(c Config) interface{} { return c.Output.Inject }},
+	{"output.injectBackend", true, func(c Config) interface{} { return c.Output.InjectBackend }},
+	{"history.enabled", true, func(c Config) interface{} { return c.History.Enabled }},
+	{"history.file", true, func(c Config) interface{} { return c.History.File }},
+	{"history.retention", true, func(c Config) interface{} { return c.History.Retention }},
+	{"dictionary.file", true, func(c Config) interface{} { return c.Dictionary.File }},
+	{"dictionary.live", false, func(c Config) interface{} { return c.Dictionary.Live }},
+}
+
+// Diff 比较两份配置，返回可实时生效的修改和需要重启的修改（均为配置项名称）
+// Diff compares two configs and returns the live changes and the restart-required changes (as setting names)
+func Diff(old, new Config) (live, restart []string) {
+	for _, f := range fields {
+		if f.get(old) == f.get(new) {
+			continue
+		}
+		if f.restart {
+			restart = append(restart, f.name)
+		} else {
+			live = append(live, f.name)
+		}
+	}
+	return live, restart
+}
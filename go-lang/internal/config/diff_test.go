@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		live    []string
+		restart []string
+	}{
+		{"no change", func(c *Config) {}, nil, nil},
+		{"live setting", func(c *Config) { c.Segment.MaxCards = 10 }, []string{"segment.maxCards"}, nil},
+		{"restart setting", func(c *Config) { c.Server.Port = 6000 }, nil, []string{"server.port"}},
+		{"duration", func(c *Config) { c.Segment.Interval = Duration(3 * time.Second) }, []string{"segment.interval"}, nil},
+		{"restart duration", func(c *Config) { c.Security.PINTTL = Duration(time.Minute) }, nil, []string{"security.pinTTL"}},
+		{
+			name: "live and restart together",
+			mutate: func(c *Config) {
+				c.Server.MaxPhones = 3
+				c.Output.Inject = "card"
+				c.Dictionary.Live = true
+				c.Dictionary.File = "/tmp/words.json"
+			},
+			live:    []string{"server.maxPhones", "dictionary.live"},
+			restart: []string{"output.inject", "dictionary.file"},
+		},
+		{
+			name:   "profiles added",
+			mutate: func(c *Config) { c.Filter.Profiles = map[string][]string{"mine": {"trim_leading_punct"}} },
+			live:   []string{"filter.profiles"},
+		},
+		{
+			// nil 和空映射的 fmt.Sprint 结果相同 / nil and empty maps print the same
+			name:   "empty profiles equal nil",
+			mutate: func(c *Config) { c.Filter.Profiles = map[string][]string{} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(&cfg)
+			live, restart := Diff(Default(), cfg)
+			if !slices.Equal(live, tt.live) || !slices.Equal(restart, tt.restart) {
+				t.Errorf("Diff = live %q, restart %q; want live %q, restart %q", live, restart, tt.live, tt.restart)
+			}
+		})
+	}
+}
+
+func TestDiffProfiles(t *testing.T) {
+	// fmt.Sprint 按键排序输出映射，只有内容变化才算修改 / fmt.Sprint prints maps in key order, so only content changes count
+	a, b := Default(), Default()
+	a.Filter.Profiles = map[string][]string{"x": {"trim_leading_punct"}, "y": {}}
+	b.Filter.Profiles = map[string][]string{"y": {}, "x": {"trim_leading_punct"}}
+	if live, restart := Diff(a, b); live != nil || restart != nil {
+		t.Errorf("Diff of equal profiles = %q, %q; want none", live, restart)
+	}
+	b.Filter.Profiles["y"] = []string{"trim_leading_punct"}
+	if live, _ := Diff(a, b); !slices.Equal(live, []string{"filter.profiles"}) {
+		t.Errorf("Diff of changed profiles = %q, want [filter.profiles]", live)
+	}
+}
+
+func TestDiffFieldsAreComparable(t *testing.T) {
+	// Diff 用 == 比较 interface{}，不可比较的类型（切片、映射）会在运行时 panic，需先转换
+	// Diff compares interface{} values with ==, which panics on slices and maps, so those must be converted first
+	cfg := Default()
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if seen[f.name] {
+			t.Errorf("%s is listed twice", f.name)
+		}
+		seen[f.name] = true
+		if v := f.get(cfg); !reflect.TypeOf(v).Comparable() {
+			t.Errorf("%s returns %T, which is not comparable", f.name, v)
+		}
+	}
+
+	// 每个配置项都应出现在 fields 中 / Every setting should appear in fields
+	count := 0
+	configType := reflect.TypeOf(cfg)
+	for i := range configType.NumField() {
+		count += configType.Field(i).Type.NumField()
+	}
+	if count != len(fields) {
+		t.Errorf("Config has %d settings, fields lists %d", count, len(fields))
+	}
+}
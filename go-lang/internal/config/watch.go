@@ -0,0 +1,18 @@
+// Package config 提供配置文件的监听：文件变化时重新加载
+// Package config provides config file watching: the file is reloaded when it changes
+package config
+
+import (
+	"time"
+
+	"airinputlan/internal/filewatch"
+)
+
+// Watch 开始监听配置文件；onChange 在文件内容变化后被调用（解析失败时 err 非空）
+// Watch starts watching the config file; onChange is called after the contents change (err is set when parsing fails)
+func Watch(path string, interval time.Duration, onChange func(Config, error)) *filewatch.Poller {
+	return filewatch.Watch(path, interval, func() {
+		cfg, err := Load(path)
+		onChange(cfg, err)
+	})
+}
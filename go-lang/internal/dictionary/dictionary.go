@@ -0,0 +1,200 @@
+// Package dictionary 提供用户词典：按顺序执行的替换规则（原文、整词、正则），用于纠正语音输入听错的产品名和术语
+// Package dictionary provides the user dictionary: replacement rules (literal, whole-word, regex) applied in order,
+// used to fix product names and jargon that voice input keeps mishearing
+package dictionary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unicode/utf8"
+)
+
+// RuleType 表示规则的匹配方式
+// RuleType is how a rule matches
+type RuleType string
+
+const (
+	RuleLiteral RuleType = "literal" // 原样匹配 / Match the text as is
+	RuleWord    RuleType = "word"    // 整词匹配：以英文字母或数字开头、结尾时要求词边界 / Whole word: a word boundary is required at ends that are ASCII letters or digits
+	RuleRegex   RuleType = "regex"   // 正则匹配，替换文本可用 $1 引用分组 / Regular expression; the replacement may refer to groups with $1
+)
+
+// Rule 表示一条替换规则
+// Rule is one replacement rule
+type Rule struct {
+	Type       RuleType `json:"type"`                 // 匹配方式，空表示 literal / Match type; empty means literal
+	Match      string   `json:"match"`                // 要匹配的文本或正则 / Text or regular expression to match
+	Replace    string   `json:"replace"`              // 替换文本 / Replacement text
+	IgnoreCase bool     `json:"ignoreCase,omitempty"` // 忽略大小写 / Match case-insensitively
+}
+
+// file 表示词典文件的内容
+// file is the contents of the dictionary file
+type file struct {
+	Rules []Rule `json:"rules"`
+}
+
+// compiledRule 表示编译后的规则
+// compiledRule is a compiled rule
+type compiledRule struct {
+	pattern *regexp.Regexp
+	replace string
+	expand  bool // 替换文本是否展开 $1 等分组引用 / Whether $1 and other group references in the replacement are expanded
+}
+
+// Dictionary 表示编译后的词典（创建后只读，可并发使用）
+// Dictionary is a compiled dictionary (read-only once created, safe for concurrent use)
+type Dictionary struct {
+	rules    []Rule
+	compiled []compiledRule
+}
+
+// Compile 校验并编译规则，出错时返回包含规则序号（从 1 开始）的错误
+// Compile validates and compiles the rules, returning an error with the rule number (starting at 1) on failure
+func Compile(rules []Rule) (*Dictionary, error) {
+	d := &Dictionary{
+		rules:    make([]Rule, len(rules)),
+		compiled: make([]compiledRule, len(rules)),
+	}
+	for i, rule := range rules {
+		if rule.Type == "" {
+			rule.Type = RuleLiteral
+		}
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条规则: %w", i+1, err)
+		}
+		d.rules[i] = rule
+		d.compiled[i] = compiled
+	}
+	return d, nil
+}
+
+// compile 将一条规则编译为正则
+// compile turns one rule into a regular expression
+func compile(rule Rule) (compiledRule, error) {
+	if rule.Match == "" {
+		return compiledRule{}, errors.New("匹配文本为空")
+	}
+	var expr string
+	switch rule.Type {
+	case RuleLiteral:
+		expr = regexp.QuoteMeta(rule.Match)
+	case RuleWord:
+		expr = regexp.QuoteMeta(rule.Match)
+		// \b 只认英文字母、数字和下划线，中文两端不加边界 / \b only knows ASCII word characters, so CJK ends get no boundary
+		if first, _ := utf8.DecodeRuneInString(rule.Match); isWordChar(first) {
+			expr = `\b` + expr
+		}
+		if last, _ := utf8.DecodeLastRuneInString(rule.Match); isWordChar(last) {
+			expr += `\b`
+		}
+	case RuleRegex:
+		expr = rule.Match
+	default:
+		return compiledRule{}, fmt.Errorf("未知的规则类型: %s（可用: literal, word, regex）", rule.Type)
+	}
+	if rule.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("无效的正则: %w", err)
+	}
+	return compiledRule{pattern: pattern, replace: rule.Replace, expand: rule.Type == RuleRegex}, nil
+}
+
+// isWordChar 判断字符是否为正则 \b 所认的单词字符
+// isWordChar reports whether the character is a word character as far as \b is concerned
+func isWordChar(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'
+}
+
+// Replace 按顺序执行所有规则；nil 词典原样返回文本
+// Replace applies every rule in order; a nil dictionary returns the text unchanged
+func (d *Dictionary) Replace(text string) string {
+	if d == nil {
+		return text
+	}
+	for _, rule := range d.compiled {
+		if rule.expand {
+			text = rule.pattern.ReplaceAllString(text, rule.replace)
+		} else {
+			text = rule.pattern.ReplaceAllLiteralString(text, rule.replace)
+		}
+	}
+	return text
+}
+
+// Rules 返回规则列表的副本
+// Rules returns a copy of the rules
+func (d *Dictionary) Rules() []Rule {
+	if d == nil {
+		return []Rule{}
+	}
+	return append([]Rule{}, d.rules...)
+}
+
+// Len 返回规则数量
+// Len returns the number of rules
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.rules)
+}
+
+// DefaultPath 返回默认的词典文件路径（用户配置目录下的 airinputlan/dictionary.json）
+// DefaultPath returns the default dictionary file path (airinputlan/dictionary.json under the user config dir)
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户配置目录失败: %w", err)
+	}
+	return filepath.Join(configDir, "airinputlan", "dictionary.json"), nil
+}
+
+// Load 读取并编译词典文件；文件不存在时返回空词典
+// Load reads and compiles the dictionary file; a missing file yields an empty dictionary
+func Load(path string) (*Dictionary, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Compile(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取词典文件失败: %w", err)
+	}
+	var f file
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("解析词典文件失败: %w", err)
+	}
+	return Compile(f.Rules)
+}
+
+// Save 以缩进 JSON 格式写入词典文件（先写临时文件再改名，避免监听方读到写了一半的文件）
+// Save writes the dictionary file as indented JSON (via a temporary file and a rename, so watchers never read a half-written file)
+func Save(path string, d *Dictionary) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建词典目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(file{Rules: d.Rules()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("写入词典文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入词典文件失败: %w", err)
+	}
+	return nil
+}
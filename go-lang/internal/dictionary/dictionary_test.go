@@ -0,0 +1,206 @@
+package dictionary
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		in   string
+		want string
+	}{
+		{
+			name: "literal matches inside words",
+			rule: Rule{Match: "go", Replace: "Go"},
+			in:   "go and gopher",
+			want: "Go and Gopher",
+		},
+		{
+			name: "literal is case sensitive by default",
+			rule: Rule{Type: RuleLiteral, Match: "go", Replace: "Go"},
+			in:   "GO go",
+			want: "GO Go",
+		},
+		{
+			name: "literal escapes regex metacharacters",
+			rule: Rule{Type: RuleLiteral, Match: "c++", Replace: "C++"},
+			in:   "c++ 和 cxx",
+			want: "C++ 和 cxx",
+		},
+		{
+			name: "literal does not expand groups",
+			rule: Rule{Type: RuleLiteral, Match: "价格", Replace: "$1 元"},
+			in:   "价格",
+			want: "$1 元",
+		},
+		{
+			name: "word needs ascii boundaries",
+			rule: Rule{Type: RuleWord, Match: "go", Replace: "Go"},
+			in:   "go gopher ago go.",
+			want: "Go gopher ago Go.",
+		},
+		{
+			name: "word matches next to cjk",
+			rule: Rule{Type: RuleWord, Match: "k8s", Replace: "Kubernetes"},
+			in:   "部署到k8s集群，不是k8sx",
+			want: "部署到Kubernetes集群，不是k8sx",
+		},
+		{
+			name: "cjk word has no boundary",
+			rule: Rule{Type: RuleWord, Match: "微服", Replace: "微服务"},
+			in:   "拆成微服",
+			want: "拆成微服务",
+		},
+		{
+			name: "mixed word keeps the ascii end boundary",
+			rule: Rule{Type: RuleWord, Match: "杰森son", Replace: "JSON"},
+			in:   "返回杰森son格式，杰森sonic",
+			want: "返回JSON格式，杰森sonic",
+		},
+		{
+			name: "ignore case",
+			rule: Rule{Type: RuleWord, Match: "github", Replace: "GitHub", IgnoreCase: true},
+			in:   "GITHUB Github github",
+			want: "GitHub GitHub GitHub",
+		},
+		{
+			name: "regex expands groups",
+			rule: Rule{Type: RuleRegex, Match: `(\d+)\s*块钱`, Replace: "$1 元"},
+			in:   "一共 25块钱和 3 块钱",
+			want: "一共 25 元和 3 元",
+		},
+		{
+			name: "regex named group",
+			rule: Rule{Type: RuleRegex, Match: `v(?P<major>\d+)点(?P<minor>\d+)`, Replace: "v${major}.${minor}"},
+			in:   "升级到 v1点25",
+			want: "升级到 v1.25",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Compile([]Rule{tt.rule})
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := d.Replace(tt.in); got != tt.want {
+				t.Errorf("Replace(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceAppliesRulesInOrder(t *testing.T) {
+	d, err := Compile([]Rule{
+		{Match: "狗狼", Replace: "Golang"},
+		{Type: RuleWord, Match: "Golang", Replace: "Go"},
+	})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if got := d.Replace("用狗狼写"); got != "用Go写" {
+		t.Errorf("Replace = %q, want %q", got, "用Go写")
+	}
+
+	var nilDict *Dictionary
+	if got := nilDict.Replace("原样"); got != "原样" {
+		t.Errorf("nil Replace = %q, want the text unchanged", got)
+	}
+	if nilDict.Len() != 0 || len(nilDict.Rules()) != 0 {
+		t.Error("nil dictionary has rules")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  string
+	}{
+		{"empty match", []Rule{{Match: ""}}, "第 1 条规则: 匹配文本为空"},
+		{"unknown type", []Rule{{Match: "a"}, {Type: "glob", Match: "*"}}, "第 2 条规则: 未知的规则类型: glob"},
+		{"invalid regex", []Rule{{Type: RuleRegex, Match: "(unclosed"}}, "第 1 条规则: 无效的正则"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.rules)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCompileDefaultsToLiteral(t *testing.T) {
+	d, err := Compile([]Rule{{Match: "a.b", Replace: "x"}})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if got := d.Rules()[0].Type; got != RuleLiteral {
+		t.Errorf("Type = %q, want %q", got, RuleLiteral)
+	}
+	if got := d.Replace("a.b axb"); got != "x axb" {
+		t.Errorf("Replace = %q, want %q", got, "x axb")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "dictionary.json")
+
+	// 文件不存在时为空词典 / A missing file is an empty dictionary
+	d, err := Load(path)
+	if err != nil || d.Len() != 0 {
+		t.Fatalf("Load missing file = %d rules, %v; want 0, nil", d.Len(), err)
+	}
+
+	rules := []Rule{
+		{Type: RuleLiteral, Match: "狗狼", Replace: "Golang"},
+		{Type: RuleWord, Match: "github", Replace: "GitHub", IgnoreCase: true},
+		{Type: RuleRegex, Match: `(\d+)块钱`, Replace: "$1 元"},
+	}
+	saved, err := Compile(rules)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if err := Save(path, saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Save left the temporary file behind")
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !slices.Equal(loaded.Rules(), rules) {
+		t.Errorf("loaded rules = %+v, want %+v", loaded.Rules(), rules)
+	}
+	if got := loaded.Replace("用狗狼调 GITHUB 花了 5块钱"); got != "用Golang调 GitHub 花了 5 元" {
+		t.Errorf("Replace after Load = %q", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid json", `{"rules": [`, "解析词典文件失败"},
+		{"unknown field", `{"rules": [], "extra": true}`, "解析词典文件失败"},
+		{"invalid rule", `{"rules": [{"type": "regex", "match": "[", "replace": ""}]}`, "第 1 条规则"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "dictionary.json")
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package filewatch 提供文件的轮询监听（无需依赖平台相关的文件通知），供配置文件和用户词典共用
+// Package filewatch provides polling-based file watching (no platform-specific file notifications needed), shared by the config file and the user dictionary
+package filewatch
+
+import (
+	"os"
+	"time"
+)
+
+// Poller 定期检查文件的修改时间和大小，变化时通知调用方
+// Poller periodically checks a file's modification time and size and notifies the caller on change
+type Poller struct {
+	path     string
+	interval time.Duration
+	onChange func()
+	stop     chan struct{}
+}
+
+// Watch 开始监听文件；onChange 在文件内容变化后被调用，由调用方自行重新读取文件。
+// 文件被删除时不通知，等它重新出现后再通知
+// Watch starts watching the file; onChange is called after the contents change and the caller reads the file again.
+// Removing the file does not notify; the caller is notified once it reappears
+func Watch(path string, interval time.Duration, onChange func()) *Poller {
+	p := &Poller{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Stop 停止监听
+// Stop stops watching
+func (p *Poller) Stop() {
+	close(p.stop)
+}
+
+// run 轮询循环
+// run is the polling loop
+func (p *Poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	lastMod, lastSize := p.stat()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			mod, size := p.stat()
+			if mod.Equal(lastMod) && size == lastSize {
+				continue
+			}
+			lastMod, lastSize = mod, size
+			if mod.IsZero() {
+				// 文件被删除（或编辑器正在替换文件），等待重新出现 / File removed (or being replaced by an editor); wait for it
+				continue
+			}
+			p.onChange()
+		}
+	}
+}
+
+// stat 返回文件的修改时间和大小，文件不存在时返回零值
+// stat returns the file's modification time and size, or zero values when it does not exist
+func (p *Poller) stat() (time.Time, int64) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,47 @@
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchNotifiesOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watched.json")
+	if err := os.WriteFile(path, []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan struct{}, 10)
+	p := Watch(path, 10*time.Millisecond, func() { changed <- struct{}{} })
+	defer p.Stop()
+
+	expect := func(want bool, what string) {
+		t.Helper()
+		select {
+		case <-changed:
+			if !want {
+				t.Fatalf("%s: unexpected notification", what)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if want {
+				t.Fatalf("%s: no notification", what)
+			}
+		}
+	}
+
+	expect(false, "unchanged file")
+	if err := os.WriteFile(path, []byte("bb"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect(true, "rewritten file")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	expect(false, "removed file")
+	if err := os.WriteFile(path, []byte("ccc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expect(true, "recreated file")
+}
@@ -0,0 +1,108 @@
+// Package inject 提供键盘输入注入功能，将文字直接输入到当前获得焦点的应用
+// Package inject provides keystroke injection that types text straight into the focused application
+package inject
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable 表示当前系统没有可用的输入注入后端
+// ErrUnavailable indicates no keystroke injection backend is available on this system
+var ErrUnavailable = errors.New("没有可用的键盘输入后端")
+
+// Sink 表示一个键盘输入注入后端
+// Sink represents a keystroke injection backend
+type Sink interface {
+	// Name 返回后端名称，用于日志和命令行参数
+	// Name returns the backend name, used for logging and command line flags
+	Name() string
+	// TypeText 在当前焦点位置输入文本
+	// TypeText types the text at the current focus
+	TypeText(text string) error
+	// Backspace 删除光标前的 n 个字符
+	// Backspace deletes n characters before the cursor
+	Backspace(n int) error
+	// Close 释放后端占用的资源
+	// Close releases the resources held by the backend
+	Close() error
+}
+
+// New 按名称创建输入注入后端；名称为空或 "auto" 时自动检测当前平台可用的后端
+// New creates an injection backend by name; an empty name or "auto" detects the backend available on this platform
+func New(name string) (Sink, error) {
+	switch name {
+	case "", "auto":
+		return detect()
+	case "memory":
+		return NewMemory(), nil
+	}
+	return newPlatformSink(name)
+}
+
+// commandSink 通过外部命令输入文本（xdotool / ydotool / osascript）
+// commandSink types text through an external command (xdotool / ydotool / osascript)
+type commandSink struct {
+	name      string
+	typeArgs  func(text string) []string // 输入文本的命令行
+	eraseArgs func(n int) []string       // 删除 n 个字符的命令行
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (s *commandSink) Name() string {
+	return s.name
+}
+
+// TypeText 运行外部命令输入文本
+// TypeText runs the external command to type the text
+func (s *commandSink) TypeText(text string) error {
+	if text == "" {
+		return nil
+	}
+	return s.run(s.typeArgs(text))
+}
+
+// Backspace 运行外部命令删除字符
+// Backspace runs the external command to delete characters
+func (s *commandSink) Backspace(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	return s.run(s.eraseArgs(n))
+}
+
+// Close 外部命令后端无需释放资源
+// Close is a no-op for command backends
+func (s *commandSink) Close() error {
+	return nil
+}
+
+// run 执行命令并在失败时附带命令输出
+// run executes the command and includes its output on failure
+func (s *commandSink) run(args []string) error {
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s 输入失败: %w %s", s.name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// available 判断外部命令是否已安装
+// available reports whether the external command is installed
+func (s *commandSink) available() bool {
+	_, err := exec.LookPath(s.typeArgs("x")[0])
+	return err == nil
+}
+
+// repeatArgs 将同一参数重复 n 次，用于不支持重复次数参数的命令
+// repeatArgs repeats the same arguments n times for commands without a repeat option
+func repeatArgs(n int, args ...string) []string {
+	out := make([]string, 0, n*len(args))
+	for i := 0; i < n; i++ {
+		out = append(out, args...)
+	}
+	return out
+}
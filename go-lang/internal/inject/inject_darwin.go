@@ -0,0 +1,54 @@
+// Package inject 提供 macOS 平台的输入注入后端（osascript / System Events）
+// Package inject provides the injection backend for macOS (osascript / System Events)
+//go:build darwin
+
+package inject
+
+import "fmt"
+
+// osascriptSink 通过 System Events 模拟按键；需要在“辅助功能”中授权终端
+// osascriptSink simulates keystrokes through System Events; the terminal must be granted Accessibility access
+func osascriptSink() *commandSink {
+	return &commandSink{
+		name: "osascript",
+		// 文本通过 argv 传入，避免 AppleScript 转义问题 / Text is passed via argv to avoid AppleScript escaping
+		typeArgs: func(text string) []string {
+			return []string{"osascript",
+				"-e", "on run argv",
+				"-e", `tell application "System Events" to keystroke (item 1 of argv)`,
+				"-e", "end run",
+				text}
+		},
+		// 51 为 Delete 键 / 51 is the Delete key code
+		eraseArgs: func(n int) []string {
+			return []string{"osascript",
+				"-e", "on run argv",
+				"-e", `tell application "System Events"`,
+				"-e", "repeat (item 1 of argv as integer) times",
+				"-e", "key code 51",
+				"-e", "end repeat",
+				"-e", "end tell",
+				"-e", "end run",
+				fmt.Sprint(n)}
+		},
+	}
+}
+
+// newPlatformSink 按名称创建 macOS 后端
+// newPlatformSink creates a macOS backend by name
+func newPlatformSink(name string) (Sink, error) {
+	if name == "osascript" {
+		return osascriptSink(), nil
+	}
+	return nil, fmt.Errorf("不支持的键盘输入后端: %s", name)
+}
+
+// detect 返回 osascript 后端（系统自带）
+// detect returns the osascript backend (shipped with the system)
+func detect() (Sink, error) {
+	sink := osascriptSink()
+	if !sink.available() {
+		return nil, ErrUnavailable
+	}
+	return sink, nil
+}
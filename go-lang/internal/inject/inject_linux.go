@@ -0,0 +1,65 @@
+// Package inject 提供 Linux 平台的输入注入后端（uinput 虚拟键盘，xdotool / ydotool 兜底）
+// Package inject provides injection backends for Linux (uinput virtual keyboard, with xdotool / ydotool fallbacks)
+//go:build linux
+
+package inject
+
+import (
+	"fmt"
+	"os"
+)
+
+// commandSinks 返回 Linux 支持的外部命令后端
+// commandSinks returns the command backends supported on Linux
+func commandSinks() []*commandSink {
+	return []*commandSink{
+		{
+			name:     "xdotool",
+			typeArgs: func(text string) []string { return []string{"xdotool", "type", "--clearmodifiers", "--", text} },
+			eraseArgs: func(n int) []string {
+				return []string{"xdotool", "key", "--clearmodifiers", "--repeat", fmt.Sprint(n), "BackSpace"}
+			},
+		},
+		{
+			name:     "ydotool",
+			typeArgs: func(text string) []string { return []string{"ydotool", "type", "--", text} },
+			// 14 为 KEY_BACKSPACE，:1 按下、:0 抬起 / 14 is KEY_BACKSPACE; :1 presses, :0 releases
+			eraseArgs: func(n int) []string { return append([]string{"ydotool", "key"}, repeatArgs(n, "14:1", "14:0")...) },
+		},
+	}
+}
+
+// newPlatformSink 按名称创建 Linux 后端
+// newPlatformSink creates a Linux backend by name
+func newPlatformSink(name string) (Sink, error) {
+	if name == "uinput" {
+		sink, err := newUinputSink()
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	for _, sink := range commandSinks() {
+		if sink.name == name {
+			return sink, nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的键盘输入后端: %s", name)
+}
+
+// detect 优先使用 uinput 虚拟键盘（与显示服务器无关），没有权限时依次尝试 xdotool（X11）和 ydotool
+// detect prefers the uinput virtual keyboard (display-server agnostic) and falls back to xdotool (X11) then ydotool
+func detect() (Sink, error) {
+	if sink, err := newUinputSink(); err == nil {
+		return sink, nil
+	}
+	for _, sink := range commandSinks() {
+		if sink.name == "xdotool" && os.Getenv("DISPLAY") == "" {
+			continue
+		}
+		if sink.available() {
+			return sink, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
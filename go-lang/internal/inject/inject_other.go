@@ -0,0 +1,19 @@
+// Package inject 在不支持的平台上只提供内存后端
+// Package inject offers only the in-memory backend on unsupported platforms
+//go:build !linux && !darwin && !windows
+
+package inject
+
+import "fmt"
+
+// newPlatformSink 不支持的平台没有系统后端
+// newPlatformSink has no system backends on unsupported platforms
+func newPlatformSink(name string) (Sink, error) {
+	return nil, fmt.Errorf("不支持的键盘输入后端: %s", name)
+}
+
+// detect 不支持的平台始终返回 ErrUnavailable
+// detect always returns ErrUnavailable on unsupported platforms
+func detect() (Sink, error) {
+	return nil, ErrUnavailable
+}
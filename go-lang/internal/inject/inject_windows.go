@@ -0,0 +1,140 @@
+// Package inject 提供 Windows 平台的输入注入后端（SendInput Unicode 按键）
+// Package inject provides the injection backend for Windows (SendInput Unicode keystrokes)
+//go:build windows
+
+package inject
+
+import (
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// Windows 常量定义 / Windows constants
+const (
+	INPUT_KEYBOARD    = 1
+	KEYEVENTF_KEYUP   = 0x0002
+	KEYEVENTF_UNICODE = 0x0004
+	VK_BACK           = 0x08
+	VK_RETURN         = 0x0D
+)
+
+// 动态加载 user32.dll / Dynamically load user32.dll
+var (
+	user32        = syscall.NewLazyDLL("user32.dll")
+	procSendInput = user32.NewProc("SendInput")
+)
+
+// keybdInput 对应 KEYBDINPUT
+// keybdInput mirrors KEYBDINPUT
+type keybdInput struct {
+	wVk         uint16
+	wScan       uint16
+	dwFlags     uint32
+	time        uint32
+	dwExtraInfo uintptr
+}
+
+// keyboardInput 对应 type 为 INPUT_KEYBOARD 的 INPUT（补齐到联合体中最大的 MOUSEINPUT 大小）
+// keyboardInput mirrors an INPUT of type INPUT_KEYBOARD (padded to the size of MOUSEINPUT, the largest union member)
+type keyboardInput struct {
+	inputType uint32
+	ki        keybdInput
+	padding   [8]byte
+}
+
+// sendInputSink 使用 SendInput 的 Unicode 模式输入任意字符，无需考虑键盘布局
+// sendInputSink uses SendInput in Unicode mode to type any character regardless of keyboard layout
+type sendInputSink struct{}
+
+// newPlatformSink 按名称创建 Windows 后端
+// newPlatformSink creates a Windows backend by name
+func newPlatformSink(name string) (Sink, error) {
+	if name == "sendinput" {
+		return sendInputSink{}, nil
+	}
+	return nil, fmt.Errorf("不支持的键盘输入后端: %s", name)
+}
+
+// detect 返回 SendInput 后端（系统自带）
+// detect returns the SendInput backend (always available)
+func detect() (Sink, error) {
+	return sendInputSink{}, nil
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (sendInputSink) Name() string {
+	return "sendinput"
+}
+
+// TypeText 将文本转换为 UTF-16 并逐个代码单元发送（换行使用回车键）
+// TypeText converts the text to UTF-16 and sends each code unit (newlines use the Enter key)
+func (sendInputSink) TypeText(text string) error {
+	var inputs []keyboardInput
+	for _, r := range text {
+		switch r {
+		case '\r':
+			continue
+		case '\n':
+			inputs = append(inputs, virtualKey(VK_RETURN, 0), virtualKey(VK_RETURN, KEYEVENTF_KEYUP))
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			inputs = append(inputs, unicodeKey(unit, 0), unicodeKey(unit, KEYEVENTF_KEYUP))
+		}
+	}
+	return sendInput(inputs)
+}
+
+// Backspace 按 n 次退格键
+// Backspace presses the backspace key n times
+func (sendInputSink) Backspace(n int) error {
+	inputs := make([]keyboardInput, 0, n*2)
+	for i := 0; i < n; i++ {
+		inputs = append(inputs, virtualKey(VK_BACK, 0), virtualKey(VK_BACK, KEYEVENTF_KEYUP))
+	}
+	return sendInput(inputs)
+}
+
+// Close SendInput 后端无需释放资源
+// Close is a no-op for the SendInput backend
+func (sendInputSink) Close() error {
+	return nil
+}
+
+// unicodeKey 构造 Unicode 按键事件
+// unicodeKey builds a Unicode key event
+func unicodeKey(unit uint16, flags uint32) keyboardInput {
+	return keyboardInput{
+		inputType: INPUT_KEYBOARD,
+		ki:        keybdInput{wScan: unit, dwFlags: KEYEVENTF_UNICODE | flags},
+	}
+}
+
+// virtualKey 构造虚拟键事件
+// virtualKey builds a virtual key event
+func virtualKey(vk uint16, flags uint32) keyboardInput {
+	return keyboardInput{
+		inputType: INPUT_KEYBOARD,
+		ki:        keybdInput{wVk: vk, dwFlags: flags},
+	}
+}
+
+// sendInput 调用 SendInput 一次性提交全部事件
+// sendInput submits all events with a single SendInput call
+func sendInput(inputs []keyboardInput) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	sent, _, err := procSendInput.Call(
+		uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])),
+		unsafe.Sizeof(inputs[0]),
+	)
+	if int(sent) != len(inputs) {
+		return fmt.Errorf("SendInput 失败（%d/%d）: %w", sent, len(inputs), err)
+	}
+	return nil
+}
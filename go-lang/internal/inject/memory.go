@@ -0,0 +1,75 @@
+// Package inject 提供内存输入后端，用于测试和调试
+// Package inject provides an in-memory injection backend for tests and debugging
+package inject
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Memory 是把输入记录在内存中的后端，模拟一个文本框的内容
+// Memory is a backend that records input in memory, simulating the contents of a text box
+type Memory struct {
+	mu     sync.Mutex
+	buffer []rune
+	events []string
+}
+
+// NewMemory 创建内存输入后端
+// NewMemory creates an in-memory injection backend
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// TypeText 将文本追加到模拟文本框
+// TypeText appends the text to the simulated text box
+func (m *Memory) TypeText(text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buffer = append(m.buffer, []rune(text)...)
+	m.events = append(m.events, "type:"+text)
+	return nil
+}
+
+// Backspace 从模拟文本框末尾删除 n 个字符
+// Backspace removes n characters from the end of the simulated text box
+func (m *Memory) Backspace(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n > len(m.buffer) {
+		n = len(m.buffer)
+	}
+	m.buffer = m.buffer[:len(m.buffer)-n]
+	m.events = append(m.events, "backspace:"+strconv.Itoa(n))
+	return nil
+}
+
+// Close 内存后端无需释放资源
+// Close is a no-op for the memory backend
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Text 返回模拟文本框的当前内容
+// Text returns the current contents of the simulated text box
+func (m *Memory) Text() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return string(m.buffer)
+}
+
+// Events 返回全部操作记录的副本
+// Events returns a copy of every recorded operation
+func (m *Memory) Events() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := make([]string, len(m.events))
+	copy(events, m.events)
+	return events
+}
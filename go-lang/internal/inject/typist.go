@@ -0,0 +1,78 @@
+// Package inject 提供 Typist：根据实时文本计算差异，只输入变化的部分
+// Package inject provides Typist: it diffs live text and only types what changed
+package inject
+
+import "sync"
+
+// Typist 在 Sink 之上维护已输入的文本，使实时输入只发送增量
+// Typist tracks the text already typed on top of a Sink so live input only sends deltas
+type Typist struct {
+	mu    sync.Mutex
+	sink  Sink
+	typed []rune // 当前输入段中已经输入的文本 / Text already typed in the current segment
+}
+
+// NewTypist 创建基于指定后端的 Typist
+// NewTypist creates a Typist on top of the given sink
+func NewTypist(sink Sink) *Typist {
+	return &Typist{sink: sink}
+}
+
+// Sink 返回底层后端
+// Sink returns the underlying sink
+func (t *Typist) Sink() Sink {
+	return t.sink
+}
+
+// Type 直接输入一段完整文本（卡片模式），不影响实时输入的状态
+// Type types a complete piece of text (card mode) without touching the live input state
+func (t *Typist) Type(text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sink.TypeText(text)
+}
+
+// Update 将焦点应用中的当前段落更新为 text（实时模式）
+// 先用退格删除与已输入文本不同的尾部，再输入新的尾部
+// Update brings the current segment in the focused application up to text (live mode)
+// It backspaces over the tail that differs from what was typed, then types the new tail
+func (t *Typist) Update(text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	target := []rune(text)
+	common := 0
+	for common < len(t.typed) && common < len(target) && t.typed[common] == target[common] {
+		common++
+	}
+
+	if erase := len(t.typed) - common; erase > 0 {
+		if err := t.sink.Backspace(erase); err != nil {
+			return err
+		}
+		t.typed = t.typed[:common]
+	}
+	if common < len(target) {
+		if err := t.sink.TypeText(string(target[common:])); err != nil {
+			return err
+		}
+	}
+	t.typed = append(t.typed[:common], target[common:]...)
+	return nil
+}
+
+// Reset 结束当前段落：已输入的文本保留在应用中，之后的 Update 从空白开始
+// Reset ends the current segment: typed text stays in the application and the next Update starts fresh
+func (t *Typist) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.typed = nil
+}
+
+// Close 关闭底层后端
+// Close closes the underlying sink
+func (t *Typist) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sink.Close()
+}
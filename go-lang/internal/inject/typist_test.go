@@ -0,0 +1,64 @@
+package inject
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTypistUpdateSendsDeltas(t *testing.T) {
+	sink := NewMemory()
+	typist := NewTypist(sink)
+
+	steps := []string{
+		"你好",
+		"你好世界",   // 追加 / Appended
+		"你好世界。",  // 追加标点 / Punctuation appended
+		"你好，世界。", // 中间插入，退格到分歧处重输 / Inserted in the middle: backspace to the divergence and retype
+		"你好",     // 删除尾部 / Tail deleted
+		"你好",     // 没有变化 / Unchanged
+		"",       // 清空 / Cleared
+	}
+	for _, text := range steps {
+		if err := typist.Update(text); err != nil {
+			t.Fatalf("Update(%q): %v", text, err)
+		}
+		if got := sink.Text(); got != text {
+			t.Fatalf("after Update(%q) the text box holds %q", text, got)
+		}
+	}
+
+	want := []string{
+		"type:你好",
+		"type:世界",
+		"type:。",
+		"backspace:3",
+		"type:，世界。",
+		"backspace:4",
+		"backspace:2",
+	}
+	if got := sink.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestTypistResetKeepsTypedText(t *testing.T) {
+	sink := NewMemory()
+	typist := NewTypist(sink)
+
+	typist.Update("第一段")
+	typist.Reset()
+	// 新段落从空白开始，不会删除上一段 / The new segment starts fresh and does not erase the previous one
+	typist.Update("第二")
+	typist.Update("第二段")
+	if err := typist.Type("卡片"); err != nil {
+		t.Fatalf("Type: %v", err)
+	}
+
+	want := []string{"type:第一段", "type:第二", "type:段", "type:卡片"}
+	if got := sink.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if got, want := sink.Text(), "第一段第二段卡片"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,266 @@
+// Package inject 提供基于 /dev/uinput 的虚拟键盘
+// Package inject provides a virtual keyboard backed by /dev/uinput
+//go:build linux
+
+package inject
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// uinput 常量（见 linux/uinput.h 与 linux/input-event-codes.h） / uinput constants (see linux/uinput.h and linux/input-event-codes.h)
+const (
+	uinputPath      = "/dev/uinput"
+	uiDevCreate     = 0x5501     // _IO('U', 1)
+	uiDevDestroy    = 0x5502     // _IO('U', 2)
+	uiSetEvBit      = 0x40045564 // _IOW('U', 100, int)
+	uiSetKeyBit     = 0x40045565 // _IOW('U', 101, int)
+	uinputMaxName   = 80
+	absCnt          = 64
+	evSyn           = 0x00
+	evKey           = 0x01
+	synReport       = 0
+	busVirtual      = 0x06
+	keyBackspace    = 14
+	keyLeftShift    = 42
+	uinputSetupWait = 200 * time.Millisecond // 等待桌面环境识别新设备 / Wait for the desktop to pick up the new device
+)
+
+// uinputUserDev 对应 struct uinput_user_dev（旧版设备创建接口，兼容性最好）
+// uinputUserDev mirrors struct uinput_user_dev (the legacy setup interface, which is the most widely supported)
+type uinputUserDev struct {
+	Name         [uinputMaxName]byte
+	BusType      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	FFEffectsMax uint32
+	AbsMax       [absCnt]int32
+	AbsMin       [absCnt]int32
+	AbsFuzz      [absCnt]int32
+	AbsFlat      [absCnt]int32
+}
+
+// inputEvent 对应 struct input_event
+// inputEvent mirrors struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// keyStroke 表示一个字符对应的按键
+// keyStroke is the key press that produces one character
+type keyStroke struct {
+	code  uint16
+	shift bool
+}
+
+// usKeymap 美式键盘布局下 ASCII 字符到按键的映射
+// usKeymap maps ASCII characters to key presses on a US keyboard layout
+var usKeymap = buildUSKeymap()
+
+// buildUSKeymap 构建美式键盘布局映射表
+// buildUSKeymap builds the US layout key map
+func buildUSKeymap() map[rune]keyStroke {
+	m := map[rune]keyStroke{
+		' ': {57, false}, '\n': {28, false}, '\t': {15, false},
+		'-': {12, false}, '=': {13, false}, '[': {26, false}, ']': {27, false},
+		';': {39, false}, '\'': {40, false}, '`': {41, false}, '\\': {43, false},
+		',': {51, false}, '.': {52, false}, '/': {53, false},
+		'_': {12, true}, '+': {13, true}, '{': {26, true}, '}': {27, true},
+		':': {39, true}, '"': {40, true}, '~': {41, true}, '|': {43, true},
+		'<': {51, true}, '>': {52, true}, '?': {53, true},
+		'!': {2, true}, '@': {3, true}, '#': {4, true}, '$': {5, true}, '%': {6, true},
+		'^': {7, true}, '&': {8, true}, '*': {9, true}, '(': {10, true}, ')': {11, true},
+	}
+	for i, r := range "1234567890" {
+		m[r] = keyStroke{uint16(2 + i), false}
+	}
+	rows := []struct {
+		letters string
+		first   uint16
+	}{
+		{"qwertyuiop", 16},
+		{"asdfghjkl", 30},
+		{"zxcvbnm", 44},
+	}
+	for _, row := range rows {
+		for i, r := range row.letters {
+			m[r] = keyStroke{row.first + uint16(i), false}
+			m[r-'a'+'A'] = keyStroke{row.first + uint16(i), true}
+		}
+	}
+	return m
+}
+
+// uinputSink 通过内核 uinput 模块创建虚拟键盘输入文本
+// 虚拟键盘只能输入键盘布局上的字符，其他字符（如中文）交给 fallback 输入
+// uinputSink types text through a virtual keyboard created with the kernel uinput module
+// A virtual keyboard can only produce characters on the layout; others (such as Chinese) go to the fallback
+type uinputSink struct {
+	file     *os.File
+	fallback Sink // 输入键盘布局以外字符的后端（可为 nil） / Backend for characters outside the layout (may be nil)
+}
+
+// newUinputSink 创建虚拟键盘设备；需要 /dev/uinput 的写权限
+// newUinputSink creates the virtual keyboard device; requires write access to /dev/uinput
+func newUinputSink() (*uinputSink, error) {
+	file, err := os.OpenFile(uinputPath, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("打开 %s 失败: %w", uinputPath, err)
+	}
+	fd := file.Fd()
+
+	if err := ioctl(fd, uiSetEvBit, evKey); err != nil {
+		file.Close()
+		return nil, err
+	}
+	keys := map[uint16]bool{keyBackspace: true, keyLeftShift: true}
+	for _, stroke := range usKeymap {
+		keys[stroke.code] = true
+	}
+	for code := range keys {
+		if err := ioctl(fd, uiSetKeyBit, uintptr(code)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	dev := uinputUserDev{BusType: busVirtual, Vendor: 0x1, Product: 0x1, Version: 1}
+	copy(dev.Name[:], "AirInputLan Virtual Keyboard")
+	if err := binary.Write(file, binary.NativeEndian, &dev); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("配置虚拟键盘失败: %w", err)
+	}
+	if err := ioctl(fd, uiDevCreate, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	time.Sleep(uinputSetupWait)
+
+	sink := &uinputSink{file: file}
+	// 键盘布局以外的字符尝试交给 xdotool / ydotool / Characters outside the layout go to xdotool / ydotool if present
+	for _, fallback := range commandSinks() {
+		if fallback.available() {
+			sink.fallback = fallback
+			break
+		}
+	}
+	return sink, nil
+}
+
+// Name 返回后端名称
+// Name returns the backend name
+func (s *uinputSink) Name() string {
+	if s.fallback != nil {
+		return "uinput+" + s.fallback.Name()
+	}
+	return "uinput"
+}
+
+// TypeText 逐字符按键输入；键盘布局以外的连续字符交给 fallback
+// TypeText presses keys character by character; runs of characters outside the layout go to the fallback
+func (s *uinputSink) TypeText(text string) error {
+	var buf bytes.Buffer
+	var pending []rune // 等待交给 fallback 的字符 / Characters waiting for the fallback
+
+	flush := func() error {
+		if buf.Len() > 0 {
+			if _, err := s.file.Write(buf.Bytes()); err != nil {
+				return fmt.Errorf("写入虚拟键盘失败: %w", err)
+			}
+			buf.Reset()
+		}
+		if len(pending) > 0 {
+			if s.fallback == nil {
+				return fmt.Errorf("虚拟键盘无法输入 %q，请安装 xdotool 或 ydotool", string(pending))
+			}
+			if err := s.fallback.TypeText(string(pending)); err != nil {
+				return err
+			}
+			pending = nil
+		}
+		return nil
+	}
+
+	for _, r := range text {
+		stroke, ok := usKeymap[r]
+		if !ok {
+			if buf.Len() > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			pending = append(pending, r)
+			continue
+		}
+		if len(pending) > 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		s.writeStroke(&buf, stroke)
+	}
+	return flush()
+}
+
+// Backspace 按 n 次退格键
+// Backspace presses the backspace key n times
+func (s *uinputSink) Backspace(n int) error {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		s.writeStroke(&buf, keyStroke{code: keyBackspace})
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("写入虚拟键盘失败: %w", err)
+	}
+	return nil
+}
+
+// Close 销毁虚拟键盘设备
+// Close destroys the virtual keyboard device
+func (s *uinputSink) Close() error {
+	ioctl(s.file.Fd(), uiDevDestroy, 0)
+	return s.file.Close()
+}
+
+// writeStroke 写入一次完整的按下/抬起事件序列
+// writeStroke writes a complete press/release event sequence
+func (s *uinputSink) writeStroke(buf *bytes.Buffer, stroke keyStroke) {
+	if stroke.shift {
+		writeEvent(buf, evKey, keyLeftShift, 1)
+	}
+	writeEvent(buf, evKey, stroke.code, 1)
+	writeEvent(buf, evSyn, synReport, 0)
+	writeEvent(buf, evKey, stroke.code, 0)
+	if stroke.shift {
+		writeEvent(buf, evKey, keyLeftShift, 0)
+	}
+	writeEvent(buf, evSyn, synReport, 0)
+}
+
+// writeEvent 编码一个 input_event（时间戳由内核填充）
+// writeEvent encodes one input_event (the kernel fills in the timestamp)
+func writeEvent(buf *bytes.Buffer, typ, code uint16, value int32) {
+	binary.Write(buf, binary.NativeEndian, inputEvent{Type: typ, Code: code, Value: value})
+}
+
+// ioctl 执行 ioctl 系统调用
+// ioctl performs the ioctl system call
+func ioctl(fd, request, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg); errno != 0 {
+		return fmt.Errorf("ioctl 0x%x 失败: %w", request, errno)
+	}
+	return nil
+}
+
+// 确保结构体大小与内核一致 / Make sure the struct size matches the kernel's
+var _ [1116]byte = [unsafe.Sizeof(uinputUserDev{})]byte{}
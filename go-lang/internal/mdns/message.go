@@ -0,0 +1,216 @@
+// Package mdns 提供 mDNS 所需的最小 DNS 报文编解码（RFC 1035 / RFC 6762）
+// Package mdns provides the minimal DNS message encoding and decoding needed for mDNS (RFC 1035 / RFC 6762)
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// 资源记录类型 / Resource record types
+const (
+	TypeA    uint16 = 1
+	TypePTR  uint16 = 12
+	TypeTXT  uint16 = 16
+	TypeAAAA uint16 = 28
+	TypeSRV  uint16 = 33
+	TypeANY  uint16 = 255
+)
+
+const (
+	classIN         uint16 = 1
+	classMask       uint16 = 0x7fff // 去掉最高位后的类别 / Class without the top bit
+	unicastResponse uint16 = 0x8000 // 问题中的 QU 位：请求单播响应 / QU bit in a question: unicast response requested
+	cacheFlush      uint16 = 0x8000 // 记录中的缓存刷新位：唯一记录 / Cache-flush bit in a record: unique record
+
+	flagResponse      uint16 = 0x8000 // QR 位 / QR bit
+	flagAuthoritative uint16 = 0x0400 // AA 位 / AA bit
+	opcodeMask        uint16 = 0x7800
+
+	headerLen     = 12
+	maxLabelLen   = 63
+	maxPointerHop = 16 // 名称压缩指针的最大跳转次数，防止循环 / Maximum compression pointer hops, guards against loops
+)
+
+var errMalformed = errors.New("mdns: 报文格式错误")
+
+// Question 表示查询中的一个问题
+// Question is a single question of a query
+type Question struct {
+	Name    string // 小写、不带结尾点的域名 / Lower-case domain name without the trailing dot
+	Type    uint16
+	Unicast bool // 是否设置了 QU 位 / Whether the QU bit is set
+}
+
+// Query 表示解析后的查询报文（只包含响应所需的部分）
+// Query is a parsed query message (only the parts needed to answer it)
+type Query struct {
+	ID        uint16
+	Questions []Question
+}
+
+// Record 表示一条资源记录
+// Record is a single resource record
+type Record struct {
+	Name   string
+	Type   uint16
+	Unique bool   // 唯一记录（A/AAAA/SRV/TXT）设置缓存刷新位 / Unique records (A/AAAA/SRV/TXT) carry the cache-flush bit
+	TTL    uint32 // 秒，0 表示撤销 / Seconds, 0 withdraws the record
+	Data   []byte // 已编码的 RDATA / Encoded RDATA
+}
+
+// ParseQuery 解析查询报文；响应报文和非标准查询返回错误
+// ParseQuery parses a query message; responses and non-standard queries are rejected
+func ParseQuery(msg []byte) (Query, error) {
+	if len(msg) < headerLen {
+		return Query{}, errMalformed
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&flagResponse != 0 || flags&opcodeMask != 0 {
+		return Query{}, errors.New("mdns: 不是标准查询")
+	}
+	q := Query{ID: binary.BigEndian.Uint16(msg)}
+	count := int(binary.BigEndian.Uint16(msg[4:]))
+	off := headerLen
+	for i := 0; i < count; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return Query{}, err
+		}
+		if next+4 > len(msg) {
+			return Query{}, errMalformed
+		}
+		qtype := binary.BigEndian.Uint16(msg[next:])
+		qclass := binary.BigEndian.Uint16(msg[next+2:])
+		off = next + 4
+		if qclass&classMask != classIN && qclass&classMask != 255 {
+			continue
+		}
+		q.Questions = append(q.Questions, Question{Name: name, Type: qtype, Unicast: qclass&unicastResponse != 0})
+	}
+	return q, nil
+}
+
+// readName 从 off 处读取域名（支持压缩指针），返回小写名称和名称之后的偏移
+// readName reads a domain name at off (following compression pointers) and returns it lower-cased with the offset after it
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for hops := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")), end, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(msg) || hops >= maxPointerHop {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			hops++
+		case n > maxLabelLen:
+			return "", 0, errMalformed
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+// appendName 以未压缩形式编码域名
+// appendName encodes a domain name uncompressed
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		if len(label) > maxLabelLen {
+			label = label[:maxLabelLen]
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// BuildResponse 编码响应报文；questions 非空时回显问题（用于传统单播查询）
+// BuildResponse encodes a response message; questions are echoed when given (for legacy unicast queries)
+func BuildResponse(id uint16, questions []Question, answers, additional []Record) []byte {
+	b := make([]byte, headerLen, 512)
+	binary.BigEndian.PutUint16(b[0:], id)
+	binary.BigEndian.PutUint16(b[2:], flagResponse|flagAuthoritative)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(answers)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(additional)))
+	for _, q := range questions {
+		b = appendName(b, q.Name)
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, classIN)
+	}
+	for _, rr := range append(answers, additional...) {
+		b = appendName(b, rr.Name)
+		b = binary.BigEndian.AppendUint16(b, rr.Type)
+		class := classIN
+		if rr.Unique {
+			class |= cacheFlush
+		}
+		b = binary.BigEndian.AppendUint16(b, class)
+		b = binary.BigEndian.AppendUint32(b, rr.TTL)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(rr.Data)))
+		b = append(b, rr.Data...)
+	}
+	return b
+}
+
+// addressRecord 返回 IP 对应的 A 或 AAAA 记录
+// addressRecord returns the A or AAAA record for an IP
+func addressRecord(name string, ip net.IP, ttl uint32) Record {
+	if v4 := ip.To4(); v4 != nil {
+		return Record{Name: name, Type: TypeA, Unique: true, TTL: ttl, Data: v4}
+	}
+	return Record{Name: name, Type: TypeAAAA, Unique: true, TTL: ttl, Data: ip.To16()}
+}
+
+// ptrRecord 返回指向 target 的 PTR 记录（共享记录）
+// ptrRecord returns a PTR record pointing at target (a shared record)
+func ptrRecord(name, target string, ttl uint32) Record {
+	return Record{Name: name, Type: TypePTR, TTL: ttl, Data: appendName(nil, target)}
+}
+
+// srvRecord 返回 SRV 记录（优先级和权重为 0）
+// srvRecord returns an SRV record (priority and weight 0)
+func srvRecord(name, target string, port int, ttl uint32) Record {
+	data := make([]byte, 6, 6+len(target)+2)
+	binary.BigEndian.PutUint16(data[4:], uint16(port))
+	return Record{Name: name, Type: TypeSRV, Unique: true, TTL: ttl, Data: appendName(data, target)}
+}
+
+// txtRecord 返回 TXT 记录；没有条目时按 RFC 6763 写入一个空字符串
+// txtRecord returns a TXT record; with no entries a single empty string is written per RFC 6763
+func txtRecord(name string, entries []string, ttl uint32) Record {
+	var data []byte
+	for _, e := range entries {
+		if len(e) > 255 {
+			e = e[:255]
+		}
+		data = append(data, byte(len(e)))
+		data = append(data, e...)
+	}
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+	return Record{Name: name, Type: TypeTXT, Unique: true, TTL: ttl, Data: data}
+}
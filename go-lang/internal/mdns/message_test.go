@@ -0,0 +1,272 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// query 拼接查询报文：报头（问题数为 qdcount）后接 body
+// query assembles a query message: the header (with qdcount questions) followed by body
+func query(flags uint16, qdcount int, body ...[]byte) []byte {
+	msg := make([]byte, headerLen)
+	binary.BigEndian.PutUint16(msg[0:], 0x1234)
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[4:], uint16(qdcount))
+	for _, b := range body {
+		msg = append(msg, b...)
+	}
+	return msg
+}
+
+// question 编码一个问题：未压缩的名称、类型和类别
+// question encodes a question: the uncompressed name, type and class
+func question(name string, qtype, class uint16) []byte {
+	b := appendName(nil, name)
+	b = binary.BigEndian.AppendUint16(b, qtype)
+	return binary.BigEndian.AppendUint16(b, class)
+}
+
+// typeClass 编码问题中名称之后的类型和类别
+// typeClass encodes the type and class that follow a question's name
+func typeClass(qtype, class uint16) []byte {
+	return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, qtype), class)
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+		want []Question
+	}{
+		{
+			name: "lower-cases the name",
+			msg:  query(0, 1, question("AirInput.Local", TypeA, classIN)),
+			want: []Question{{Name: "airinput.local", Type: TypeA}},
+		},
+		{
+			name: "QU bit requests a unicast response",
+			msg:  query(0, 1, question("airinput.local", TypeAAAA, classIN|unicastResponse)),
+			want: []Question{{Name: "airinput.local", Type: TypeAAAA, Unicast: true}},
+		},
+		{
+			name: "class ANY is answered, other classes are skipped",
+			msg: query(0, 2,
+				question("airinput.local", TypeA, 3),
+				question("airinput.local", TypeANY, 255),
+			),
+			want: []Question{{Name: "airinput.local", Type: TypeANY}},
+		},
+		{
+			name: "compressed name points at an earlier suffix",
+			msg: query(0, 2,
+				question("_airinputlan._tcp.local", TypePTR, classIN),
+				// "airinput" + 指向偏移 30 处 "local" 的指针 / "airinput" + a pointer to "local" at offset 30
+				[]byte{8, 'a', 'i', 'r', 'i', 'n', 'p', 'u', 't', 0xc0, 30},
+				typeClass(TypeA, classIN),
+			),
+			want: []Question{
+				{Name: "_airinputlan._tcp.local", Type: TypePTR},
+				{Name: "airinput.local", Type: TypeA},
+			},
+		},
+		{
+			name: "no questions",
+			msg:  query(0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			if q.ID != 0x1234 {
+				t.Errorf("ID = %#x, want 0x1234", q.ID)
+			}
+			if !reflect.DeepEqual(q.Questions, tt.want) {
+				t.Errorf("Questions = %+v, want %+v", q.Questions, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"short header", make([]byte, headerLen-1)},
+		{"response", query(flagResponse, 1, question("airinput.local", TypeA, classIN))},
+		{"non-standard opcode", query(1<<11, 1, question("airinput.local", TypeA, classIN))},
+		{"more questions than present", query(0, 2, question("airinput.local", TypeA, classIN))},
+		{"pointer to itself", query(0, 1, []byte{0xc0, headerLen}, typeClass(TypeA, classIN))},
+		{"pointer loop", query(0, 1, []byte{0xc0, headerLen + 2, 0xc0, headerLen}, typeClass(TypeA, classIN))},
+		{"label loop through a pointer", query(0, 1, []byte{1, 'a', 0xc0, headerLen}, typeClass(TypeA, classIN))},
+		{"pointer past the end", query(0, 1, []byte{0xc0, 0xff}, typeClass(TypeA, classIN))},
+		{"truncated pointer", query(0, 1, []byte{0xc0})},
+		{"truncated label", query(0, 1, []byte{10, 'a', 'i', 'r'})},
+		{"label longer than 63 bytes", query(0, 1, append([]byte{64}, make([]byte, 64)...), []byte{0}, typeClass(TypeA, classIN))},
+		{"missing terminator", query(0, 1, []byte{3, 'a', 'i', 'r'})},
+		{"missing type and class", query(0, 1, appendName(nil, "airinput.local"), []byte{0, 1})},
+	}
+	for _, tt := range tests {
+		if _, err := ParseQuery(tt.msg); err == nil {
+			t.Errorf("%s: ParseQuery succeeded", tt.name)
+		}
+	}
+}
+
+func TestReadNameFollowsPointerChain(t *testing.T) {
+	// 链长恰好为 maxPointerHop 时仍可解析，再多一跳即报错
+	// A chain of exactly maxPointerHop pointers still parses; one more hop is an error
+	chain := func(hops int) []byte {
+		msg := appendName(nil, "local")
+		for i := 0; i < hops; i++ {
+			target := 0
+			if i > 0 {
+				target = len(msg) - 2
+			}
+			msg = append(msg, 0xc0, byte(target))
+		}
+		return msg
+	}
+	msg := chain(maxPointerHop)
+	name, next, err := readName(msg, len(msg)-2)
+	if err != nil || name != "local" || next != len(msg) {
+		t.Errorf("readName = %q, %d, %v; want \"local\", %d, nil", name, next, err, len(msg))
+	}
+	msg = chain(maxPointerHop + 1)
+	if _, _, err := readName(msg, len(msg)-2); err == nil {
+		t.Error("readName followed more than maxPointerHop pointers")
+	}
+}
+
+// parsedRecord 是测试中解析出的资源记录（类别保留缓存刷新位）
+// parsedRecord is a resource record parsed in tests (the class keeps the cache-flush bit)
+type parsedRecord struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+}
+
+// parseResponse 解析 BuildResponse 生成的报文
+// parseResponse parses a message produced by BuildResponse
+func parseResponse(t *testing.T, msg []byte) (uint16, []Question, []parsedRecord, int) {
+	t.Helper()
+	if len(msg) < headerLen {
+		t.Fatalf("response is %d bytes", len(msg))
+	}
+	if flags := binary.BigEndian.Uint16(msg[2:]); flags != flagResponse|flagAuthoritative {
+		t.Errorf("flags = %#x, want QR and AA", flags)
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+	arcount := int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := headerLen
+	var questions []Question
+	for range qdcount {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			t.Fatalf("question name: %v", err)
+		}
+		questions = append(questions, Question{Name: name, Type: binary.BigEndian.Uint16(msg[next:])})
+		off = next + 4
+	}
+	var records []parsedRecord
+	for range ancount + arcount {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			t.Fatalf("record name: %v", err)
+		}
+		rr := parsedRecord{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[next:]),
+			Class: binary.BigEndian.Uint16(msg[next+2:]),
+			TTL:   binary.BigEndian.Uint32(msg[next+4:]),
+		}
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		off = next + 10 + length
+		rr.Data = msg[next+10 : off]
+		records = append(records, rr)
+	}
+	if off != len(msg) {
+		t.Errorf("%d trailing bytes", len(msg)-off)
+	}
+	return binary.BigEndian.Uint16(msg), questions, records, ancount
+}
+
+func TestBuildResponseRoundTrip(t *testing.T) {
+	const (
+		host     = "airinput.local"
+		service  = "_airinputlan._tcp.local"
+		instance = "AirInput._airinputlan._tcp.local"
+	)
+	answers := []Record{
+		addressRecord(host, net.ParseIP("192.168.1.10"), 120),
+		addressRecord(host, net.ParseIP("fe80::1"), 120),
+		ptrRecord(service, instance, 4500),
+	}
+	additional := []Record{
+		srvRecord(instance, host, 5000, 120),
+		txtRecord(instance, nil, 4500),
+	}
+	questions := []Question{{Name: host, Type: TypeA}}
+
+	id, gotQuestions, records, ancount := parseResponse(t, BuildResponse(0xbeef, questions, answers, additional))
+	if id != 0xbeef {
+		t.Errorf("ID = %#x, want 0xbeef", id)
+	}
+	if !reflect.DeepEqual(gotQuestions, questions) {
+		t.Errorf("questions = %+v, want %+v", gotQuestions, questions)
+	}
+	if ancount != len(answers) || len(records) != len(answers)+len(additional) {
+		t.Fatalf("%d answers, %d records; want %d, %d", ancount, len(records), len(answers), len(answers)+len(additional))
+	}
+
+	for i, rr := range append(answers, additional...) {
+		got := records[i]
+		wantClass := classIN
+		if rr.Unique {
+			wantClass |= cacheFlush
+		}
+		// readName 返回小写名称 / readName lower-cases names
+		if got.Name != strings.ToLower(rr.Name) || got.Type != rr.Type || got.Class != wantClass || got.TTL != rr.TTL || !reflect.DeepEqual(got.Data, rr.Data) {
+			t.Errorf("record %d = %+v, want %+v with class %#x", i, got, rr, wantClass)
+		}
+	}
+
+	// RDATA 内容 / RDATA contents
+	if ip := net.IP(records[0].Data); !ip.Equal(net.ParseIP("192.168.1.10")) || len(ip) != 4 {
+		t.Errorf("A record = %v", ip)
+	}
+	if ip := net.IP(records[1].Data); !ip.Equal(net.ParseIP("fe80::1")) || records[1].Type != TypeAAAA {
+		t.Errorf("AAAA record = %v", ip)
+	}
+	if target, _, err := readName(records[2].Data, 0); err != nil || target != "airinput._airinputlan._tcp.local" {
+		t.Errorf("PTR target = %q, %v", target, err)
+	}
+	srv := records[3].Data
+	if port := binary.BigEndian.Uint16(srv[4:]); port != 5000 {
+		t.Errorf("SRV port = %d, want 5000", port)
+	}
+	if target, _, err := readName(srv, 6); err != nil || target != host {
+		t.Errorf("SRV target = %q, %v", target, err)
+	}
+	if !reflect.DeepEqual(records[4].Data, []byte{0}) {
+		t.Errorf("empty TXT = %v, want a single empty string", records[4].Data)
+	}
+}
+
+func TestBuildResponseWithoutQuestions(t *testing.T) {
+	msg := BuildResponse(0, nil, []Record{addressRecord("airinput.local", net.ParseIP("10.0.0.1"), 0)}, nil)
+	_, questions, records, _ := parseResponse(t, msg)
+	if len(questions) != 0 || len(records) != 1 || records[0].TTL != 0 {
+		t.Errorf("questions %+v, records %+v; want no questions and one withdrawn record", questions, records)
+	}
+}
@@ -0,0 +1,349 @@
+// Package mdns 提供不依赖第三方库的 mDNS/DNS-SD 响应器：发布 <主机名>.local 的地址记录和服务实例
+// 响应器只在当前访问地址所在的网卡上工作，切换网卡时重新加入组播组
+// Package mdns provides a dependency-free mDNS/DNS-SD responder publishing <host>.local address records and a service instance
+// The responder works on the interface of the current access address and rejoins the multicast groups when it changes
+package mdns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	Port             = 5353        // mDNS 端口 / mDNS port
+	DefaultTTL       = 120         // 记录的默认 TTL（秒） / Default record TTL in seconds
+	legacyUnicastTTL = 10          // 传统单播查询的最大 TTL（RFC 6762 第 6.7 节） / Maximum TTL for legacy unicast queries (RFC 6762 section 6.7)
+	announceCount    = 2           // 启动时发布记录的次数 / Number of announcements at startup
+	announceInterval = time.Second // 两次发布之间的间隔 / Interval between announcements
+	maxPacketSize    = 9000        // 接收缓冲区大小 / Receive buffer size
+
+	servicesName = "_services._dns-sd._udp.local" // DNS-SD 服务类型枚举名称 / DNS-SD service type enumeration name
+)
+
+var (
+	groupIPv4 = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: Port}
+	groupIPv6 = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: Port}
+)
+
+// Service 描述要发布的服务
+// Service describes the service to publish
+type Service struct {
+	Host     string   // 主机名（不含 .local），如 airinput / Host name without .local, e.g. airinput
+	Instance string   // 服务实例名，显示在服务浏览器中 / Service instance name, shown in service browsers
+	Type     string   // 服务类型，如 _airinputlan._tcp / Service type, e.g. _airinputlan._tcp
+	Port     int      // 服务端口 / Service port
+	TXT      []string // TXT 记录条目（key=value） / TXT record entries (key=value)
+}
+
+// HostName 返回完整主机名，如 airinput.local
+// HostName returns the full host name, e.g. airinput.local
+func (s Service) HostName() string {
+	return strings.ToLower(s.Host) + ".local"
+}
+
+// typeName 返回服务类型的完整名称
+// typeName returns the full name of the service type
+func (s Service) typeName() string {
+	return strings.ToLower(s.Type) + ".local"
+}
+
+// instanceName 返回服务实例的完整名称
+// instanceName returns the full name of the service instance
+func (s Service) instanceName() string {
+	return s.Instance + "." + s.typeName()
+}
+
+// link 表示在一个组播组上监听的连接
+// link is a connection listening on one multicast group
+type link struct {
+	conn  *net.UDPConn
+	group *net.UDPAddr
+}
+
+// Responder 表示运行中的 mDNS 响应器
+// Responder is a running mDNS responder
+type Responder struct {
+	svc   Service
+	mu    sync.Mutex
+	iface string
+	addrs []net.IP
+	links []link
+	stop  chan struct{} // 关闭时停止本轮发布 / Closed to stop the current announcements
+}
+
+// Start 在指定网卡上启动响应器，发布 addrs 中的地址
+// Start starts the responder on the given interface, publishing the addresses in addrs
+func Start(svc Service, iface string, addrs []net.IP) (*Responder, error) {
+	svc.Host = strings.ToLower(svc.Host)
+	// 实例名是单个标签：不能包含点，最长 63 字节 / The instance name is a single label: no dots, at most 63 bytes
+	svc.Instance = strings.ReplaceAll(svc.Instance, ".", "-")
+	for len(svc.Instance) > maxLabelLen {
+		_, size := utf8.DecodeLastRuneInString(svc.Instance)
+		svc.Instance = svc.Instance[:len(svc.Instance)-size]
+	}
+
+	r := &Responder{svc: svc}
+	if err := r.SetInterface(iface, addrs); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// HostName 返回发布的完整主机名
+// HostName returns the published full host name
+func (r *Responder) HostName() string {
+	return r.svc.HostName()
+}
+
+// SetInterface 更新发布的网卡和地址；网卡变化时重新加入组播组，并向旧网卡发送撤销记录
+// SetInterface updates the published interface and addresses; on an interface change the multicast groups are rejoined
+// and the records are withdrawn on the old interface
+func (r *Responder) SetInterface(iface string, addrs []net.IP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if iface == r.iface && r.links != nil {
+		if !sameIPs(r.addrs, addrs) {
+			r.addrs = addrs
+			r.announceLocked()
+		}
+		return nil
+	}
+
+	r.closeLocked()
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return fmt.Errorf("mdns: 查找网卡 %s 失败: %w", iface, err)
+	}
+	var links []link
+	var firstErr error
+	for _, g := range []struct {
+		network string
+		group   *net.UDPAddr
+	}{{"udp4", groupIPv4}, {"udp6", groupIPv6}} {
+		conn, err := net.ListenMulticastUDP(g.network, ifi, g.group)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		links = append(links, link{conn: conn, group: g.group})
+	}
+	if len(links) == 0 {
+		return fmt.Errorf("mdns: 无法在网卡 %s 上监听: %w", iface, firstErr)
+	}
+
+	r.iface, r.addrs, r.links = iface, addrs, links
+	for _, l := range links {
+		go r.serve(l)
+	}
+	r.announceLocked()
+	return nil
+}
+
+// Stop 撤销已发布的记录并关闭连接
+// Stop withdraws the published records and closes the connections
+func (r *Responder) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeLocked()
+}
+
+// closeLocked 发送撤销记录（TTL 为 0）并关闭连接，调用方需持有锁
+// closeLocked sends goodbye records (TTL 0) and closes the connections; the caller holds the lock
+func (r *Responder) closeLocked() {
+	if r.links == nil {
+		return
+	}
+	close(r.stop)
+	r.stop = nil
+	msg := BuildResponse(0, nil, r.allRecordsLocked(0), nil)
+	for _, l := range r.links {
+		l.conn.WriteToUDP(msg, l.group)
+		l.conn.Close()
+	}
+	r.iface, r.addrs, r.links = "", nil, nil
+}
+
+// announceLocked 在后台发布全部记录（RFC 6762 第 8.3 节），调用方需持有锁
+// announceLocked publishes every record in the background (RFC 6762 section 8.3); the caller holds the lock
+func (r *Responder) announceLocked() {
+	if r.stop != nil {
+		close(r.stop)
+	}
+	stop := make(chan struct{})
+	r.stop = stop
+	msg := BuildResponse(0, nil, r.allRecordsLocked(DefaultTTL), nil)
+	links := r.links
+	go func() {
+		for i := 0; i < announceCount; i++ {
+			if i > 0 {
+				select {
+				case <-stop:
+					return
+				case <-time.After(announceInterval):
+				}
+			}
+			for _, l := range links {
+				l.conn.WriteToUDP(msg, l.group)
+			}
+		}
+	}()
+}
+
+// serve 处理一个连接上收到的查询，连接关闭后返回
+// serve answers the queries received on one connection and returns once it is closed
+func (r *Responder) serve(l link) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, src, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		query, err := ParseQuery(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		// 源端口不是 5353 的是传统单播查询（如 nslookup），需回显问题并单播响应
+		// A source port other than 5353 marks a legacy unicast query (e.g. nslookup): echo the questions and reply unicast
+		legacy := src.Port != Port
+		unicast := legacy
+		var answers, additional []Record
+		r.mu.Lock()
+		for _, q := range query.Questions {
+			a, ad := r.answerLocked(q)
+			if len(a) > 0 && q.Unicast {
+				unicast = true
+			}
+			answers = append(answers, a...)
+			additional = append(additional, ad...)
+		}
+		r.mu.Unlock()
+		if len(answers) == 0 {
+			continue
+		}
+		additional = withoutDuplicates(additional, answers)
+
+		var msg []byte
+		if legacy {
+			msg = BuildResponse(query.ID, query.Questions, legacyRecords(answers), legacyRecords(additional))
+		} else {
+			msg = BuildResponse(0, nil, answers, additional)
+		}
+		if unicast {
+			l.conn.WriteToUDP(msg, src)
+		} else {
+			l.conn.WriteToUDP(msg, l.group)
+		}
+	}
+}
+
+// answerLocked 返回一个问题的答案和附加记录，调用方需持有锁
+// answerLocked returns the answers and additional records for one question; the caller holds the lock
+func (r *Responder) answerLocked(q Question) (answers, additional []Record) {
+	host := r.svc.HostName()
+	instance := r.svc.instanceName()
+	typeName := r.svc.typeName()
+	want := func(t uint16) bool { return q.Type == t || q.Type == TypeANY }
+
+	switch q.Name {
+	case host:
+		for _, rr := range r.addressRecordsLocked(DefaultTTL) {
+			if want(rr.Type) {
+				answers = append(answers, rr)
+			}
+		}
+	case typeName:
+		if want(TypePTR) {
+			answers = append(answers, ptrRecord(typeName, instance, DefaultTTL))
+			additional = append(additional, srvRecord(instance, host, r.svc.Port, DefaultTTL), txtRecord(instance, r.svc.TXT, DefaultTTL))
+			additional = append(additional, r.addressRecordsLocked(DefaultTTL)...)
+		}
+	case servicesName:
+		if want(TypePTR) {
+			answers = append(answers, ptrRecord(servicesName, typeName, DefaultTTL))
+		}
+	case strings.ToLower(instance):
+		if want(TypeSRV) {
+			answers = append(answers, srvRecord(instance, host, r.svc.Port, DefaultTTL))
+			additional = append(additional, r.addressRecordsLocked(DefaultTTL)...)
+		}
+		if want(TypeTXT) {
+			answers = append(answers, txtRecord(instance, r.svc.TXT, DefaultTTL))
+		}
+	}
+	return answers, additional
+}
+
+// addressRecordsLocked 返回主机名的 A/AAAA 记录，调用方需持有锁
+// addressRecordsLocked returns the A/AAAA records of the host name; the caller holds the lock
+func (r *Responder) addressRecordsLocked(ttl uint32) []Record {
+	records := make([]Record, 0, len(r.addrs))
+	for _, ip := range r.addrs {
+		records = append(records, addressRecord(r.svc.HostName(), ip, ttl))
+	}
+	return records
+}
+
+// allRecordsLocked 返回发布或撤销时使用的全部记录，调用方需持有锁
+// allRecordsLocked returns every record, used when announcing or withdrawing; the caller holds the lock
+func (r *Responder) allRecordsLocked(ttl uint32) []Record {
+	instance := r.svc.instanceName()
+	records := []Record{
+		ptrRecord(servicesName, r.svc.typeName(), ttl),
+		ptrRecord(r.svc.typeName(), instance, ttl),
+		srvRecord(instance, r.svc.HostName(), r.svc.Port, ttl),
+		txtRecord(instance, r.svc.TXT, ttl),
+	}
+	return append(records, r.addressRecordsLocked(ttl)...)
+}
+
+// legacyRecords 返回适用于传统单播响应的记录：不设缓存刷新位，TTL 不超过 10 秒
+// legacyRecords returns records suitable for a legacy unicast response: no cache-flush bit and a TTL of at most 10 seconds
+func legacyRecords(records []Record) []Record {
+	out := make([]Record, len(records))
+	for i, rr := range records {
+		rr.Unique = false
+		rr.TTL = min(rr.TTL, legacyUnicastTTL)
+		out[i] = rr
+	}
+	return out
+}
+
+// withoutDuplicates 去掉 records 中重复的记录以及已出现在 answers 中的记录
+// withoutDuplicates drops records that repeat or already appear in answers
+func withoutDuplicates(records, answers []Record) []Record {
+	seen := make(map[string]bool)
+	key := func(rr Record) string { return fmt.Sprintf("%s/%d/%x", strings.ToLower(rr.Name), rr.Type, rr.Data) }
+	for _, rr := range answers {
+		seen[key(rr)] = true
+	}
+	var out []Record
+	for _, rr := range records {
+		if !seen[key(rr)] {
+			seen[key(rr)] = true
+			out = append(out, rr)
+		}
+	}
+	return out
+}
+
+// sameIPs 判断两个地址列表是否相同
+// sameIPs reports whether two address lists are equal
+func sameIPs(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
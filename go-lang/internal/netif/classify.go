@@ -0,0 +1,155 @@
+// Package netif 提供网卡分类：Linux 优先读取 sysfs 元数据，其余情况按名称规则表判断
+// Package netif provides NIC classification: sysfs metadata first on Linux, a table of name rules otherwise
+package netif
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// 网卡类型 / NIC types
+const (
+	NicEthernet = "以太网"
+	NicUSB      = "USB共享网卡"
+	NicWiFi     = "WiFi"
+	NicVirtual  = "虚拟网卡"
+)
+
+// DefaultSysfsRoot Linux 下网卡信息所在的 sysfs 目录
+// DefaultSysfsRoot is the sysfs directory holding NIC information on Linux
+const DefaultSysfsRoot = "/sys/class/net"
+
+// nameRule 描述一条按网卡名称分类的规则
+// nameRule describes a rule that classifies a NIC by its name
+type nameRule struct {
+	goos    string         // 适用的系统，空表示所有系统 / OS the rule applies to, empty for all
+	pattern *regexp.Regexp // 匹配网卡名称（不区分大小写） / Matches the NIC name (case-insensitive)
+	nicType string
+}
+
+// nameRules 名称规则表，按顺序匹配，第一条命中的规则生效；都不命中时为以太网
+// nameRules is the name rule table, matched in order with the first hit winning; Ethernet when none match
+var nameRules = []nameRule{
+	// 虚拟网卡：Linux 网桥/容器/VPN、VMware、VirtualBox、Hyper-V、KVM/QEMU 等
+	// Virtual NICs: Linux bridges/containers/VPNs, VMware, VirtualBox, Hyper-V, KVM/QEMU and others
+	{"", regexp.MustCompile(`(?i)^(virbr|veth|docker|br-|tun|tap|vnet|utun|awdl|llw|vmnet|vmware|vbox|vnic|vethernet|kvm|qemu|lxc|lxd|podman|flannel|cni|zt|tailscale|wg)`), NicVirtual},
+	{"windows", regexp.MustCompile(`(?i)(virtual|hyper-v|vmware|virtualbox|loopback|tap-windows|wintun)`), NicVirtual},
+
+	// 无线网卡：wlan0、wlp2s0、wlo1、wlx<MAC>（USB 无线网卡），Windows 的 WLAN / Wi-Fi
+	// Wireless NICs: wlan0, wlp2s0, wlo1, wlx<MAC> (USB Wi-Fi dongles), WLAN / Wi-Fi on Windows
+	{"", regexp.MustCompile(`(?i)^(wlan|wlp|wlo|wlx|wifi)`), NicWiFi},
+	{"windows", regexp.MustCompile(`(?i)(wlan|wi-fi|wireless|无线)`), NicWiFi},
+
+	// USB 共享网卡：enx<MAC>、usb0、按 USB 路径命名的 enp0s20f0u1，Windows 的 RNDIS
+	// USB tethering: enx<MAC>, usb0, USB-path names such as enp0s20f0u1, RNDIS on Windows
+	{"linux", regexp.MustCompile(`(?i)^(enx|rndis)|usb|^en\S*u\d+`), NicUSB},
+	{"windows", regexp.MustCompile(`(?i)(rndis|usb)`), NicUSB},
+	{"darwin", regexp.MustCompile(`(?i)(bridge|usb)`), NicUSB},
+}
+
+// Classifier 判断网卡类型；SysfsRoot 可替换为测试用的目录树
+// Classifier determines NIC types; SysfsRoot can point at a fixture tree for tests
+type Classifier struct {
+	SysfsRoot string // sysfs 网卡目录，空表示不读取 sysfs / sysfs NIC directory, empty to skip sysfs
+	GOOS      string // 名称规则使用的系统，空表示当前系统 / OS used for the name rules, empty for the running OS
+}
+
+// defaultClassifier 扫描时使用的分类器（仅 Linux 读取 sysfs）
+// defaultClassifier is the classifier used when scanning (sysfs is read on Linux only)
+var defaultClassifier = newDefaultClassifier()
+
+// newDefaultClassifier 返回当前系统的默认分类器
+// newDefaultClassifier returns the default classifier for the running OS
+func newDefaultClassifier() Classifier {
+	if runtime.GOOS == "linux" {
+		return Classifier{SysfsRoot: DefaultSysfsRoot}
+	}
+	return Classifier{}
+}
+
+// Classify 返回网卡类型：优先使用 sysfs 元数据，无法判断时使用名称规则表
+// Classify returns the NIC type: sysfs metadata first, the name rule table when that is inconclusive
+func (c Classifier) Classify(ifaceName string) string {
+	if nicType, ok := c.classifySysfs(ifaceName); ok {
+		return nicType
+	}
+	return c.classifyName(ifaceName)
+}
+
+// classifySysfs 根据 /sys/class/net/<网卡>/ 判断类型；网卡目录不存在时返回 false
+// 判断顺序：虚拟设备路径 > wireless/phy80211 目录或 DEVTYPE=wlan > device/subsystem 为 usb > 以太网
+// classifySysfs classifies using /sys/class/net/<iface>/; returns false when the directory does not exist
+// Order: virtual device path > wireless/phy80211 dir or DEVTYPE=wlan > device/subsystem is usb > Ethernet
+func (c Classifier) classifySysfs(ifaceName string) (string, bool) {
+	if c.SysfsRoot == "" || ifaceName == "" || strings.ContainsAny(ifaceName, `/\`) {
+		return "", false
+	}
+	dir := filepath.Join(c.SysfsRoot, ifaceName)
+	if _, err := os.Stat(dir); err != nil {
+		return "", false
+	}
+
+	// 虚拟网卡没有物理设备：链接指向 devices/virtual，或没有 device 目录
+	// Virtual NICs have no physical device: the link points into devices/virtual, or there is no device dir
+	if target, err := filepath.EvalSymlinks(dir); err == nil && strings.Contains(filepath.ToSlash(target), "/devices/virtual/") {
+		return NicVirtual, true
+	}
+	if !exists(filepath.Join(dir, "device")) {
+		return NicVirtual, true
+	}
+
+	// 无线网卡（包括 USB 无线网卡） / Wireless NICs (USB Wi-Fi dongles included)
+	if exists(filepath.Join(dir, "wireless")) || exists(filepath.Join(dir, "phy80211")) ||
+		ueventValue(filepath.Join(dir, "uevent"), "DEVTYPE") == "wlan" {
+		return NicWiFi, true
+	}
+
+	// 挂在 USB 总线上的有线网卡，即手机 USB 共享网络 / Wired NICs on the USB bus, i.e. phone USB tethering
+	if target, err := filepath.EvalSymlinks(filepath.Join(dir, "device", "subsystem")); err == nil && filepath.Base(target) == "usb" {
+		return NicUSB, true
+	}
+	return NicEthernet, true
+}
+
+// classifyName 按名称规则表判断类型
+// classifyName classifies using the name rule table
+func (c Classifier) classifyName(ifaceName string) string {
+	goos := c.GOOS
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	for _, rule := range nameRules {
+		if rule.goos != "" && rule.goos != goos {
+			continue
+		}
+		if rule.pattern.MatchString(ifaceName) {
+			return rule.nicType
+		}
+	}
+	return NicEthernet
+}
+
+// exists 判断路径是否存在
+// exists reports whether the path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ueventValue 读取 uevent 文件中的指定键，不存在时返回空字符串
+// ueventValue reads the given key from a uevent file, returning "" when absent
+func ueventValue(path, key string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok && k == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
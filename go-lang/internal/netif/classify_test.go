@@ -0,0 +1,174 @@
+package netif
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sysfsFixture 在临时目录中搭建与 /sys 相同布局的目录树
+// sysfsFixture builds a tree laid out like /sys in a temp dir
+type sysfsFixture struct {
+	t    *testing.T
+	root string
+}
+
+func newSysfsFixture(t *testing.T) *sysfsFixture {
+	t.Helper()
+	f := &sysfsFixture{t: t, root: t.TempDir()}
+	f.mkdir("class/net")
+	f.mkdir("bus/usb")
+	f.mkdir("bus/pci")
+	return f
+}
+
+// net 返回网卡目录（相当于 /sys/class/net）
+// net returns the NIC directory (the /sys/class/net equivalent)
+func (f *sysfsFixture) net() string {
+	return filepath.Join(f.root, "class/net")
+}
+
+func (f *sysfsFixture) mkdir(rel string) string {
+	f.t.Helper()
+	path := filepath.Join(f.root, rel)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		f.t.Fatal(err)
+	}
+	return path
+}
+
+func (f *sysfsFixture) symlink(target, rel string) {
+	f.t.Helper()
+	if err := os.Symlink(filepath.Join(f.root, target), filepath.Join(f.root, rel)); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *sysfsFixture) write(rel, content string) {
+	f.t.Helper()
+	if err := os.WriteFile(filepath.Join(f.root, rel), []byte(content), 0644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// physical 添加挂在 bus 总线上的物理网卡，与内核一样让 class/net/<name> 链接到设备目录，返回网卡目录的相对路径
+// physical adds a physical NIC on the given bus, linking class/net/<name> to its device dir like the kernel does;
+// it returns the relative path of the NIC dir
+func (f *sysfsFixture) physical(name, devicePath, bus string) string {
+	f.t.Helper()
+	f.mkdir(devicePath)
+	f.symlink("bus/"+bus, devicePath+"/subsystem")
+	netDir := devicePath + "/net/" + name
+	f.mkdir(netDir)
+	f.symlink(devicePath, netDir+"/device")
+	f.symlink(netDir, "class/net/"+name)
+	return netDir
+}
+
+func TestClassifySysfs(t *testing.T) {
+	f := newSysfsFixture(t)
+
+	// 虚拟网卡：链接指向 devices/virtual / Virtual NIC: the link points into devices/virtual
+	f.mkdir("devices/virtual/net/docker0")
+	f.symlink("devices/virtual/net/docker0", "class/net/docker0")
+
+	// 没有 device 目录的网卡同样是虚拟网卡 / A NIC without a device dir is virtual too
+	f.mkdir("class/net/mystery0")
+
+	// 板载无线网卡：wireless 目录 / Onboard Wi-Fi: the wireless dir
+	wifi := f.physical("wlp0s20f3", "devices/pci0000:00/0000:00:14.3", "pci")
+	f.mkdir(wifi + "/wireless")
+
+	// 只有 phy80211 的无线网卡 / Wi-Fi with only phy80211
+	phy := f.physical("radio0", "devices/pci0000:00/0000:00:14.4", "pci")
+	f.mkdir(phy + "/phy80211")
+
+	// USB 无线网卡：DEVTYPE=wlan 优先于 USB 总线 / USB Wi-Fi dongle: DEVTYPE=wlan wins over the USB bus
+	dongle := f.physical("wlx001122334455", "devices/pci0000:00/usb1/1-2/1-2:1.0", "usb")
+	f.write(dongle+"/uevent", "INTERFACE=wlx001122334455\nDEVTYPE=wlan\n")
+
+	// 手机 USB 共享：device/subsystem 为 usb / Phone USB tethering: device/subsystem is usb
+	f.physical("enp0s20f0u1", "devices/pci0000:00/usb1/1-1/1-1:1.0", "usb")
+
+	// 名称看起来像 USB，但 sysfs 说是 PCI 以太网 / Looks like USB by name, but sysfs says PCI Ethernet
+	f.physical("usbeth0", "devices/pci0000:00/0000:00:1f.6", "pci")
+
+	// 有线网卡 / Wired NIC
+	f.physical("eno1", "devices/pci0000:00/0000:00:1f.7", "pci")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"docker0", NicVirtual},
+		{"mystery0", NicVirtual},
+		{"wlp0s20f3", NicWiFi},
+		{"radio0", NicWiFi},
+		{"wlx001122334455", NicWiFi},
+		{"enp0s20f0u1", NicUSB},
+		{"usbeth0", NicEthernet},
+		{"eno1", NicEthernet},
+	}
+	c := Classifier{SysfsRoot: f.net(), GOOS: "linux"}
+	for _, tt := range tests {
+		nicType, ok := c.classifySysfs(tt.name)
+		if !ok || nicType != tt.want {
+			t.Errorf("classifySysfs(%q) = %q, %v; want %q, true", tt.name, nicType, ok, tt.want)
+		}
+		if got := c.Classify(tt.name); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyFallsBackToNameRules(t *testing.T) {
+	f := newSysfsFixture(t)
+	c := Classifier{SysfsRoot: f.net(), GOOS: "linux"}
+
+	// 这些网卡不在 sysfs 目录树中 / These NICs are not in the sysfs tree
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"wlo1", NicWiFi},
+		{"enp0s20f0u1", NicUSB},
+		{"enx0a1b2c3d4e5f", NicUSB},
+		{"usb0", NicUSB},
+		{"docker0", NicVirtual},
+		{"tailscale0", NicVirtual},
+		{"enp3s0", NicEthernet},
+		{"../eth0", NicEthernet},
+	}
+	for _, tt := range tests {
+		if _, ok := c.classifySysfs(tt.name); ok {
+			t.Errorf("classifySysfs(%q) found a NIC missing from the fixture", tt.name)
+		}
+		if got := c.Classify(tt.name); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyNameRulesPerOS(t *testing.T) {
+	tests := []struct {
+		goos string
+		name string
+		want string
+	}{
+		{"linux", "wlan0", NicWiFi},
+		{"linux", "enp0s20f0u1", NicUSB},
+		{"darwin", "enp0s20f0u1", NicEthernet},
+		{"darwin", "bridge100", NicUSB},
+		{"darwin", "utun3", NicVirtual},
+		{"windows", "Wi-Fi", NicWiFi},
+		{"windows", "vEthernet (WSL)", NicVirtual},
+		{"windows", "以太网 2 (RNDIS)", NicUSB},
+		{"windows", "以太网", NicEthernet},
+	}
+	for _, tt := range tests {
+		c := Classifier{GOOS: tt.goos}
+		if got := c.Classify(tt.name); got != tt.want {
+			t.Errorf("Classify(%q) on %s = %q, want %q", tt.name, tt.goos, got, tt.want)
+		}
+	}
+}
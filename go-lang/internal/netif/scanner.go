@@ -5,14 +5,13 @@ package netif
 import (
 	"fmt"
 	"net"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
 )
 
-// ScanValidIps 扫描系统中所有有效的 IPv4 地址，按优先级排序返回
-// ScanValidIps scans all valid IPv4 addresses in the system and returns them sorted by priority
+// ScanValidIps 扫描系统中所有有效的 IPv4 地址及 IPv6 全局、链路本地地址，按优先级排序返回
+// ScanValidIps scans all valid IPv4 addresses plus global and link-local IPv6 addresses, sorted by priority
 func ScanValidIps() ([]IpInfo, error) {
 	var ips []IpInfo
 	var mu sync.Mutex
@@ -45,19 +44,30 @@ func ScanValidIps() ([]IpInfo, error) {
 				}
 
 				ip := ipNet.IP
-				// 仅保留 IPv4，过滤回环地址
-				if ip.To4() == nil || ip.IsLoopback() {
+				// 过滤回环地址 / Skip loopback addresses
+				if ip.IsLoopback() {
 					continue
 				}
 
-				nicType := getNicType(i.Name)
-
-				mu.Lock()
-				ips = append(ips, IpInfo{
+				info := IpInfo{
 					IP:        ip.String(),
-					NicType:   nicType,
+					NicType:   getNicType(i.Name),
 					IfaceName: i.Name,
-				})
+					Family:    FamilyIPv4,
+				}
+				if ip.To4() == nil {
+					// IPv6 只保留全局地址和链路本地地址（后者需要 Zone 才能访问） / Keep only global and link-local IPv6 (the latter needs a zone)
+					if !ip.IsGlobalUnicast() && !ip.IsLinkLocalUnicast() {
+						continue
+					}
+					info.Family = FamilyIPv6
+					if ip.IsLinkLocalUnicast() {
+						info.Zone = i.Name
+					}
+				}
+
+				mu.Lock()
+				ips = append(ips, info)
 				mu.Unlock()
 			}
 		}(iface)
@@ -67,24 +77,25 @@ func ScanValidIps() ([]IpInfo, error) {
 	// 去重
 	ips = deduplicateIps(ips)
 
-	// 排序：以太网 > USB共享网卡 > WiFi > 虚拟网卡
-	sort.Slice(ips, func(i, j int) bool {
+	// 排序：虚拟网卡排最后；其余按 IPv4 > IPv6 全局 > IPv6 链路本地，同一类地址再按以太网 > USB共享网卡 > WiFi
+	// Sort: virtual NICs last; otherwise IPv4 > global IPv6 > link-local IPv6, then Ethernet > USB tether > WiFi
+	sort.SliceStable(ips, func(i, j int) bool {
 		// 虚拟网卡排最后
-		if ips[i].NicType == "虚拟网卡" && ips[j].NicType != "虚拟网卡" {
-			return false
+		if (ips[i].NicType == NicVirtual) != (ips[j].NicType == NicVirtual) {
+			return ips[j].NicType == NicVirtual
 		}
-		if ips[i].NicType != "虚拟网卡" && ips[j].NicType == "虚拟网卡" {
-			return true
+		if fi, fj := familyRank(ips[i]), familyRank(ips[j]); fi != fj {
+			return fi < fj
 		}
 		// 以太网优先
-		if ips[i].NicType == "以太网" && ips[j].NicType != "以太网" {
+		if ips[i].NicType == NicEthernet && ips[j].NicType != NicEthernet {
 			return true
 		}
-		if ips[i].NicType != "以太网" && ips[j].NicType == "以太网" {
+		if ips[i].NicType != NicEthernet && ips[j].NicType == NicEthernet {
 			return false
 		}
 		// USB 共享网卡次之
-		if ips[i].NicType == "USB共享网卡" && ips[j].NicType != "USB共享网卡" {
+		if ips[i].NicType == NicUSB && ips[j].NicType != NicUSB {
 			return true
 		}
 		return false
@@ -93,66 +104,23 @@ func ScanValidIps() ([]IpInfo, error) {
 	return ips, nil
 }
 
-// getNicType 确定网络接口类型
-// getNicType determines the network interface type
-func getNicType(ifaceName string) string {
-	// 检测虚拟机网卡
-	if isVirtualNic(ifaceName) {
-		return "虚拟网卡"
-	}
-	if detectUsbNic(ifaceName) {
-		return "USB共享网卡"
-	}
-	if strings.HasPrefix(ifaceName, "wlan") || strings.HasPrefix(ifaceName, "wlp") {
-		return "WiFi"
-	}
-	return "以太网"
-}
-
-// isVirtualNic 检测是否为虚拟网卡
-// isVirtualNic detects if the network interface is a virtual network card
-func isVirtualNic(ifaceName string) bool {
-	virtualPrefixes := []string{
-		// Linux 虚拟网卡
-		"virbr", "veth", "docker", "br-", "tun", "tap", "vnet", "utun", "awdl",
-		// VMware 虚拟网卡
-		"vmnet", "vmware",
-		// VirtualBox 虚拟网卡
-		"vbox", "vnic",
-		// Hyper-V 虚拟网卡
-		"vEthernet",
-		// KVM/QEMU 虚拟网卡
-		"kvm", "qemu",
-		// 其他虚拟网卡
-		"lxc", "lxd", "podman", "flannel", "cni",
-	}
-	lowerName := strings.ToLower(ifaceName)
-	for _, prefix := range virtualPrefixes {
-		if strings.HasPrefix(lowerName, prefix) {
-			return true
-		}
+// familyRank 返回地址族的排序权重：IPv4 最优先，链路本地 IPv6 最后
+// familyRank returns the sort weight of the address family: IPv4 first, link-local IPv6 last
+func familyRank(info IpInfo) int {
+	switch {
+	case info.Family != FamilyIPv6:
+		return 0
+	case info.Zone == "":
+		return 1
+	default:
+		return 2
 	}
-	return false
 }
 
-// detectUsbNic 检测是否为 USB 共享网卡
-func detectUsbNic(ifaceName string) bool {
-	switch runtime.GOOS {
-	case "windows":
-		// Windows: RNDIS、USB 字样
-		return strings.Contains(strings.ToLower(ifaceName), "rndis") ||
-			strings.Contains(strings.ToLower(ifaceName), "usb")
-	case "darwin":
-		// macOS: bridge、usb 字样
-		return strings.Contains(strings.ToLower(ifaceName), "bridge") ||
-			strings.Contains(strings.ToLower(ifaceName), "usb")
-	case "linux":
-		// Linux: usb、enx 前缀
-		return strings.Contains(strings.ToLower(ifaceName), "usb") ||
-			strings.HasPrefix(ifaceName, "enx")
-	default:
-		return false
-	}
+// getNicType 确定网络接口类型
+// getNicType determines the network interface type
+func getNicType(ifaceName string) string {
+	return defaultClassifier.Classify(ifaceName)
 }
 
 // deduplicateIps 去重 IP 地址
@@ -162,8 +130,9 @@ func deduplicateIps(ips []IpInfo) []IpInfo {
 	result := make([]IpInfo, 0)
 
 	for _, ip := range ips {
-		if !seen[ip.IP] {
-			seen[ip.IP] = true
+		// 链路本地地址可能出现在多个网卡上，以带 Zone 的地址去重 / Link-local addresses may repeat across NICs, so dedupe with the zone
+		if !seen[ip.Host()] {
+			seen[ip.Host()] = true
 			result = append(result, ip)
 		}
 	}
@@ -178,7 +147,7 @@ func GetUsbShareIp() (string, error) {
 	}
 
 	for _, ip := range ips {
-		if ip.NicType == "USB共享网卡" {
+		if ip.NicType == NicUSB {
 			return ip.IP, nil
 		}
 	}
@@ -189,4 +158,39 @@ func GetUsbShareIp() (string, error) {
 	}
 
 	return "", fmt.Errorf("未找到有效网卡")
-}
\ No newline at end of file
+}
+
+// PreferIP 将用户指定的网卡或 IP 移到列表首位（作为默认访问地址）
+// iface 和 ip 都为空时原样返回；都指定时 ip 优先；ip 可以带 Zone（如 fe80::1%eth0）
+// PreferIP moves the user-selected interface or IP to the front of the list (making it the default address)
+// The list is returned unchanged when both are empty; ip wins when both are given; ip may carry a zone (e.g. fe80::1%eth0)
+func PreferIP(ips []IpInfo, iface, ip string) ([]IpInfo, error) {
+	if iface == "" && ip == "" {
+		return ips, nil
+	}
+
+	index := -1
+	for i, info := range ips {
+		if (ip != "" && (info.IP == ip || info.Host() == ip)) || (ip == "" && info.IfaceName == iface) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		available := make([]string, len(ips))
+		for i, info := range ips {
+			available[i] = fmt.Sprintf("%s (%s)", info.Host(), info.IfaceName)
+		}
+		target := ip
+		if target == "" {
+			target = iface
+		}
+		return nil, fmt.Errorf("未找到指定的网卡或 IP: %s，可用: %s", target, strings.Join(available, ", "))
+	}
+
+	result := make([]IpInfo, 0, len(ips))
+	result = append(result, ips[index])
+	result = append(result, ips[:index]...)
+	result = append(result, ips[index+1:]...)
+	return result, nil
+}
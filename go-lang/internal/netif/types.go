@@ -2,10 +2,27 @@
 // Package netif defines data structures for network interface information
 package netif
 
+// 地址族 / Address families
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
 // IpInfo 表示网卡信息
 // IpInfo represents network interface information
 type IpInfo struct {
-	IP        string `json:"ip"`        // IP 地址
-	NicType   string `json:"nicType"`   // 网卡类型
-	IfaceName string `json:"ifaceName"` // 网卡名称
-}
\ No newline at end of file
+	IP        string `json:"ip"`             // IP 地址（不含 Zone） / IP address (without the zone)
+	NicType   string `json:"nicType"`        // 网卡类型
+	IfaceName string `json:"ifaceName"`      // 网卡名称
+	Family    string `json:"family"`         // 地址族: ipv4 或 ipv6 / Address family: ipv4 or ipv6
+	Zone      string `json:"zone,omitempty"` // IPv6 链路本地地址的 Zone（网卡名称） / Zone of an IPv6 link-local address (the interface name)
+}
+
+// Host 返回带 Zone 的地址（如 fe80::1%eth0），用于拼接 URL 和匹配用户输入
+// Host returns the address with its zone (e.g. fe80::1%eth0), for building URLs and matching user input
+func (info IpInfo) Host() string {
+	if info.Zone == "" {
+		return info.IP
+	}
+	return info.IP + "%" + info.Zone
+}
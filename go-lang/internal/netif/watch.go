@@ -0,0 +1,182 @@
+// Package netif 提供网络变化监听：Linux 使用 netlink 通知，其他平台定期轮询
+// Package netif provides network change monitoring: netlink notifications on Linux, periodic polling elsewhere
+package netif
+
+import (
+	"sort"
+	"time"
+)
+
+// EventKind 表示网络变化的类型
+// EventKind represents the type of a network change
+type EventKind string
+
+const (
+	EventAdded   EventKind = "added"   // 新增地址 / Address added
+	EventRemoved EventKind = "removed" // 地址消失 / Address removed
+	EventChanged EventKind = "changed" // 网卡地址或类型变化 / Interface address or type changed
+)
+
+// settleDelay 收到通知后等待地址配置稳定的时间（DHCP 等会连续产生多条通知）
+// settleDelay is how long to wait after a notification for addresses to settle (DHCP etc. emit bursts)
+const settleDelay = 500 * time.Millisecond
+
+// Event 描述一次网络变化
+// Event describes a network change
+type Event struct {
+	Kind EventKind `json:"kind"`
+	IP   IpInfo    `json:"ip"`            // 当前地址（removed 时为消失的地址） / Current address (the vanished one for removed)
+	Old  *IpInfo   `json:"old,omitempty"` // changed 时的原地址 / Previous address for changed
+}
+
+// Watcher 监听网卡地址变化
+// Watcher monitors interface address changes
+type Watcher struct {
+	interval time.Duration
+	onChange func(ips []IpInfo, events []Event)
+	stop     chan struct{}
+	mode     string
+	notify   <-chan struct{}
+}
+
+// Watch 开始监听网络变化；地址列表变化时以最新列表（已排序）和变化事件调用 onChange
+// 支持 netlink 时由通知触发重新扫描，否则按 interval 轮询
+// Watch starts monitoring network changes; onChange is called with the new sorted list and the events when it changes
+// Rescans are triggered by netlink notifications where supported, otherwise the list is polled every interval
+func Watch(current []IpInfo, interval time.Duration, onChange func(ips []IpInfo, events []Event)) *Watcher {
+	w := &Watcher{
+		interval: interval,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+		mode:     "polling",
+	}
+	if notify, err := subscribe(w.stop); err == nil {
+		w.notify = notify
+		w.mode = "netlink"
+	}
+	go w.run(current)
+	return w
+}
+
+// Mode 返回监听方式："netlink" 或 "polling"
+// Mode returns how changes are detected: "netlink" or "polling"
+func (w *Watcher) Mode() string {
+	return w.mode
+}
+
+// Stop 停止监听
+// Stop stops monitoring
+func (w *Watcher) Stop() {
+	close(w.stop)
+}
+
+// run 监听循环；netlink 通道关闭时退回轮询
+// run is the monitoring loop; it falls back to polling when the netlink channel closes
+func (w *Watcher) run(last []IpInfo) {
+	var tick <-chan time.Time
+	if w.notify == nil {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case _, ok := <-w.notify:
+			if !ok {
+				w.notify = nil
+				ticker := time.NewTicker(w.interval)
+				defer ticker.Stop()
+				tick = ticker.C
+				continue
+			}
+			if !w.settle() {
+				return
+			}
+		case <-tick:
+		}
+
+		ips, err := ScanValidIps()
+		if err != nil {
+			continue
+		}
+		if events := Diff(last, ips); len(events) > 0 {
+			last = ips
+			w.onChange(ips, events)
+		}
+	}
+}
+
+// settle 等待通知平息，合并期间收到的后续通知；收到停止信号时返回 false
+// settle waits for notifications to calm down, absorbing any that arrive meanwhile; returns false when stopped
+func (w *Watcher) settle() bool {
+	timer := time.NewTimer(settleDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return false
+		case <-w.notify:
+			timer.Reset(settleDelay)
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// Diff 比较两次扫描结果：IP 相同但网卡信息不同，或同一网卡换了 IP，视为 changed
+// Diff compares two scans: the same IP with different interface details, or an interface with a new IP, counts as changed
+func Diff(old, new []IpInfo) []Event {
+	// 以带 Zone 的地址为键，区分不同网卡上的同一链路本地地址 / Key by address with zone, so a link-local address on two NICs stays distinct
+	oldByIP := make(map[string]IpInfo, len(old))
+	for _, info := range old {
+		oldByIP[info.Host()] = info
+	}
+	newByIP := make(map[string]IpInfo, len(new))
+	for _, info := range new {
+		newByIP[info.Host()] = info
+	}
+
+	var events []Event
+	var added []IpInfo
+	for _, info := range new {
+		prev, ok := oldByIP[info.Host()]
+		switch {
+		case !ok:
+			added = append(added, info)
+		case prev != info:
+			events = append(events, Event{Kind: EventChanged, IP: info, Old: &prev})
+		}
+	}
+
+	// 同一网卡上消失的地址与新增的地址配对为 changed（如切换 WiFi 后 DHCP 分配了新地址）
+	// Pair a vanished address with a new one on the same interface as changed (e.g. a new DHCP lease after roaming)
+	for _, info := range old {
+		if _, ok := newByIP[info.Host()]; ok {
+			continue
+		}
+		prev := info
+		paired := false
+		for i, a := range added {
+			if a.IfaceName == info.IfaceName && a.Family == info.Family {
+				events = append(events, Event{Kind: EventChanged, IP: a, Old: &prev})
+				added = append(added[:i], added[i+1:]...)
+				paired = true
+				break
+			}
+		}
+		if !paired {
+			events = append(events, Event{Kind: EventRemoved, IP: info})
+		}
+	}
+	for _, info := range added {
+		events = append(events, Event{Kind: EventAdded, IP: info})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Kind < events[j].Kind
+	})
+	return events
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+// Package netif 在 Linux 上通过 netlink 接收网卡和地址变化通知
+// Package netif receives link and address change notifications over netlink on Linux
+package netif
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+const (
+	rtmgrpLink       = 0x1   // RTMGRP_LINK：网卡增删和状态变化 / Link added, removed or state changed
+	rtmgrpIPv4IfAddr = 0x10  // RTMGRP_IPV4_IFADDR：IPv4 地址增删 / IPv4 address added or removed
+	rtmgrpIPv6IfAddr = 0x100 // RTMGRP_IPV6_IFADDR：IPv6 地址增删 / IPv6 address added or removed
+
+	// netlinkReadTimeout 读取超时，用于定期检查停止信号 / Read timeout, so the stop signal is checked regularly
+	netlinkReadTimeout = time.Second
+)
+
+// subscribe 订阅 rtnetlink 的网卡和 IPv4/IPv6 地址通知，每次变化向返回的通道发送信号
+// 读取出错时关闭通道，调用方应退回轮询
+// subscribe subscribes to rtnetlink link and IPv4/IPv6 address notifications, signalling the returned channel on each change
+// The channel is closed on a read error, after which the caller should fall back to polling
+func subscribe(stop <-chan struct{}) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("创建 netlink 套接字失败: %w", err)
+	}
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("绑定 netlink 套接字失败: %w", err)
+	}
+	tv := syscall.NsecToTimeval(int64(netlinkReadTimeout))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("设置 netlink 超时失败: %w", err)
+	}
+
+	notify := make(chan struct{}, 1)
+	go func() {
+		defer syscall.Close(fd)
+		defer close(notify)
+
+		buf := make([]byte, 64*1024)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil {
+				if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+					continue
+				}
+				// ENOBUFS 表示通知过多被丢弃，仍然需要重新扫描 / ENOBUFS means notifications were dropped; a rescan is still needed
+				if !errors.Is(err, syscall.ENOBUFS) {
+					return
+				}
+			} else if !hasAddressChange(buf[:n]) {
+				continue
+			}
+
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notify, nil
+}
+
+// hasAddressChange 判断 netlink 消息中是否包含网卡或地址的增删
+// hasAddressChange reports whether the netlink messages contain a link or address addition or removal
+func hasAddressChange(data []byte) bool {
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return true
+	}
+	for _, m := range msgs {
+		switch m.Header.Type {
+		case syscall.RTM_NEWLINK, syscall.RTM_DELLINK, syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+// Package netif 在非 Linux 平台上没有网络变化通知，由调用方轮询
+// Package netif has no change notifications outside Linux, so callers poll
+package netif
+
+import "errors"
+
+// subscribe 非 Linux 平台不支持通知
+// subscribe is not supported outside Linux
+func subscribe(stop <-chan struct{}) (<-chan struct{}, error) {
+	return nil, errors.New("当前平台不支持网络变化通知")
+}
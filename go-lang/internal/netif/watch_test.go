@@ -0,0 +1,78 @@
+package netif
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	eth := IpInfo{IP: "192.168.1.10", NicType: NicEthernet, IfaceName: "eth0", Family: FamilyIPv4}
+	wifi := IpInfo{IP: "10.0.0.5", NicType: NicWiFi, IfaceName: "wlan0", Family: FamilyIPv4}
+	wifiRoamed := IpInfo{IP: "10.0.1.7", NicType: NicWiFi, IfaceName: "wlan0", Family: FamilyIPv4}
+	usb := IpInfo{IP: "192.168.42.2", NicType: NicUSB, IfaceName: "usb0", Family: FamilyIPv4}
+	usbRenamed := IpInfo{IP: "192.168.42.2", NicType: NicUSB, IfaceName: "rndis0", Family: FamilyIPv4}
+	linkLocal := IpInfo{IP: "fe80::1", NicType: NicEthernet, IfaceName: "eth0", Family: FamilyIPv6, Zone: "eth0"}
+	linkLocalWifi := IpInfo{IP: "fe80::1", NicType: NicWiFi, IfaceName: "wlan0", Family: FamilyIPv6, Zone: "wlan0"}
+
+	tests := []struct {
+		name string
+		old  []IpInfo
+		new  []IpInfo
+		want []Event
+	}{
+		{
+			name: "unchanged",
+			old:  []IpInfo{eth, wifi},
+			new:  []IpInfo{wifi, eth},
+		},
+		{
+			name: "added",
+			old:  []IpInfo{eth},
+			new:  []IpInfo{eth, usb},
+			want: []Event{{Kind: EventAdded, IP: usb}},
+		},
+		{
+			name: "removed",
+			old:  []IpInfo{eth, usb},
+			new:  []IpInfo{eth},
+			want: []Event{{Kind: EventRemoved, IP: usb}},
+		},
+		{
+			name: "same interface with a new address",
+			old:  []IpInfo{eth, wifi},
+			new:  []IpInfo{eth, wifiRoamed},
+			want: []Event{{Kind: EventChanged, IP: wifiRoamed, Old: &wifi}},
+		},
+		{
+			name: "same address on another interface",
+			old:  []IpInfo{usb},
+			new:  []IpInfo{usbRenamed},
+			want: []Event{{Kind: EventChanged, IP: usbRenamed, Old: &usb}},
+		},
+		{
+			name: "new address on another interface is not paired",
+			old:  []IpInfo{wifi},
+			new:  []IpInfo{usb},
+			want: []Event{{Kind: EventAdded, IP: usb}, {Kind: EventRemoved, IP: wifi}},
+		},
+		{
+			name: "link-local addresses are keyed by zone",
+			old:  []IpInfo{linkLocal},
+			new:  []IpInfo{linkLocal, linkLocalWifi},
+			want: []Event{{Kind: EventAdded, IP: linkLocalWifi}},
+		},
+		{
+			name: "everything gone",
+			old:  []IpInfo{eth, wifi},
+			new:  nil,
+			want: []Event{{Kind: EventRemoved, IP: eth}, {Kind: EventRemoved, IP: wifi}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
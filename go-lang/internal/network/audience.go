@@ -0,0 +1,69 @@
+// Package network 提供定向发送：按连接 ID、客户端角色（电脑端/手机端）或手机端设备选择消息的接收者
+// Package network provides targeted delivery: messages addressed by connection ID, client role (PC/mobile) or mobile device
+package network
+
+import "net/http"
+
+// ClientRole 表示客户端角色
+// ClientRole is the role of a client
+type ClientRole string
+
+const (
+	RolePC     ClientRole = "pc"     // 电脑端页面 / PC page
+	RoleMobile ClientRole = "mobile" // 手机端页面 / Mobile page
+)
+
+// envelope 表示待发送的消息及其接收者
+// envelope is a message waiting to be delivered along with its audience
+type envelope struct {
+	message Message
+	to      func(*SSEClient) bool // 为 nil 时发送给所有客户端 / nil delivers to every client
+}
+
+// clientRole 根据查询参数 type 判断客户端角色；未指定时按 IP 判断（本机为电脑端），兼容旧页面
+// clientRole determines the client role from the type query parameter; without it the IP decides (local is PC) for older pages
+func clientRole(r *http.Request, clientIP string) ClientRole {
+	switch ClientRole(r.URL.Query().Get("type")) {
+	case RoleMobile:
+		return RoleMobile
+	case RolePC:
+		return RolePC
+	}
+	if isLocalIP(clientIP) {
+		return RolePC
+	}
+	return RoleMobile
+}
+
+// deliver 将消息交给 Run 协程发送给匹配的客户端，保持与 Broadcast 相同的顺序
+// deliver hands the message to the Run goroutine for the matching clients, keeping the same ordering as Broadcast
+func (s *SSEServer) deliver(message Message, to func(*SSEClient) bool) {
+	s.broadcast <- envelope{message: message, to: to}
+}
+
+// SendTo 将消息发送给指定连接
+// SendTo sends the message to the given connection
+func (s *SSEServer) SendTo(clientID string, message Message) {
+	s.deliver(message, func(c *SSEClient) bool { return c.ID == clientID })
+}
+
+// SendToRole 将消息发送给指定角色的所有客户端
+// SendToRole sends the message to every client with the given role
+func (s *SSEServer) SendToRole(role ClientRole, message Message) {
+	s.deliver(message, func(c *SSEClient) bool { return c.Role == role })
+}
+
+// SendToDevice 将消息发送给指定手机端设备的所有连接
+// SendToDevice sends the message to every connection of the given mobile device
+func (s *SSEServer) SendToDevice(deviceID string, message Message) {
+	if deviceID == "" {
+		return
+	}
+	s.deliver(message, func(c *SSEClient) bool { return c.DeviceID == deviceID })
+}
+
+// isPC 判断客户端是否为电脑端页面
+// isPC reports whether the client is a PC page
+func isPC(c *SSEClient) bool {
+	return c.Role == RolePC
+}
@@ -0,0 +1,125 @@
+// Package network 提供手机端设备标识：持久化的设备 ID Cookie 和设备名称
+// Package network provides mobile device identity: a persistent device ID cookie and the device name
+package network
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DeviceCookieName    = "airinput_device"    // 设备 ID Cookie 名称
+	deviceCookieMaxAge  = 365 * 24 * time.Hour // 设备 ID 有效期，远长于配对会话
+	deviceIDByteLen     = 8                    // 设备 ID 字节数
+	MaxDeviceNameLength = 32                   // 设备名称最大长度（字符数）
+)
+
+// DeviceInfo 描述一个已连接的手机端设备
+// DeviceInfo describes a connected mobile device
+type DeviceInfo struct {
+	ID          string    `json:"id"`          // 设备 ID / Device ID
+	Name        string    `json:"name"`        // 设备名称 / Device name
+	IP          string    `json:"ip"`          // 最近连接的 IP / IP of the latest connection
+	Connections int       `json:"connections"` // 当前连接数（同一设备可能有多个页面） / Open connections (one device may have several pages)
+	ConnectedAt time.Time `json:"connectedAt"` // 最早连接的时间 / Time of the earliest connection
+}
+
+// DeviceID 返回请求携带的设备 ID，没有时返回空字符串
+// DeviceID returns the device ID carried by the request, or "" when absent
+func DeviceID(r *http.Request) string {
+	cookie, err := r.Cookie(DeviceCookieName)
+	if err != nil || !validDeviceID(cookie.Value) {
+		return ""
+	}
+	return cookie.Value
+}
+
+// deviceKey 返回设备的唯一标识：有设备 ID 时使用设备 ID，没有 Cookie 的手机使用 IP，
+// 接管、踢出和撤销配对都使用这个标识，断开后重新连接也无法绕过
+// deviceKey returns the single identity of a device: the device ID when present, the IP for a phone without the cookie.
+// Takeover, kick and pairing revocation all use it, so reconnecting cannot escape them
+func deviceKey(deviceID, ip string) string {
+	if deviceID != "" {
+		return deviceID
+	}
+	return ip
+}
+
+// EnsureDeviceID 返回请求的设备 ID，没有时生成新的 ID 并写入 Cookie
+// EnsureDeviceID returns the request's device ID, generating one and setting the cookie when absent
+func EnsureDeviceID(w http.ResponseWriter, r *http.Request) string {
+	if id := DeviceID(r); id != "" {
+		return id
+	}
+	token, err := randomToken(deviceIDByteLen)
+	if err != nil {
+		LogInfo("生成设备 ID 失败: %v", err)
+		return ""
+	}
+	id := "dev_" + token
+	http.SetCookie(w, &http.Cookie{
+		Name:     DeviceCookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(deviceCookieMaxAge / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	LogFormat("处理", "HTTP", "服务端", "分配设备 ID: %s，IP: %s", id, getClientIP(r))
+	return id
+}
+
+// DeviceName 返回请求中的设备名称（查询参数 name），未指定时根据 User-Agent 和设备 ID 生成
+// DeviceName returns the device name from the request (the name query parameter), derived from the User-Agent and device ID when unset
+func DeviceName(r *http.Request, deviceID string) string {
+	if name := sanitizeDeviceName(r.URL.Query().Get("name")); name != "" {
+		return name
+	}
+	ua := r.UserAgent()
+	model := "手机"
+	switch {
+	case strings.Contains(ua, "iPhone"):
+		model = "iPhone"
+	case strings.Contains(ua, "iPad"):
+		model = "iPad"
+	case strings.Contains(ua, "Android"):
+		model = "Android"
+	}
+	if len(deviceID) >= 4 {
+		return model + "-" + deviceID[len(deviceID)-4:]
+	}
+	return model
+}
+
+// sanitizeDeviceName 去掉设备名称中的控制字符并限制长度
+// sanitizeDeviceName strips control characters from a device name and limits its length
+func sanitizeDeviceName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	for utf8.RuneCountInString(name) > MaxDeviceNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// validDeviceID 检查设备 ID 格式，避免把任意 Cookie 值写入日志和卡片
+// validDeviceID checks the device ID format so arbitrary cookie values do not end up in logs and cards
+func validDeviceID(id string) bool {
+	if !strings.HasPrefix(id, "dev_") || len(id) != len("dev_")+deviceIDByteLen*2 {
+		return false
+	}
+	for _, c := range id[len("dev_"):] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -4,10 +4,12 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 )
 
 // HttpServer 表示 HTTP 服务实例
@@ -16,8 +18,9 @@ type HttpServer struct {
 	port     int
 	ip       string
 	mux      *http.ServeMux
-	server   *http.Server
-	listener net.Listener
+	server    *http.Server
+	listener  net.Listener
+	tlsConfig *tls.Config // 非空时以 HTTPS 方式提供服务
 }
 
 // NewHttpServer 创建并返回一个新的 HTTP 服务实例
@@ -31,6 +34,21 @@ func NewHttpServer(port int, ip string) *HttpServer {
 	}
 }
 
+// SetTLSConfig 设置 TLS 配置，需在 Start 之前调用；设置后服务以 HTTPS 方式运行
+// SetTLSConfig sets the TLS configuration; must be called before Start. The service then runs over HTTPS
+func (hs *HttpServer) SetTLSConfig(config *tls.Config) {
+	hs.tlsConfig = config
+}
+
+// Scheme 返回服务使用的协议（http 或 https）
+// Scheme returns the protocol used by the service (http or https)
+func (hs *HttpServer) Scheme() string {
+	if hs.tlsConfig != nil {
+		return "https"
+	}
+	return "http"
+}
+
 // HandleFunc 为指定的 URL 模式注册处理函数
 // HandleFunc registers a handler function for the specified URL pattern
 func (hs *HttpServer) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
@@ -43,29 +61,43 @@ func (hs *HttpServer) Handle(pattern string, handler http.Handler) {
 	hs.mux.Handle(pattern, handler)
 }
 
-// Start 启动 HTTP 服务，从 5000 端口开始尝试绑定，阻塞运行直到出错
-// Start starts the HTTP service, trying to bind from port 5000, and blocks until an error occurs
+// IP 返回实际监听的地址（IPv6 不可用时 :: 会改为 0.0.0.0）
+// IP returns the address actually listened on (:: becomes 0.0.0.0 when IPv6 is unavailable)
+func (hs *HttpServer) IP() string {
+	return hs.ip
+}
+
+// Start 启动 HTTP 服务并返回实际使用的端口
+// 指定了端口时只绑定该端口，否则从 5000 端口开始尝试绑定
+// Start starts the HTTP service and returns the port in use
+// A fixed port is bound as-is; otherwise ports are tried starting from 5000
 func (hs *HttpServer) Start() (int, error) {
-	const (
-		DefaultPortStart = 5000
-		MaxPortTry       = 100
-	)
-	
-	// 从 5000 端口开始尝试绑定
-	for port := DefaultPortStart; port < DefaultPortStart+MaxPortTry; port++ {
-		addr := fmt.Sprintf("%s:%d", hs.ip, port)
+	portStart, portTry := DefaultPortStart, MaxPortTry
+	if hs.port > 0 {
+		portStart, portTry = hs.port, 1
+	}
+	hs.fallbackToIPv4()
+
+	// 从起始端口开始尝试绑定
+	var lastErr error
+	for port := portStart; port < portStart+portTry; port++ {
+		addr := net.JoinHostPort(hs.ip, strconv.Itoa(port))
 		
 		// 创建 listener 尝试绑定端口
 		listener, err := net.Listen("tcp", addr)
 		if err == nil {
 			// 绑定成功
+			if hs.tlsConfig != nil {
+				listener = tls.NewListener(listener, hs.tlsConfig)
+			}
 			hs.port = port
 			hs.listener = listener
 			hs.server = &http.Server{
-				Addr:    addr,
-				Handler: hs.mux,
+				Addr:      addr,
+				Handler:   hs.mux,
+				TLSConfig: hs.tlsConfig,
 			}
-			LogFormat("启动", "HTTP", "系统", "服务绑定成功: %s", addr)
+			LogFormat("启动", "HTTP", "系统", "服务绑定成功: %s://%s", hs.Scheme(), addr)
 			LogFormat("提示", "HTTP", "系统", "如果手机无法访问，请检查防火墙和杀毒软件设置")
 			
 			// 在 goroutine 中启动服务
@@ -77,14 +109,34 @@ func (hs *HttpServer) Start() (int, error) {
 			
 			return port, nil
 		}
+		lastErr = err
 	}
 	
-	// 所有端口都被占用
-	err := fmt.Errorf("端口适配失败：连续 %d 个端口被占用", MaxPortTry)
+	// 所有端口都绑定失败，带上最后一次的真实错误 / Every port failed; include the last real error
+	err := fmt.Errorf("端口适配失败：连续 %d 个端口绑定失败: %w", portTry, lastErr)
+	if portTry == 1 {
+		err = fmt.Errorf("端口 %d 绑定失败: %w", portStart, lastErr)
+	}
 	LogFormat("错误", "HTTP", "系统", "%v", err)
 	return 0, err
 }
 
+// fallbackToIPv4 监听地址为 :: 但系统不支持 IPv6（如内核禁用了 IPv6）时改为监听 0.0.0.0
+// fallbackToIPv4 switches to 0.0.0.0 when listening on :: but the system has no IPv6 (disabled in the kernel, for example)
+func (hs *HttpServer) fallbackToIPv4() {
+	ip := net.ParseIP(hs.ip)
+	if ip == nil || !ip.IsUnspecified() || ip.To4() != nil {
+		return
+	}
+	probe, err := net.Listen("tcp", "[::]:0")
+	if err == nil {
+		probe.Close()
+		return
+	}
+	LogInfo("IPv6 不可用（%v），改为监听 0.0.0.0", err)
+	hs.ip = "0.0.0.0"
+}
+
 // Shutdown 优雅关闭 HTTP 服务
 // Shutdown gracefully shuts down the HTTP service
 func (hs *HttpServer) Shutdown(ctx context.Context) error {
@@ -98,15 +150,25 @@ func (hs *HttpServer) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// HandleGetIP 返回一个处理函数，用于响应获取 IP 列表的请求
-// HandleGetIP returns a handler function for responding to IP list requests
-func HandleGetIP(ips []interface{}) http.HandlerFunc {
+// HandleGetIP 返回一个处理函数，用于响应获取 IP 列表的请求（每次请求时读取最新列表）
+// HandleGetIP returns a handler function for responding to IP list requests (the list is read on every request)
+func HandleGetIP(ips func() []interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ips": ips(),
+		})
+	}
+}
+
+// HandleGetTLS 返回一个处理函数，用于响应获取 HTTPS 状态及证书指纹的请求
+// HandleGetTLS returns a handler function for responding to HTTPS status and certificate fingerprint requests
+func HandleGetTLS(enabled bool, fingerprint string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"ips": ips,
+			"enabled":     enabled,
+			"fingerprint": fingerprint,
 		})
 	}
 }
@@ -116,8 +178,6 @@ func HandleGetIP(ips []interface{}) http.HandlerFunc {
 func HandleGetPort(port int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"port": port,
 		})
@@ -0,0 +1,68 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStartReportsListenError(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer occupied.Close()
+	port := occupied.Addr().(*net.TCPAddr).Port
+
+	// 各平台的系统错误码不同，只检查错误信息带上了端口和底层的监听错误
+	// System error codes differ across platforms, so only check that the message names the port and wraps the listen error
+	tests := []struct {
+		name string
+		ip   string
+	}{
+		{"port in use", "127.0.0.1"},
+		// TEST-NET-3 地址不属于本机 / A TEST-NET-3 address is never local
+		{"address not available", "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		_, err := NewHttpServer(port, tt.ip).Start()
+		if err == nil {
+			t.Fatalf("%s: Start succeeded", tt.name)
+		}
+		if want := fmt.Sprintf("端口 %d 绑定失败", port); !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %q, want it to contain %q", tt.name, err, want)
+		}
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) || opErr.Op != "listen" {
+			t.Errorf("%s: error = %v, want it to wrap the listen error", tt.name, err)
+		}
+	}
+}
+
+func TestStartListensOnUnspecifiedIPv6(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	free.Close()
+
+	hs := NewHttpServer(free.Addr().(*net.TCPAddr).Port, "::")
+	port, err := hs.Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer hs.server.Close()
+
+	// 无论是否回退到 0.0.0.0，IPv4 回环地址都能连上 / IPv4 loopback connects whether or not it fell back to 0.0.0.0
+	if ip := hs.IP(); ip != "::" && ip != "0.0.0.0" {
+		t.Errorf("IP = %q, want :: or 0.0.0.0", ip)
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close()
+}
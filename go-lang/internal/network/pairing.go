@@ -0,0 +1,440 @@
+// Package network 提供手机端配对功能：一次性配对码 + 会话 Cookie
+// Package network provides mobile pairing: a one-time PIN plus a session cookie
+package network
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PairingCookieName     = "airinput_session" // 会话 Cookie 名称
+	PairingPINLength      = 6                  // 配对码位数
+	DefaultPINTTL         = 5 * time.Minute    // 配对码有效期
+	DefaultSessionTTL     = 12 * time.Hour     // 会话有效期
+	maxPairingFailures    = 5                  // 同一 IP 连续失败次数上限，超过后锁定该 IP
+	pairingLockout        = 30 * time.Second   // 第一次锁定的时长，之后每次加倍
+	maxPairingLockout     = 10 * time.Minute   // 锁定时长上限
+	pairingSessionByteLen = 32                 // 会话令牌字节数
+)
+
+// ErrInvalidPIN 表示配对码错误或已过期
+// ErrInvalidPIN indicates the PIN is wrong or has expired
+var ErrInvalidPIN = errors.New("配对码无效或已过期")
+
+// ErrPairingLocked 表示该 IP 失败次数过多，暂时不能配对
+// ErrPairingLocked indicates the IP failed too often and cannot pair for now
+var ErrPairingLocked = errors.New("配对失败次数过多，请稍后再试")
+
+// pairingSession 表示一个已配对的会话
+// pairingSession represents a paired session
+type pairingSession struct {
+	IP        string
+	DeviceID  string // 配对时分配的设备 ID，见 device.go
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// pairingFailure 记录一个 IP 的配对失败，用于按 IP 锁定
+// pairingFailure records the pairing failures of one IP, for per-IP lockout
+type pairingFailure struct {
+	count       int       // 上次锁定以来的失败次数 / Failures since the last lockout
+	lockouts    int       // 已锁定的次数，决定下次锁定时长 / Lockouts so far, which set the next lockout's length
+	lockedUntil time.Time // 锁定结束时间 / End of the current lockout
+	lastFailure time.Time
+}
+
+// lockoutDuration 返回第 n 次锁定的时长：从 pairingLockout 开始加倍，不超过 maxPairingLockout
+// lockoutDuration returns the length of the n-th lockout: doubling from pairingLockout, capped at maxPairingLockout
+func lockoutDuration(n int) time.Duration {
+	d := pairingLockout
+	for i := 1; i < n && d < maxPairingLockout; i++ {
+		d *= 2
+	}
+	return min(d, maxPairingLockout)
+}
+
+// PairingManager 管理配对码与已配对会话
+// PairingManager manages the pairing PIN and paired sessions
+type PairingManager struct {
+	mu         sync.Mutex
+	pin        string
+	pinExpires time.Time
+	pinTimer   *time.Timer
+	failures   map[string]*pairingFailure // IP -> 配对失败记录
+	pinTTL     time.Duration
+	sessionTTL time.Duration
+	sessions   map[string]pairingSession // token -> session
+	onChange   func(pin string)          // 配对码变化时的回调
+	onRevoke   func()                    // 撤销配对时的回调
+}
+
+// NewPairingManager 创建配对管理器并生成第一个配对码
+// NewPairingManager creates a pairing manager and generates the first PIN
+func NewPairingManager(pinTTL, sessionTTL time.Duration) *PairingManager {
+	pm := &PairingManager{
+		pinTTL:     pinTTL,
+		sessionTTL: sessionTTL,
+		sessions:   make(map[string]pairingSession),
+		failures:   make(map[string]*pairingFailure),
+	}
+	pm.mu.Lock()
+	pm.rotateLocked()
+	pm.mu.Unlock()
+	return pm
+}
+
+// SetOnChange 设置配对码变化时的回调函数
+// SetOnChange sets the callback invoked when the PIN changes
+func (pm *PairingManager) SetOnChange(callback func(pin string)) {
+	pm.onChange = callback
+}
+
+// SetOnRevoke 设置撤销配对时的回调函数
+// SetOnRevoke sets the callback invoked when pairing is revoked
+func (pm *PairingManager) SetOnRevoke(callback func()) {
+	pm.onRevoke = callback
+}
+
+// CurrentPIN 返回当前配对码及其过期时间
+// CurrentPIN returns the current PIN and its expiry time
+func (pm *PairingManager) CurrentPIN() (string, time.Time) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.pin, pm.pinExpires
+}
+
+// SessionCount 返回有效会话数量
+// SessionCount returns the number of valid sessions
+func (pm *PairingManager) SessionCount() int {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.pruneLocked()
+	return len(pm.sessions)
+}
+
+// Pair 校验配对码，成功后消耗该配对码并返回新的会话令牌
+// Pair validates the PIN; on success it consumes the PIN and returns a new session token
+func (pm *PairingManager) Pair(pin, ip, deviceID string) (string, error) {
+	pm.mu.Lock()
+	pm.pruneLocked()
+
+	// 锁定期间不校验配对码，猜测无效 / The PIN is not checked while locked, so guesses are wasted
+	now := time.Now()
+	failure := pm.failures[ip]
+	if failure != nil && now.Before(failure.lockedUntil) {
+		pm.mu.Unlock()
+		LogFormat("拒绝", "配对", "手机端 --> 服务端", "IP %s 已被锁定，剩余 %v", ip, failure.lockedUntil.Sub(now).Round(time.Second))
+		return "", ErrPairingLocked
+	}
+
+	if pin == "" || now.After(pm.pinExpires) ||
+		subtle.ConstantTimeCompare([]byte(pin), []byte(pm.pin)) != 1 {
+		// 按 IP 计数，只锁定猜错的设备，不更换配对码，其他手机仍可配对
+		// Count per IP and lock only the guessing device; the PIN is kept so other phones can still pair
+		if failure == nil {
+			failure = &pairingFailure{}
+			pm.failures[ip] = failure
+		}
+		failure.count++
+		failure.lastFailure = now
+		LogFormat("拒绝", "配对", "手机端 --> 服务端", "配对码错误，IP: %s，失败次数: %d", ip, failure.count)
+		if failure.count >= maxPairingFailures {
+			failure.count = 0
+			failure.lockouts++
+			failure.lockedUntil = now.Add(lockoutDuration(failure.lockouts))
+			LogInfo("IP %s 配对失败次数过多，锁定 %v", ip, lockoutDuration(failure.lockouts))
+		}
+		pm.mu.Unlock()
+		return "", ErrInvalidPIN
+	}
+	delete(pm.failures, ip)
+
+	token, err := randomToken(pairingSessionByteLen)
+	if err != nil {
+		pm.mu.Unlock()
+		return "", err
+	}
+	pm.sessions[token] = pairingSession{
+		IP:        ip,
+		DeviceID:  deviceID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(pm.sessionTTL),
+	}
+
+	// 配对码一次性使用 / The PIN is single-use
+	newPIN := pm.rotateLocked()
+	pm.mu.Unlock()
+
+	LogInfo("手机端配对成功，IP: %s", ip)
+	pm.notifyChange(newPIN)
+	return token, nil
+}
+
+// Validate 判断会话令牌是否有效
+// Validate reports whether the session token is valid
+func (pm *PairingManager) Validate(token string) bool {
+	if token == "" {
+		return false
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	session, ok := pm.sessions[token]
+	if !ok {
+		return false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(pm.sessions, token)
+		return false
+	}
+	return true
+}
+
+// Revoke 撤销所有会话并更换配对码
+// Revoke revokes all sessions and rotates the PIN
+func (pm *PairingManager) Revoke() {
+	pm.mu.Lock()
+	count := len(pm.sessions)
+	pm.sessions = make(map[string]pairingSession)
+	newPIN := pm.rotateLocked()
+	pm.mu.Unlock()
+
+	LogInfo("已撤销 %d 个配对会话", count)
+	if pm.onRevoke != nil {
+		pm.onRevoke()
+	}
+	pm.notifyChange(newPIN)
+}
+
+// RevokeDevice 撤销指定设备的所有会话，配对码不变；key 为 deviceKey 的结果，
+// 是 IP 时（手机没有设备 ID Cookie）同时撤销从该 IP 配对的会话
+// RevokeDevice revokes every session of the device; the PIN is unchanged. key comes from deviceKey,
+// and when it is an IP (the phone has no device ID cookie) the sessions paired from that IP are revoked too
+func (pm *PairingManager) RevokeDevice(key string) {
+	pm.mu.Lock()
+	count := 0
+	for token, session := range pm.sessions {
+		if session.DeviceID == key || session.IP == key {
+			delete(pm.sessions, token)
+			count++
+		}
+	}
+	pm.mu.Unlock()
+
+	LogInfo("已撤销设备 %s 的 %d 个配对会话", key, count)
+}
+
+// IsPaired 判断请求是否携带有效的会话 Cookie
+// IsPaired reports whether the request carries a valid session cookie
+func (pm *PairingManager) IsPaired(r *http.Request) bool {
+	cookie, err := r.Cookie(PairingCookieName)
+	if err != nil {
+		return false
+	}
+	return pm.Validate(cookie.Value)
+}
+
+// IsTrusted 判断请求是否无需配对：本机同源请求或已配对会话
+// 注意：这里只使用 RemoteAddr，不信任 X-Forwarded-For，避免伪造本机地址绕过配对
+// IsTrusted reports whether the request needs no pairing: same-origin local requests or paired sessions
+// Note: only RemoteAddr is used; X-Forwarded-For is not trusted so it cannot be spoofed to bypass pairing
+func (pm *PairingManager) IsTrusted(r *http.Request) bool {
+	return isLoopbackRequest(r) || pm.IsPaired(r)
+}
+
+// PairRequest 使用请求中的 pin 参数完成配对并写入会话 Cookie，会话记录设备 ID 以便踢出时撤销
+// PairRequest pairs using the request's pin parameter and writes the session cookie;
+// the session records the device ID so a kick can revoke it
+func (pm *PairingManager) PairRequest(w http.ResponseWriter, r *http.Request) error {
+	token, err := pm.Pair(r.URL.Query().Get("pin"), getClientIP(r), EnsureDeviceID(w, r))
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     PairingCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(pm.sessionTTL / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// Require 返回一个中间件，拒绝未配对的远程请求
+// Require returns a middleware that rejects unpaired remote requests
+func (pm *PairingManager) Require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !pm.IsTrusted(r) {
+			LogFormat("拒绝", "HTTP", "服务端", "拒绝未配对的请求: %s，IP: %s", r.URL.Path, getClientIP(r))
+			http.Error(w, "未配对，请扫描电脑端二维码或输入配对码", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HandlePairingInfo 返回当前配对码（仅限本机访问）
+// HandlePairingInfo returns the current PIN (local access only)
+func (pm *PairingManager) HandlePairingInfo(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	pin, expires := pm.CurrentPIN()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pin":       pin,
+		"expiresAt": expires.UnixMilli(),
+		"sessions":  pm.SessionCount(),
+	})
+}
+
+// HandleRevoke 撤销所有配对（仅限本机访问）
+// HandleRevoke revokes all pairings (local access only)
+func (pm *PairingManager) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopbackRequest(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	pm.Revoke()
+	w.WriteHeader(http.StatusOK)
+}
+
+// rotateLocked 生成新的配对码并重置过期定时器（调用方需持有锁）
+// rotateLocked generates a new PIN and resets the expiry timer (caller must hold the lock)
+func (pm *PairingManager) rotateLocked() string {
+	pin, err := randomPIN(PairingPINLength)
+	if err != nil {
+		// 随机数生成失败时保留旧配对码 / Keep the old PIN if random generation fails
+		LogInfo("生成配对码失败: %v", err)
+		return pm.pin
+	}
+	pm.pin = pin
+	pm.pinExpires = time.Now().Add(pm.pinTTL)
+
+	if pm.pinTimer != nil {
+		pm.pinTimer.Stop()
+	}
+	pm.pinTimer = time.AfterFunc(pm.pinTTL, func() {
+		pm.mu.Lock()
+		newPIN := pm.rotateLocked()
+		pm.mu.Unlock()
+		LogFormat("处理", "配对", "服务端", "配对码已过期，已更换")
+		pm.notifyChange(newPIN)
+	})
+	return pin
+}
+
+// pruneLocked 清理过期会话，以及锁定已结束且超过 maxPairingLockout 没有再失败的记录（调用方需持有锁）
+// pruneLocked removes expired sessions and failure records whose lockout is over and that have not failed
+// again for maxPairingLockout (caller must hold the lock)
+func (pm *PairingManager) pruneLocked() {
+	now := time.Now()
+	for token, session := range pm.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(pm.sessions, token)
+		}
+	}
+	for ip, failure := range pm.failures {
+		if now.After(failure.lockedUntil) && now.Sub(failure.lastFailure) > maxPairingLockout {
+			delete(pm.failures, ip)
+		}
+	}
+}
+
+// notifyChange 触发配对码变化回调
+// notifyChange invokes the PIN change callback
+func (pm *PairingManager) notifyChange(pin string) {
+	if pm.onChange != nil {
+		pm.onChange(pin)
+	}
+}
+
+// IsLocalRequest 判断请求是否来自本机的同源页面或非浏览器客户端
+// IsLocalRequest reports whether the request comes from a same-origin page or a non-browser client on this machine
+func IsLocalRequest(r *http.Request) bool {
+	return isLoopbackRequest(r)
+}
+
+// isLoopbackRequest 根据 RemoteAddr 判断请求是否来自本机；本机浏览器中其他站点的页面发出的请求
+// （Origin 与 Host 不一致）不算，否则它们可以绕过配对读取配对码和卡片。
+// Host 也必须是本机地址：DNS 重绑定的页面（如 evil.example 解析到 127.0.0.1）Origin 与 Host 一致，但 Host 是外部域名
+// isLoopbackRequest reports whether the request comes from this machine, using RemoteAddr; requests from pages of
+// other sites in the local browser (Origin differs from Host) do not count, or they could skip pairing and read the PIN and cards.
+// Host must name this machine too: a DNS-rebinding page (evil.example resolved to 127.0.0.1) has a matching Origin, but a foreign Host
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback() && isLocalHost(r.Host) && sameOrigin(r)
+}
+
+// isLocalHost 判断 Host 头是否指向本机：localhost、回环地址或本机网卡的 IP
+// isLocalHost reports whether the Host header names this machine: localhost, a loopback address or an IP of a local interface
+func isLocalHost(hostport string) bool {
+	host := stripPort(hostport)
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	addr, _, _ := strings.Cut(host, "%")
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomPIN 生成指定位数的数字配对码
+// randomPIN generates a numeric PIN with the given number of digits
+func randomPIN(digits int) (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < digits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// randomToken 生成十六进制随机令牌
+// randomToken generates a random hex token
+func randomToken(byteLen int) (string, error) {
+	buf := make([]byte, byteLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,183 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsTrusted(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	pin, _ := pm.CurrentPIN()
+	token, err := pm.Pair(pin, "192.168.1.20", "")
+	if err != nil {
+		t.Fatalf("Pair: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		host   string
+		origin string
+		cookie string
+		want   bool
+	}{
+		{"loopback without origin", "127.0.0.1:40000", "127.0.0.1:5000", "", "", true},
+		{"loopback same origin", "127.0.0.1:40000", "127.0.0.1:5000", "http://127.0.0.1:5000", "", true},
+		{"localhost", "127.0.0.1:40000", "localhost:5000", "http://localhost:5000", "", true},
+		{"ipv6 loopback", "[::1]:40000", "[::1]:5000", "", "", true},
+		{"loopback page from another site", "127.0.0.1:40000", "127.0.0.1:5000", "https://evil.example", "", false},
+		{"dns rebinding", "127.0.0.1:40000", "evil.example:5000", "http://evil.example:5000", "", false},
+		{"remote unpaired", "192.168.1.20:40000", "192.168.1.2:5000", "", "", false},
+		{"remote paired", "192.168.1.20:40000", "192.168.1.2:5000", "http://192.168.1.2:5000", token, true},
+		{"remote bad token", "192.168.1.20:40000", "192.168.1.2:5000", "", "bogus", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws?type=pc", nil)
+			r.Host = tt.host
+			r.RemoteAddr = tt.remote
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: PairingCookieName, Value: tt.cookie})
+			}
+			if got := pm.IsTrusted(r); got != tt.want {
+				t.Errorf("IsTrusted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePairingInfoRejectsCrossOrigin(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	r := httptest.NewRequest(http.MethodGet, "/api/pairing", nil)
+	r.Host = "127.0.0.1:5000"
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("Origin", "https://evil.example")
+
+	w := httptest.NewRecorder()
+	pm.HandlePairingInfo(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
+
+func TestIsLocalHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost:5000", true},
+		{"LOCALHOST", true},
+		{"127.0.0.1:5000", true},
+		{"[::1]:5000", true},
+		{"evil.example:5000", false},
+		{"203.0.113.5:5000", false},
+		{"", false},
+	}
+	// 本机网卡的地址同样算本机 / Addresses of local interfaces count as this machine too
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+				tests = append(tests, struct {
+					host string
+					want bool
+				}{net.JoinHostPort(ipNet.IP.String(), "5000"), true})
+				break
+			}
+		}
+	}
+	for _, tt := range tests {
+		if got := isLocalHost(tt.host); got != tt.want {
+			t.Errorf("isLocalHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestPairLocksOutOnlyTheGuessingIP(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	pin, _ := pm.CurrentPIN()
+	wrong := "000000"
+	if pin == wrong {
+		wrong = "111111"
+	}
+
+	const attacker = "192.168.1.66"
+	for i := 0; i < maxPairingFailures; i++ {
+		if _, err := pm.Pair(wrong, attacker, ""); err != ErrInvalidPIN {
+			t.Fatalf("guess %d: error = %v, want %v", i+1, err, ErrInvalidPIN)
+		}
+	}
+	// 锁定期间即使配对码正确也被拒绝，配对码不变 / While locked even the right PIN is refused, and the PIN is kept
+	if _, err := pm.Pair(pin, attacker, ""); err != ErrPairingLocked {
+		t.Fatalf("locked IP: error = %v, want %v", err, ErrPairingLocked)
+	}
+	if current, _ := pm.CurrentPIN(); current != pin {
+		t.Fatal("failed guesses rotated the PIN")
+	}
+
+	// 其他手机仍可用当前配对码配对 / Other phones can still pair with the current PIN
+	if _, err := pm.Pair(pin, "192.168.1.20", ""); err != nil {
+		t.Fatalf("legitimate phone: %v", err)
+	}
+
+	// 锁定结束后恢复，再次猜错会锁定更久 / After the lockout the IP may try again; failing again locks it longer
+	pm.mu.Lock()
+	pm.failures[attacker].lockedUntil = time.Now().Add(-time.Second)
+	pm.mu.Unlock()
+	pin, _ = pm.CurrentPIN()
+	for i := 0; i < maxPairingFailures; i++ {
+		pm.Pair(wrong, attacker, "")
+	}
+	pm.mu.Lock()
+	remaining := time.Until(pm.failures[attacker].lockedUntil)
+	pm.mu.Unlock()
+	if remaining <= pairingLockout {
+		t.Errorf("second lockout lasts %v, want more than %v", remaining, pairingLockout)
+	}
+}
+
+func TestPairSuccessClearsFailures(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	pin, _ := pm.CurrentPIN()
+	wrong := "000000"
+	if pin == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < maxPairingFailures-1; i++ {
+		pm.Pair(wrong, "192.168.1.20", "")
+	}
+	if _, err := pm.Pair(pin, "192.168.1.20", ""); err != nil {
+		t.Fatalf("Pair: %v", err)
+	}
+	pm.mu.Lock()
+	_, ok := pm.failures["192.168.1.20"]
+	pm.mu.Unlock()
+	if ok {
+		t.Error("a successful pairing kept the failure record")
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, pairingLockout},
+		{2, 2 * pairingLockout},
+		{3, 4 * pairingLockout},
+		{10, maxPairingLockout},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.n); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,29 +1,155 @@
-// Package network 提供二维码生成相关功能（简化实现）
-// Package network provides QR code generation functionality (simplified implementation)
+// Package network 提供二维码生成相关功能：终端显示和 PNG/SVG 图片接口
+// Package network provides QR code generation: terminal display and the PNG/SVG image endpoints
 package network
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"airinputlan/internal/qrcode"
+)
+
+const (
+	DefaultQRScale = 8             // PNG 每个模块的默认像素数 / Default PNG pixels per module
+	MaxQRScale     = 32            // PNG 每个模块的最大像素数 / Maximum PNG pixels per module
+	QRLevel        = qrcode.Medium // 纠错等级 / Error correction level
 )
 
-// GenerateQRCodeText 生成二维码的文本描述（占位实现）
-// GenerateQRCodeText generates QR code text description (placeholder implementation)
+// GenerateQRCodeText 生成可在终端显示的二维码（Unicode 半块字符）
+// GenerateQRCodeText generates a QR code for terminal display (Unicode half blocks)
 func GenerateQRCodeText(url string) string {
-	// 简化版：返回 URL 和说明
-	// 实际项目中应使用 github.com/skip2/go-qrcode 生成真实二维码
-	return fmt.Sprintf("QR Code: %s\n请扫描或手动输入此地址", url)
+	code, err := qrcode.Encode(url, QRLevel)
+	if err != nil {
+		return fmt.Sprintf("二维码生成失败: %v", err)
+	}
+	return code.Terminal()
+}
+
+// ConnectInfo 描述手机端访问地址的组成部分
+// ConnectInfo describes the components of the mobile access address
+type ConnectInfo struct {
+	Scheme      string // 协议，默认 http / Protocol, defaults to http
+	IP          string // 访问 IP / Access IP
+	Hostname    string // mDNS 主机名（可选，如 airinput.local），设置后代替 IP / mDNS host name (optional, e.g. airinput.local), replaces the IP when set
+	Port        int    // 服务端口 / Service port
+	PIN         string // 配对码（可选） / Pairing PIN (optional)
+	Fingerprint string // HTTPS 证书指纹（可选） / HTTPS certificate fingerprint (optional)
 }
 
-// GenerateQRCodeData 生成二维码相关数据，包括 URL、IP、端口等信息
-// GenerateQRCodeData generates QR code related data, including URL, IP, port, etc.
-func GenerateQRCodeData(ip string, port int) map[string]interface{} {
-	// 手机端访问地址：http://IP:端口（不需要 /mobile 路径） / Mobile access address: http://IP:port (no /mobile path needed)
-	url := fmt.Sprintf("http://%s:%d", ip, port)
+// URL 返回手机端访问地址：http://IP:端口（不需要 /mobile 路径），带配对码时附加 ?pin=
+// IPv6 地址使用方括号形式（如 http://[fe80::1]:5000/）；链路本地地址的 Zone 是电脑的网卡名称，对手机无意义，不写入 URL；
+// 设置了 Hostname 时使用主机名
+// URL returns the mobile access address: http://IP:port (no /mobile path needed), with ?pin= when a PIN is set
+// IPv6 addresses are bracketed (e.g. http://[fe80::1]:5000/); a link-local zone names the PC's interface and means nothing
+// to the phone, so it is left out; Hostname is used when set
+func (ci ConnectInfo) URL() string {
+	scheme := ci.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := ci.IP
+	if ci.Hostname != "" {
+		host = ci.Hostname
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(ci.Port)),
+		Path:   "/",
+	}
+	if ci.PIN != "" {
+		u.RawQuery = url.Values{"pin": {ci.PIN}}.Encode()
+	}
+	return u.String()
+}
+
+// GenerateQRCodeData 生成二维码相关数据，包括 URL、IP、端口、配对码等信息
+// GenerateQRCodeData generates QR code related data, including URL, IP, port, PIN, etc.
+func GenerateQRCodeData(info ConnectInfo) map[string]interface{} {
+	url := info.URL()
+	text := GenerateQRCodeText(url)
+	if info.Fingerprint != "" {
+		text += fmt.Sprintf("证书指纹 (SHA-256): %s\n", info.Fingerprint)
+	}
 	return map[string]interface{}{
-		"url":     url,
-		"ip":      ip,
-		"port":    port,
-		"text":    GenerateQRCodeText(url),
-		"message": "请扫描二维码或手动输入地址 / Scan QR code or enter address manually",
+		"url":         url,
+		"ip":          info.IP,
+		"hostname":    info.Hostname,
+		"port":        info.Port,
+		"pin":         info.PIN,
+		"fingerprint": info.Fingerprint,
+		"text":        text,
+		"message":     "请扫描二维码或手动输入地址 / Scan QR code or enter address manually",
+	}
+}
+
+// HandleQRCode 返回一个处理函数，输出手机端访问地址的二维码图片（format 为 "png" 或 "svg"）
+// 查询参数：ip 指定地址（默认为 base 返回的当前地址，IPv6 可带 Zone，但不写入二维码）；host 指定 .local 主机名（优先于 ip）；
+// scale 指定 PNG 模块像素数；pin=0 不携带配对码
+// 配对码只对本机请求写入二维码，避免已配对的手机端读取配对码
+// HandleQRCode returns a handler that serves the mobile access address as a QR image (format is "png" or "svg")
+// Query parameters: ip selects the address (defaults to the current address returned by base; IPv6 may carry a zone,
+// which is left out of the QR code);
+// host selects a .local host name (wins over ip); scale sets PNG pixels per module; pin=0 omits the PIN
+// The PIN is only embedded for local requests, so paired phones cannot read it
+func HandleQRCode(format string, base func() ConnectInfo, pm *PairingManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := r.URL.Query()
+
+		info := base()
+		if ip := query.Get("ip"); ip != "" {
+			host, zone, _ := strings.Cut(ip, "%")
+			parsed := net.ParseIP(host)
+			if parsed == nil || (zone != "" && parsed.To4() != nil) {
+				http.Error(w, "无效的 IP 地址", http.StatusBadRequest)
+				return
+			}
+			info.IP, info.Hostname = host, ""
+		}
+		if host := query.Get("host"); host != "" {
+			if !strings.HasSuffix(host, ".local") || strings.ContainsAny(host, "/:@?#%[] ") {
+				http.Error(w, "无效的主机名", http.StatusBadRequest)
+				return
+			}
+			info.Hostname = host
+		}
+		if pm != nil && isLoopbackRequest(r) && query.Get("pin") != "0" {
+			info.PIN, _ = pm.CurrentPIN()
+		}
+
+		code, err := qrcode.Encode(info.URL(), QRLevel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		switch format {
+		case "png":
+			scale := DefaultQRScale
+			if s := query.Get("scale"); s != "" {
+				scale, err = strconv.Atoi(s)
+				if err != nil || scale < 1 || scale > MaxQRScale {
+					http.Error(w, fmt.Sprintf("scale 必须为 1-%d", MaxQRScale), http.StatusBadRequest)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", "image/png")
+			if err := code.WritePNG(w, scale); err != nil {
+				LogFormat("错误", "HTTP", "服务端", "输出二维码失败: %v", err)
+			}
+		case "svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			fmt.Fprint(w, code.SVG())
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
 	}
 }
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectInfoURL(t *testing.T) {
+	tests := []struct {
+		name string
+		info ConnectInfo
+		want string
+	}{
+		{"ipv4", ConnectInfo{IP: "192.168.1.10", Port: 5000}, "http://192.168.1.10:5000/"},
+		{"pin and scheme", ConnectInfo{Scheme: "https", IP: "192.168.1.10", Port: 5443, PIN: "123456"}, "https://192.168.1.10:5443/?pin=123456"},
+		{"ipv6", ConnectInfo{IP: "2001:db8::1", Port: 5000}, "http://[2001:db8::1]:5000/"},
+		{"link-local ipv6", ConnectInfo{IP: "fe80::1", Port: 5000}, "http://[fe80::1]:5000/"},
+		{"hostname wins", ConnectInfo{IP: "192.168.1.10", Hostname: "airinput.local", Port: 5000}, "http://airinput.local:5000/"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.URL(); got != tt.want {
+			t.Errorf("%s: URL() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandleQRCodeValidatesIP(t *testing.T) {
+	base := func() ConnectInfo { return ConnectInfo{IP: "192.168.1.10", Port: 5000} }
+	handler := HandleQRCode("svg", base, nil)
+
+	tests := []struct {
+		ip   string
+		code int
+	}{
+		{"fe80::1%25eth0", http.StatusOK},
+		{"fe80::1", http.StatusOK},
+		{"192.168.1.10%25eth0", http.StatusBadRequest},
+		{"not-an-ip", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/qrcode.svg?ip="+tt.ip, nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != tt.code {
+			t.Errorf("ip=%s: status %d, want %d", tt.ip, w.Code, tt.code)
+		}
+	}
+}
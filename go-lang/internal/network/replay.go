@@ -0,0 +1,107 @@
+// Package network 提供断线重放：广播消息带有递增 ID 并保留在环形缓冲区中，重连的客户端凭 Last-Event-ID 补收错过的消息
+// Package network provides reconnect replay: broadcast messages carry increasing IDs and stay in a ring buffer,
+// so a reconnecting client catches up on what it missed from its Last-Event-ID
+package network
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// replayBufferSize 保留的最近消息数，小于客户端发送队列容量，重放不会挤满队列
+// replayBufferSize is how many recent messages are kept; smaller than the client send queue so a replay cannot fill it
+const replayBufferSize = 128
+
+// replayLog 最近广播消息的环形缓冲区，只在 Run 协程中访问
+// replayLog is a ring buffer of recent broadcast messages; only the Run goroutine touches it
+type replayLog struct {
+	entries []envelope
+	start   int    // 最早消息的下标 / Index of the oldest message
+	count   int    // 已保存的消息数 / Number of stored messages
+	lastID  uint64 // 最近分配的 ID / Most recently assigned ID
+}
+
+// newReplayLog 创建环形缓冲区；ID 从启动时间（微秒）开始，服务重启后旧页面带来的 ID 不会被误认为在缓冲区内
+// newReplayLog creates the ring buffer; IDs start at the startup time in microseconds so IDs from before a restart never look buffered
+func newReplayLog(size int) *replayLog {
+	return &replayLog{
+		entries: make([]envelope, size),
+		lastID:  uint64(time.Now().UnixMicro()),
+	}
+}
+
+// record 为消息分配下一个 ID 并保存，缓冲区满时覆盖最早的消息
+// record assigns the next ID to the message and stores it, overwriting the oldest message when full
+func (l *replayLog) record(env envelope) envelope {
+	l.lastID++
+	env.message.ID = l.lastID
+	l.entries[(l.start+l.count)%len(l.entries)] = env
+	if l.count < len(l.entries) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.entries)
+	}
+	return env
+}
+
+// since 返回 ID 之后的所有消息；错过的消息已被覆盖或 ID 不属于本次运行时返回 false
+// since returns every message after the ID; false when some were overwritten or the ID is not from this run
+func (l *replayLog) since(id uint64) ([]envelope, bool) {
+	if id > l.lastID {
+		return nil, false
+	}
+	oldest := l.lastID - uint64(l.count) + 1
+	if id+1 < oldest {
+		return nil, false
+	}
+	missed := make([]envelope, 0, l.lastID-id)
+	for i := int(id + 1 - oldest); i < l.count; i++ {
+		missed = append(missed, l.entries[(l.start+i)%len(l.entries)])
+	}
+	return missed, true
+}
+
+// lastEventID 返回重连请求携带的最后收到的消息 ID：EventSource 自动重连时使用 Last-Event-ID 请求头，
+// 页面重建连接（包括 WebSocket）时使用查询参数 lastEventId
+// lastEventID returns the last message ID a reconnecting request received: the Last-Event-ID header on EventSource's
+// own reconnects, or the lastEventId query parameter when the page opens a new connection (including WebSocket)
+func lastEventID(r *http.Request) (uint64, bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// resumeLocked 向重连的客户端重放它错过的消息；缺口超出缓冲区时不重放，由注册后的完整状态补齐（调用方需持有写锁，在 Run 协程中调用）
+// resumeLocked replays the messages a reconnecting client missed; when the gap exceeds the buffer nothing is replayed
+// and the snapshot sent after registration catches it up (the caller holds the write lock on the Run goroutine)
+func (s *SSEServer) resumeLocked(client *SSEClient) {
+	if !client.resuming {
+		return
+	}
+	missed, ok := s.replay.since(client.lastEventID)
+	if !ok {
+		LogFormat("重连", "SSE", "服务端", "连接 %s 错过的消息超出缓冲区（Last-Event-ID: %d），发送完整状态", client.ID, client.lastEventID)
+		return
+	}
+	replayed := 0
+	for _, env := range missed {
+		if env.to != nil && !env.to(client) {
+			continue
+		}
+		if client.Enqueue(env.message) {
+			replayed++
+		}
+	}
+	client.resumed = true
+	LogFormat("重连", "SSE", "服务端 --> 客户端", "连接 %s 从 Last-Event-ID %d 恢复，重放 %d 条消息", client.ID, client.lastEventID, replayed)
+}
@@ -0,0 +1,57 @@
+// Package network 提供电脑端的完整状态快照：页面打开或刷新后立即收到历史卡片、当前输入、输入模式、二维码状态和已连接的手机
+// Package network provides the PC state snapshot: right after the page opens or reloads it receives the history cards,
+// live input, input mode, QR visibility and connected phones
+package network
+
+import (
+	"encoding/json"
+
+	"airinputlan/internal/card"
+)
+
+// SnapshotInput 表示一台设备尚未分段的当前输入
+// SnapshotInput is a device's current input that has not been segmented yet
+type SnapshotInput struct {
+	Key        string `json:"key"`        // 输入来源标识 / Input source key
+	DeviceID   string `json:"deviceId"`   // 手机端设备 ID / Mobile device ID
+	DeviceName string `json:"deviceName"` // 手机端设备名称 / Mobile device name
+	Text       string `json:"text"`       // 当前输入 / Current input
+}
+
+// Snapshot 表示电脑端连接时收到的完整状态（snapshot 消息的 Payload）
+// Snapshot is the full state a PC receives when it connects (the Payload of the snapshot message)
+type Snapshot struct {
+	Cards   []card.Card     `json:"cards"`   // 历史卡片 / History cards
+	Inputs  []SnapshotInput `json:"inputs"`  // 各设备的当前输入 / Current input of each device
+	Mode    string          `json:"mode"`    // 输入模式：single 或 continuous / Input mode: single or continuous
+	ShowQR  bool            `json:"showQR"`  // 是否显示二维码 / Whether the QR code is shown
+	Devices []DeviceInfo    `json:"devices"` // 已连接的手机端 / Connected phones
+}
+
+// SetSnapshotProvider 设置提供卡片、输入和模式的回调（在持有服务锁时调用，回调中不可再调用 SSEServer 的加锁方法）
+// SetSnapshotProvider sets the callback supplying cards, inputs and mode (called with the server lock held; the callback must not call locking SSEServer methods)
+func (s *SSEServer) SetSnapshotProvider(provider func() Snapshot) {
+	s.snapshotProvider = provider
+}
+
+// sendSnapshotLocked 向刚注册的电脑端发送完整状态；已通过重放补齐的重连不再发送（调用方需持有写锁，在 Run 协程中调用）
+// sendSnapshotLocked sends the full state to a newly registered PC; reconnects already caught up through replay are skipped
+// (the caller holds the write lock on the Run goroutine)
+func (s *SSEServer) sendSnapshotLocked(client *SSEClient) {
+	if client.Role != RolePC || client.resumed || s.snapshotProvider == nil {
+		return
+	}
+	snapshot := s.snapshotProvider()
+	snapshot.ShowQR = !s.mobileFullLocked("")
+	snapshot.Devices = s.devicesLocked()
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		LogInfo("生成完整状态失败: %v", err)
+		return
+	}
+	// 带上最近的消息 ID：快照已包含此前的所有广播，之后断线时从这里重放
+	// Carry the latest message ID: the snapshot covers every earlier broadcast, so a later reconnect replays from here
+	if client.Enqueue(Message{Type: TypeSnapshot, Payload: payload, ID: s.replay.lastID}) {
+		LogFormat("发送", "SSE", "服务端 --> PC端", "发送完整状态：%d 个输入，%d 台手机，模式 %s", len(snapshot.Inputs), len(snapshot.Devices), snapshot.Mode)
+	}
+}
@@ -4,51 +4,95 @@ package network
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 // 消息类型常量 / Message type constants
 const (
-	TypeText       = "text"        // 实时输入内容 / Real-time input content
-	TypeSegment    = "segment"     // 分段信号（旧逻辑） / Segmentation signal (old logic)
-	TypeCard       = "card"        // 卡片内容（新逻辑） / Card content (new logic)
-	TypeClearInput = "clear_input" // 清空实时输入框 / Clear real-time input box
-	TypeHeartbeat  = "heartbeat"   // 心跳 / Heartbeat
-	TypeShowQR     = "show_qr"     // 显示/隐藏二维码 / Show/hide QR code
-	TypeConnected  = "connected"   // 连接成功 / Connection success
+	TypeText          = "text"           // 实时输入内容 / Real-time input content
+	TypeSegment       = "segment"        // 分段信号（旧逻辑） / Segmentation signal (old logic)
+	TypeCard          = "card"           // 卡片内容（新逻辑） / Card content (new logic)
+	TypeClearInput    = "clear_input"    // 清空实时输入框 / Clear real-time input box
+	TypeHeartbeat     = "heartbeat"      // 心跳 / Heartbeat
+	TypeShowQR        = "show_qr"        // 显示/隐藏二维码 / Show/hide QR code
+	TypeConnected     = "connected"      // 连接成功 / Connection success
+	TypePairing       = "pairing"        // 配对码已更换 / Pairing PIN changed
+	TypeUnpaired      = "unpaired"       // 配对已被撤销 / Pairing revoked
+	TypeSnapshot      = "snapshot"       // 电脑端连接时的完整状态（Payload 为 Snapshot） / Full state for a connecting PC (Payload is a Snapshot)
+	TypeCardCreated   = "card_created"   // 卡片已创建（REST API） / Card created (REST API)
+	TypeCardUpdated   = "card_updated"   // 卡片已修改 / Card updated
+	TypeCardDeleted   = "card_deleted"   // 卡片已删除（Data 为卡片 ID） / Card deleted (Data is the card ID)
+	TypeConfigReload  = "config_reload"  // 配置文件已重新加载 / Config file reloaded
+	TypeActiveIP      = "active_ip"      // 当前访问地址已切换（Payload 为地址信息） / Active address switched (Payload is the address info)
+	TypeNetworkChange = "network_change" // 网卡地址变化（Payload 含地址列表、当前地址和 URL） / Interface addresses changed (Payload has the list, active address and URL)
+	TypeDevices       = "devices"        // 已连接的手机端列表变化（Payload 为设备数组） / Connected mobile devices changed (Payload is a device array)
+	TypeSessionEnd    = "session_end"    // 手机端会话已结束（Data 为原因：replaced 或 kicked） / Mobile session ended (Data is the reason: replaced or kicked)
 )
 
+// disconnectGracePeriod 主动断开前等待通知送达的时间
+// disconnectGracePeriod is how long to wait for the notice to be delivered before disconnecting
+const disconnectGracePeriod = 500 * time.Millisecond
+
 // Message 表示 SSE 推送的消息结构
 // Message represents an SSE push message structure
 type Message struct {
-	Type string `json:"type"` // "text", "heartbeat", "segment", "card", "clear_input", "show_qr", "mode_query"
-	Data string `json:"data"`
+	Type    string          `json:"type"` // "text", "heartbeat", "segment", "card", "clear_input", "show_qr", "mode_query"
+	Data    string          `json:"data"`
+	Payload json.RawMessage `json:"payload,omitempty"` // 结构化数据（如卡片 JSON） / Structured data (e.g. card JSON)
+	ID      uint64          `json:"id,omitempty"`      // 广播消息的递增 ID，用于断线重放 / Increasing ID of broadcast messages, used for reconnect replay
+}
+
+// MessageSource 表示上行消息的来源客户端
+// MessageSource identifies the client an upstream message came from
+type MessageSource struct {
+	ClientID   string
+	IP         string
+	DeviceID   string // 手机端设备 ID（来自 Cookie，可能为空） / Mobile device ID (from the cookie, may be empty)
+	DeviceName string // 手机端设备名称 / Mobile device name
 }
 
 // SSEClient 表示一个 SSE 客户端连接
 // SSEClient represents an SSE client connection
 type SSEClient struct {
-	ID        string
-	IP        string // 客户端 IP 地址
-	Send      chan Message
-	Close     chan struct{}
-	mu        sync.RWMutex
-	isClosed  bool
+	ID          string
+	IP          string       // 客户端 IP 地址
+	DeviceID    string       // 手机端设备 ID（来自 Cookie，可能为空）
+	DeviceName  string       // 手机端设备名称
+	ConnectedAt time.Time    // 连接时间
+	Role        ClientRole   // 客户端角色（来自查询参数 type）
+	state       SessionState // 会话状态，见 takeover.go
+	Send        chan Message
+	Close       chan struct{}
+	mu          sync.RWMutex
+	isClosed    bool
+
+	// 断线重放状态，见 replay.go / Reconnect replay state, see replay.go
+	lastEventID uint64 // 重连前最后收到的消息 ID
+	resuming    bool   // 请求是否携带了 Last-Event-ID
+	resumed     bool   // 已重放错过的消息，不再需要完整状态
 }
 
 // SSEServer 表示 SSE 服务实例
 // SSEServer represents an SSE service instance
 type SSEServer struct {
-	Clients                      map[string]*SSEClient
-	mu                           sync.RWMutex
-	register                     chan *SSEClient
-	unregister                   chan *SSEClient
-	broadcast                    chan Message
-	onMessage                    func(string)                    // 接收消息的回调
-	onPCClientsCountChange       func(int)                       // PC 端数量变化时的回调
+	Clients                map[string]*SSEClient
+	mu                     sync.RWMutex
+	register               chan *SSEClient
+	unregister             chan *SSEClient
+	broadcast              chan envelope
+	onMessage              func(string, MessageSource) // 接收消息的回调
+	onPCClientsCountChange func(int)                   // PC 端数量变化时的回调
+	onKick                 func(deviceKey string)      // 断开手机端设备时的回调，见 takeover.go
+	snapshotProvider       func() Snapshot             // 提供完整状态中的卡片、输入和模式，见 snapshot.go
+	maxMobileDevices       int                         // 同时连接的手机端设备上限，0 表示不限制
+	replay                 *replayLog                  // 最近广播消息，用于断线重放
 }
 
 // NewSSEServer 创建 SSE 服务
@@ -57,13 +101,14 @@ func NewSSEServer() *SSEServer {
 		Clients:    make(map[string]*SSEClient),
 		register:   make(chan *SSEClient),
 		unregister: make(chan *SSEClient),
-		broadcast:  make(chan Message, 256),
+		broadcast:  make(chan envelope, 256),
+		replay:     newReplayLog(replayBufferSize),
 	}
 }
 
 // SetOnMessage 设置接收消息时的回调函数
 // SetOnMessage sets the callback function for receiving messages
-func (s *SSEServer) SetOnMessage(callback func(string)) {
+func (s *SSEServer) SetOnMessage(callback func(string, MessageSource)) {
 	s.onMessage = callback
 }
 
@@ -73,6 +118,55 @@ func (s *SSEServer) SetOnPCClientsCountChange(callback func(int)) {
 	s.onPCClientsCountChange = callback
 }
 
+// SetOnKick 设置电脑端断开手机端设备时的回调函数（每个设备调用一次）
+// SetOnKick sets the callback invoked when the PC kicks a mobile device (once per device)
+func (s *SSEServer) SetOnKick(callback func(deviceKey string)) {
+	s.onKick = callback
+}
+
+// SetMaxMobileDevices 设置同时连接的手机端设备上限（0 表示不限制），只影响之后的新连接
+// SetMaxMobileDevices sets the cap on concurrently connected mobile devices (0 for no limit); only new connections are affected
+func (s *SSEServer) SetMaxMobileDevices(max int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxMobileDevices = max
+}
+
+// DeviceKey 返回区分手机端设备的标识，见 deviceKey
+// DeviceKey returns the key that tells mobile devices apart, see deviceKey
+func (c *SSEClient) DeviceKey() string {
+	return deviceKey(c.DeviceID, c.IP)
+}
+
+// source 返回该客户端发送消息时的来源信息
+// source returns the source info for messages sent by this client
+func (c *SSEClient) source() MessageSource {
+	return MessageSource{ClientID: c.ID, IP: c.IP, DeviceID: c.DeviceID, DeviceName: c.DeviceName}
+}
+
+// IsLocal 判断客户端是否为本机（电脑端）连接
+// IsLocal reports whether the client is a local (PC) connection
+func (c *SSEClient) IsLocal() bool {
+	return isLocalIP(c.IP)
+}
+
+// Enqueue 向单个客户端发送消息，客户端已关闭或缓冲区已满时返回 false
+// Enqueue sends a message to a single client; returns false if the client is closed or its buffer is full
+func (c *SSEClient) Enqueue(message Message) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.isClosed {
+		return false
+	}
+	select {
+	case c.Send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
 // RLock 获取读锁
 func (s *SSEServer) RLock() {
 	s.mu.RLock()
@@ -94,8 +188,8 @@ func (s *SSEServer) Run() {
 		case client := <-s.unregister:
 			s.unregisterClient(client)
 
-		case message := <-s.broadcast:
-			s.broadcastMessage(message)
+		case env := <-s.broadcast:
+			s.broadcastMessage(env)
 		}
 	}
 }
@@ -130,8 +224,12 @@ func (s *SSEServer) registerClient(client *SSEClient) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 注册客户端 / Register client
+	// 注册客户端，并接管同一设备残留的旧连接 / Register client and take over stale connections from the same device
 	s.Clients[client.ID] = client
+	s.takeoverLocked(client)
+
+	// 重连的客户端先补收错过的消息，之后的广播按顺序继续 / A reconnecting client first catches up on missed messages; later broadcasts follow in order
+	s.resumeLocked(client)
 
 	// 判断连接类型 / Determine connection type
 	connType := "Unknown device"
@@ -143,29 +241,22 @@ func (s *SSEServer) registerClient(client *SSEClient) {
 
 	LogFormat("连接", "SSE", connType+" --> 服务端", "客户端已连接，当前连接数: %d", len(s.Clients))
 
-	// 如果远程设备（手机端）连接，发送信号隐藏二维码 / If remote device (mobile) connects, send signal to hide QR code
+	// 手机端连接时通知设备列表变化，PC 端连接时同步当前设备列表 / Announce the device list when a phone connects; sync it to a connecting PC
 	if !isLocalIP(client.IP) {
-		s.broadcast <- Message{
-			Type: "show_qr",
-			Data: "false",
-		}
-	} else {
-		// 如果是 PC 端连接，检查是否已有远程设备，如果有则隐藏二维码
-		// If PC connects, check if there are already remote devices, if so hide QR code
-		hasRemoteClient := false
-		for _, c := range s.Clients {
-			if !isLocalIP(c.IP) {
-				hasRemoteClient = true
-				break
-			}
-		}
-		if hasRemoteClient {
-			LogFormat("连接管理", "SSE", "服务端 --> PC端", "检测到已有远程设备连接，隐藏二维码")
-			s.broadcast <- Message{
-				Type: "show_qr",
-				Data: "false",
-			}
+		LogFormat("连接", "SSE", "手机端 --> 服务端", "设备: %s（%s）", client.DeviceName, client.DeviceKey())
+		s.broadcastDevicesLocked()
+	} else if len(s.devicesLocked()) > 0 {
+		s.broadcastDevicesLocked()
+	}
+	// 手机端数量达到上限时隐藏二维码（PC 端连接时同样检查） / Hide the QR code once mobile devices reach the cap (also checked when a PC connects)
+	if s.mobileFullLocked("") {
+		if isLocalIP(client.IP) {
+			LogFormat("连接管理", "SSE", "服务端 --> PC端", "手机端数量已达上限，隐藏二维码")
 		}
+		s.deliver(Message{
+			Type: TypeShowQR,
+			Data: "false",
+		}, isPC)
 	}
 
 	// 统计 PC 端数量并触发回调 / Count PC clients and trigger callback
@@ -178,6 +269,9 @@ func (s *SSEServer) registerClient(client *SSEClient) {
 		}
 		s.onPCClientsCountChange(pcCount)
 	}
+
+	// 电脑端连接后发送完整状态 / Send the full state to a connecting PC
+	s.sendSnapshotLocked(client)
 }
 
 // unregisterClient 从服务中移除指定的客户端连接
@@ -185,6 +279,9 @@ func (s *SSEServer) unregisterClient(client *SSEClient) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// 被接管或踢出的连接已移出列表，这里只记录状态 / Replaced or kicked connections were already removed; only the state is recorded here
+	client.transition(SessionClosed)
+
 	if _, ok := s.Clients[client.ID]; ok {
 		delete(s.Clients, client.ID)
 
@@ -205,21 +302,14 @@ func (s *SSEServer) unregisterClient(client *SSEClient) {
 		client.mu.Unlock()
 		LogFormat("断开", "SSE", connType+" --> 服务端", "客户端已断开，当前连接数: %d", len(s.Clients))
 
-		// 如果远程设备（手机端）断开，检查是否还有其他远程设备 / If remote device (mobile) disconnects, check for other remote devices
+		// 手机端断开后低于上限时重新显示二维码，便于其他手机连接 / Show the QR code again once below the cap so other phones can join
 		if isRemote {
-			hasRemoteClient := false
-			for _, c := range s.Clients {
-				if !isLocalIP(c.IP) {
-					hasRemoteClient = true
-					break
-				}
-			}
-			// 如果没有其他远程设备，发送信号显示二维码 / If no other remote devices, send signal to show QR code
-			if !hasRemoteClient {
-				s.broadcast <- Message{
-					Type: "show_qr",
+			s.broadcastDevicesLocked()
+			if !s.mobileFullLocked("") {
+				s.deliver(Message{
+					Type: TypeShowQR,
 					Data: "true",
-				}
+				}, isPC)
 			}
 		}
 
@@ -236,14 +326,20 @@ func (s *SSEServer) unregisterClient(client *SSEClient) {
 	}
 }
 
-// broadcastMessage 将消息发送给所有已连接的客户端
-func (s *SSEServer) broadcastMessage(message Message) {
+// broadcastMessage 为消息分配 ID 并记录到重放缓冲区，然后发送给所有匹配的已连接客户端
+// broadcastMessage assigns the message an ID and records it for replay, then sends it to every matching connected client
+func (s *SSEServer) broadcastMessage(env envelope) {
+	env = s.replay.record(env)
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, client := range s.Clients {
+		if env.to != nil && !env.to(client) {
+			continue
+		}
 		select {
-		case client.Send <- message:
+		case client.Send <- env.message:
 		default:
 			// 发送失败，关闭连接 / Send failed, close connection
 			client.Close <- struct{}{}
@@ -254,7 +350,36 @@ func (s *SSEServer) broadcastMessage(message Message) {
 // Broadcast 将消息发送给所有已连接的客户端
 // Broadcast sends the message to all connected clients
 func (s *SSEServer) Broadcast(message Message) {
-	s.broadcast <- message
+	s.deliver(message, nil)
+}
+
+// DisconnectRemoteClients 通知并断开所有远程客户端（手机端）
+// 先发送通知消息，稍后再关闭连接，确保客户端能收到通知
+// DisconnectRemoteClients notifies and disconnects all remote (mobile) clients
+// The notice is sent first and the connection is closed shortly after so the client receives it
+func (s *SSEServer) DisconnectRemoteClients(notice Message) {
+	s.mu.RLock()
+	var targets []*SSEClient
+	for _, c := range s.Clients {
+		if !isLocalIP(c.IP) {
+			targets = append(targets, c)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, c := range targets {
+		c.Enqueue(notice)
+	}
+
+	time.AfterFunc(disconnectGracePeriod, func() {
+		for _, c := range targets {
+			select {
+			case c.Close <- struct{}{}:
+			default:
+			}
+		}
+	})
+	LogFormat("断开", "SSE", "服务端 --> 手机端", "主动断开 %d 个手机端连接", len(targets))
 }
 
 // HandleSSE 处理 SSE 连接请求
@@ -284,46 +409,25 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	clientType := r.URL.Query().Get("type")
 	isMobileDevice := clientType == "mobile"
 
-	// 如果是手机端，检查是否已有其他手机端连接
-	// If mobile device, check if there are already other mobile devices
-	if isMobileDevice {
-		s.mu.RLock()
-		hasMobileClient := false
-		for _, c := range s.Clients {
-			// 检查是否已有手机端连接（通过查询参数判断）
-			// Check if there are already mobile clients (via query parameter)
-			// 注意：这里我们无法从 SSEClient 获取查询参数，所以只能通过 IP 判断
-			// Note: we cannot get query parameters from SSEClient, so we judge by IP
-			if !isLocalIP(c.IP) {
-				hasMobileClient = true
-				break
-			}
-		}
-		s.mu.RUnlock()
-
-		if hasMobileClient {
-			LogFormat("拒绝", "SSE", "服务端", "拒绝 SSE 连接：已有手机端连接，IP: %s", clientIP)
-			// 返回错误状态
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("已有手机端连接，请稍后再试"))
-			return
-		}
+	// 如果是手机端，检查手机端数量是否已达上限（同一设备重连不受限制）
+	// If mobile device, check whether the mobile cap is reached (the same device reconnecting is not limited)
+	deviceID := DeviceID(r)
+	if isMobileDevice && !s.CanAcceptMobile(deviceID) {
+		LogFormat("拒绝", "SSE", "服务端", "拒绝 SSE 连接：手机端数量已达上限，IP: %s", clientIP)
+		// 返回错误状态
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("手机端数量已达上限，请稍后再试"))
+		return
 	}
 
 	// 使用 SSE (Server-Sent Events) 模拟 WebSocket / Use SSE (Server-Sent Events) to simulate WebSocket
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// 创建客户端 / Create client
-	clientID := generateClientID()
-	client := &SSEClient{
-		ID:    clientID,
-		IP:    clientIP,
-		Send:  make(chan Message, 256),
-		Close: make(chan struct{}, 1),
-	}
+	client := newClient(r, clientIP, deviceID)
+	clientID := client.ID
 
 	// 注册客户端 / Register client
 	s.register <- client
@@ -334,8 +438,14 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	// Note: we don't wait for registration to complete here because registerClient runs asynchronously
 	// If registration fails (rejected), the client will be unregistered later
 
-	// 发送连接成功消息（包含 IP） / Send connection success message (including IP)
-	fmt.Fprintf(w, "event: connected\ndata: {\"id\":\"%s\",\"ip\":\"%s\"}\n\n", clientID, clientIP)
+	// 发送连接成功消息（包含 IP 和设备信息） / Send connection success message (including IP and device info)
+	connected, _ := json.Marshal(map[string]string{
+		"id":         clientID,
+		"ip":         clientIP,
+		"deviceId":   client.DeviceID,
+		"deviceName": client.DeviceName,
+	})
+	fmt.Fprintf(w, "event: connected\ndata: %s\n\n", connected)
 	if f, ok := w.(http.Flusher); ok {
 		f.Flush()
 	}
@@ -345,7 +455,9 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	defer ticker.Stop()
 
 	// 处理发送 / Handle sending
+	writerDone := make(chan struct{})
 	go func() {
+		defer close(writerDone)
 		defer func() {
 			if r := recover(); r != nil {
 				LogDebug("发送协程 panic 恢复: %v", r)
@@ -363,6 +475,9 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 				client.mu.RUnlock()
 
 				data, _ := json.Marshal(message)
+				if message.ID != 0 {
+					fmt.Fprintf(w, "id: %d\n", message.ID)
+				}
 				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
 				if f, ok := w.(http.Flusher); ok {
 					f.Flush()
@@ -387,8 +502,143 @@ func (s *SSEServer) HandleSSE(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	// 处理接收（通过 POST 请求） / Handle receiving (via POST request)
-	<-r.Context().Done()
+	// 处理接收（通过 POST 请求），直到客户端断开或服务端主动关闭
+	// Handle receiving (via POST request) until the client disconnects or the server closes it
+	select {
+	case <-r.Context().Done():
+	case <-writerDone:
+	}
+
+	// 注销客户端 / Unregister client
+	s.unregister <- client
+}
+
+// HandleWebSocket 处理 WebSocket 连接请求，上下行都使用与 SSE 相同的 Message 结构
+// HandleWebSocket handles WebSocket connection requests, carrying the same Message envelope as SSE in both directions
+func (s *SSEServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if r := recover(); r != nil {
+			LogDebug("WebSocket panic 恢复: %v", r)
+		}
+	}()
+
+	// 获取客户端 IP / Get client IP
+	clientIP := getClientIP(r)
+
+	// 判断连接类型 / Determine connection type
+	connType := "Unknown device"
+	if isLocalIP(clientIP) {
+		connType = "电脑端"
+	} else {
+		connType = "手机端"
+	}
+
+	LogFormat("接收", "WS", connType+" --> 服务端", "收到连接请求，IP: %s", clientIP)
+
+	// 与 SSE 相同的手机端数量限制 / Same mobile restriction as SSE
+	isMobileDevice := r.URL.Query().Get("type") == "mobile"
+	deviceID := DeviceID(r)
+	if isMobileDevice && !s.CanAcceptMobile(deviceID) {
+		LogFormat("拒绝", "WS", "服务端", "拒绝 WebSocket 连接：手机端数量已达上限，IP: %s", clientIP)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("手机端数量已达上限，请稍后再试"))
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		LogFormat("错误", "WS", "服务端", "WebSocket 握手失败: %v", err)
+		if errors.Is(err, errWSCrossOrigin) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "WebSocket upgrade required", http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	// 创建客户端 / Create client
+	client := newClient(r, clientIP, deviceID)
+	clientID := client.ID
+
+	// 注册客户端（与 SSE 共用注册、广播及 PC 端计数逻辑） / Register client (shares registration, broadcast and PC counting with SSE)
+	s.register <- client
+
+	// 发送连接成功消息（Payload 含设备信息） / Send connection success message (Payload carries the device info)
+	devicePayload, _ := json.Marshal(map[string]string{"deviceId": client.DeviceID, "deviceName": client.DeviceName})
+	connected, _ := json.Marshal(Message{Type: TypeConnected, Data: clientID, Payload: devicePayload})
+	if err := conn.WriteText(connected); err != nil {
+		s.unregister <- client
+		return
+	}
+
+	// 心跳定时器（使用 Ping 控制帧） / Heartbeat timer (using ping control frames)
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	// 处理发送 / Handle sending
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				LogDebug("WebSocket 发送协程 panic 恢复: %v", r)
+			}
+		}()
+
+		for {
+			select {
+			case message := <-client.Send:
+				client.mu.RLock()
+				if client.isClosed {
+					client.mu.RUnlock()
+					conn.Close()
+					return
+				}
+				client.mu.RUnlock()
+
+				data, _ := json.Marshal(message)
+				if err := conn.WriteText(data); err != nil {
+					LogFormat("错误", "WS", "服务端", "发送失败: %v", err)
+					conn.Close()
+					return
+				}
+
+			case <-ticker.C:
+				if err := conn.WritePing(); err != nil {
+					conn.Close()
+					return
+				}
+
+			case <-client.Close:
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// 处理接收 / Handle receiving
+	for {
+		opcode, payload, err := conn.ReadMessage()
+		if err != nil {
+			if err != errWSClosed {
+				LogFormat("断开", "WS", connType+" --> 服务端", "读取结束: %v", err)
+			}
+			break
+		}
+		if opcode != wsOpText {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			LogFormat("错误", "WS", connType+" --> 服务端", "消息解析失败: %v", err)
+			continue
+		}
+		// 已被接管或踢出的连接不再接收输入 / Connections that were replaced or kicked no longer take input
+		if client.State() != SessionActive {
+			continue
+		}
+		s.dispatchMessage(msg, client.source())
+	}
 
 	// 注销客户端 / Unregister client
 	s.unregister <- client
@@ -405,20 +655,21 @@ func (s *SSEServer) HandlePostMessage(w http.ResponseWriter, r *http.Request) {
 	// 获取客户端 IP
 	clientIP := getClientIP(r)
 
-	// 如果是远程设备（手机端），检查是否在已连接列表中
-	// If remote device (mobile), check if it's in the connected clients list
-	if !isLocalIP(clientIP) {
-		s.mu.RLock()
-		isConnected := false
-		for _, c := range s.Clients {
-			if c.IP == clientIP {
-				isConnected = true
-				break
-			}
+	// 如果是远程设备（手机端），检查是否在已连接列表中（优先按设备 ID 查找，没有设备 ID 时按 IP）
+	// If remote device (mobile), check if it's in the connected clients list (by device ID first, by IP without one)
+	source := MessageSource{IP: clientIP}
+	deviceID := DeviceID(r)
+	s.mu.RLock()
+	for _, c := range s.Clients {
+		if (deviceID != "" && c.DeviceID == deviceID) || (deviceID == "" && c.IP == clientIP) {
+			source = c.source()
+			source.IP = clientIP
+			break
 		}
-		s.mu.RUnlock()
-
-		if !isConnected {
+	}
+	s.mu.RUnlock()
+	if !isLocalIP(clientIP) {
+		if source.ClientID == "" {
 			LogFormat("拒绝", "HTTP", "服务端", "拒绝 POST 请求：客户端未连接，IP: %s", clientIP)
 			w.WriteHeader(http.StatusServiceUnavailable)
 			w.Write([]byte("请先连接到服务"))
@@ -432,49 +683,185 @@ func (s *SSEServer) HandlePostMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.dispatchMessage(msg, source)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// dispatchMessage 处理客户端上行的消息，SSE+POST 与 WebSocket 两种传输共用
+// dispatchMessage handles upstream client messages, shared by the SSE+POST and WebSocket transports
+func (s *SSEServer) dispatchMessage(msg Message, source MessageSource) {
 	// 处理心跳 / Handle heartbeat
-	if msg.Type == "heartbeat" {
-		w.WriteHeader(http.StatusOK)
+	if msg.Type == TypeHeartbeat {
 		return
 	}
 
 	// 调用回调（会自动广播消息） / Call callback (will automatically broadcast message)
 	if s.onMessage != nil && msg.Data != "" {
-		s.onMessage(msg.Data)
+		s.onMessage(msg.Data, source)
 	}
+}
 
-	w.WriteHeader(http.StatusOK)
+// newClient 根据请求创建客户端；远程客户端（手机端）记录设备名称
+// newClient creates a client for the request; remote (mobile) clients record the device name
+func newClient(r *http.Request, clientIP, deviceID string) *SSEClient {
+	client := &SSEClient{
+		ID:          generateClientID(),
+		IP:          clientIP,
+		DeviceID:    deviceID,
+		ConnectedAt: time.Now(),
+		Role:        clientRole(r, clientIP),
+		state:       SessionActive,
+		Send:        make(chan Message, 256),
+		Close:       make(chan struct{}, 1),
+	}
+	if !isLocalIP(clientIP) {
+		client.DeviceName = DeviceName(r, deviceID)
+	}
+	client.lastEventID, client.resuming = lastEventID(r)
+	return client
 }
 
-// getClientIP 从 HTTP 请求中提取客户端 IP 地址
-// getClientIP extracts the client IP address from the HTTP request
-func getClientIP(r *http.Request) string {
-	// 优先从 X-Forwarded-For 获取
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return xff
-	}
-
-	// 从 RemoteAddr 获取
-	if r.RemoteAddr != "" {
-		lastColon := len(r.RemoteAddr)
-		for i := len(r.RemoteAddr) - 1; i >= 0; i-- {
-			if r.RemoteAddr[i] == ':' {
-				lastColon = i
-				break
-			}
+// CanAcceptMobile 判断是否可以接受该设备的手机端连接：未达上限，或该设备已经连接（如刷新页面）
+// CanAcceptMobile reports whether a mobile connection from the device can be accepted: below the cap, or the device is already connected (e.g. a page reload)
+func (s *SSEServer) CanAcceptMobile(deviceID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.mobileFullLocked(deviceID)
+}
+
+// mobileFullLocked 判断手机端设备数量是否已达上限（不计 exceptDevice），调用方需持有锁
+// mobileFullLocked reports whether mobile devices have reached the cap (not counting exceptDevice); the caller holds the lock
+func (s *SSEServer) mobileFullLocked(exceptDevice string) bool {
+	if s.maxMobileDevices <= 0 {
+		return false
+	}
+	devices := make(map[string]bool)
+	for _, c := range s.Clients {
+		if isLocalIP(c.IP) {
+			continue
 		}
-		if lastColon > 0 {
-			return r.RemoteAddr[:lastColon]
+		if exceptDevice != "" && c.DeviceID == exceptDevice {
+			return false
 		}
+		devices[c.DeviceKey()] = true
 	}
+	return len(devices) >= s.maxMobileDevices
+}
+
+// Devices 返回已连接的手机端设备（按连接时间排序）
+// Devices returns the connected mobile devices (ordered by connection time)
+func (s *SSEServer) Devices() []DeviceInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.devicesLocked()
+}
 
-	return ""
+// devicesLocked 是 Devices 的内部实现，调用方需持有锁
+// devicesLocked implements Devices; the caller holds the lock
+func (s *SSEServer) devicesLocked() []DeviceInfo {
+	byKey := make(map[string]*DeviceInfo)
+	var devices []*DeviceInfo
+	for _, c := range s.Clients {
+		if isLocalIP(c.IP) {
+			continue
+		}
+		info, ok := byKey[c.DeviceKey()]
+		if !ok {
+			info = &DeviceInfo{ID: c.DeviceKey(), Name: c.DeviceName, IP: c.IP, ConnectedAt: c.ConnectedAt}
+			byKey[c.DeviceKey()] = info
+			devices = append(devices, info)
+		}
+		info.Connections++
+		if c.ConnectedAt.Before(info.ConnectedAt) {
+			info.ConnectedAt = c.ConnectedAt
+		} else {
+			// 使用最近一次连接的名称和 IP / Use the name and IP of the latest connection
+			info.Name, info.IP = c.DeviceName, c.IP
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ConnectedAt.Before(devices[j].ConnectedAt) })
+	result := make([]DeviceInfo, len(devices))
+	for i, d := range devices {
+		result[i] = *d
+	}
+	return result
 }
 
-// isLocalIP 判断给定的 IP 地址是否为本地地址
-// isLocalIP determines if the given IP address is a local address
+// LookupDevice 按设备 ID 查找已连接的手机端设备
+// LookupDevice finds a connected mobile device by its ID
+func (s *SSEServer) LookupDevice(id string) (DeviceInfo, bool) {
+	if id == "" {
+		return DeviceInfo{}, false
+	}
+	for _, d := range s.Devices() {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	return DeviceInfo{}, false
+}
+
+// broadcastDevicesLocked 通知电脑端手机端列表已变化，调用方需持有锁（在 Run 协程中调用）
+// broadcastDevicesLocked tells the PC pages the mobile device list changed; the caller holds the lock (called from the Run goroutine)
+func (s *SSEServer) broadcastDevicesLocked() {
+	s.deliver(s.devicesMessageLocked(), isPC)
+}
+
+// devicesMessageLocked 返回当前手机端列表的消息，调用方需持有锁
+// devicesMessageLocked returns the message carrying the current mobile device list; the caller holds the lock
+func (s *SSEServer) devicesMessageLocked() Message {
+	payload, _ := json.Marshal(s.devicesLocked())
+	return Message{Type: TypeDevices, Payload: payload}
+}
+
+// HandleDevices 处理 /api/devices：返回已连接的手机端设备，仅允许本机访问（设备 ID 可用于踢出）
+// HandleDevices handles /api/devices: returns the connected mobile devices; local requests only (device IDs can be used to kick)
+func (s *SSEServer) HandleDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopbackRequest(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Devices())
+}
+
+// ClientIP 从 HTTP 请求中提取客户端 IP 地址，供包外的处理函数使用
+// ClientIP extracts the client IP address from the HTTP request, for handlers outside this package
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
+}
+
+// getClientIP 从 RemoteAddr 提取客户端 IP 地址（IPv6 不带方括号，链路本地地址保留 Zone，如 fe80::1%eth0）
+// 本应用前面没有可信的反向代理，不读取 X-Forwarded-For，否则局域网设备可以伪造 127.0.0.1 冒充电脑端
+// getClientIP extracts the client IP address from RemoteAddr (IPv6 without brackets; link-local keeps its zone, e.g. fe80::1%eth0)
+// There is no trusted reverse proxy in front of this app, so X-Forwarded-For is ignored; otherwise a LAN device could claim 127.0.0.1 and pass as the PC
+func getClientIP(r *http.Request) string {
+	return stripPort(r.RemoteAddr)
+}
+
+// stripPort 去掉地址中的端口和 IPv6 方括号；没有端口时原样返回（仍去掉方括号）
+// stripPort removes the port and IPv6 brackets from an address; without a port it is returned as is (minus brackets)
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+// isLocalIP 判断给定的 IP 地址是否为本地地址（支持带 Zone 的 IPv6 地址）
+// isLocalIP determines if the given IP address is a local address (IPv6 addresses with a zone are supported)
 func isLocalIP(ip string) bool {
-	return ip == "127.0.0.1" || ip == "::1" || ip == "localhost" || ip == ""
+	if ip == "localhost" || ip == "" {
+		return true
+	}
+	host, _, _ := strings.Cut(ip, "%")
+	parsed := net.ParseIP(host)
+	return parsed != nil && parsed.IsLoopback()
 }
 
 // generateClientID 生成唯一的客户端标识符
@@ -0,0 +1,158 @@
+// Package network 提供手机端会话的接管和踢出：同一设备的新连接接管残留的旧连接，电脑端可以断开指定手机并撤销其配对
+// Package network provides mobile session takeover and kick-out: a new connection from the same device replaces a stale one,
+// and the PC can disconnect a phone and revoke its pairing
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// SessionState 表示一个手机端连接的会话状态
+// SessionState is the session state of a mobile connection
+type SessionState string
+
+const (
+	SessionActive   SessionState = "active"   // 正常连接 / Connected
+	SessionReplaced SessionState = "replaced" // 被同一设备的新连接接管 / Taken over by a newer connection from the same device
+	SessionKicked   SessionState = "kicked"   // 被电脑端断开 / Kicked by the PC
+	SessionClosed   SessionState = "closed"   // 连接已断开 / Connection closed
+)
+
+// sessionTransitions 允许的状态转换：只有 active 可以被接管或踢出，任何未关闭的状态都可以转为 closed
+// sessionTransitions lists the allowed transitions: only active can be replaced or kicked, and any open state can become closed
+var sessionTransitions = map[SessionState][]SessionState{
+	SessionActive:   {SessionReplaced, SessionKicked, SessionClosed},
+	SessionReplaced: {SessionClosed},
+	SessionKicked:   {SessionClosed},
+}
+
+// State 返回连接的会话状态
+// State returns the session state of the connection
+func (c *SSEClient) State() SessionState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// transition 按 sessionTransitions 切换会话状态，不允许的转换返回 false
+// transition switches the session state per sessionTransitions; returns false for a disallowed transition
+func (c *SSEClient) transition(to SessionState) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, allowed := range sessionTransitions[c.state] {
+		if allowed == to {
+			LogFormat("会话", "SSE", "服务端", "连接 %s: %s -> %s", c.ID, c.state, to)
+			c.state = to
+			return true
+		}
+	}
+	return false
+}
+
+// endSessionLocked 结束连接的会话：切换状态、移出连接列表、通知客户端原因，稍后关闭连接（调用方需持有写锁）
+// endSessionLocked ends a connection's session: switches its state, removes it from the client list,
+// tells the client why and closes the connection shortly after (the caller holds the write lock)
+func (s *SSEServer) endSessionLocked(client *SSEClient, state SessionState) bool {
+	if !client.transition(state) {
+		return false
+	}
+	delete(s.Clients, client.ID)
+	client.Enqueue(Message{Type: TypeSessionEnd, Data: string(state)})
+	time.AfterFunc(disconnectGracePeriod, func() {
+		select {
+		case client.Close <- struct{}{}:
+		default:
+		}
+	})
+	return true
+}
+
+// takeoverLocked 让新连接接管同一设备（按 DeviceKey 区分）残留的旧连接（调用方需持有写锁）
+// takeoverLocked lets a new connection take over stale connections from the same device (told apart by DeviceKey; the caller holds the write lock)
+func (s *SSEServer) takeoverLocked(client *SSEClient) {
+	if isLocalIP(client.IP) {
+		return
+	}
+	key := client.DeviceKey()
+	for _, old := range s.Clients {
+		if old == client || old.DeviceKey() != key || isLocalIP(old.IP) {
+			continue
+		}
+		if s.endSessionLocked(old, SessionReplaced) {
+			LogFormat("接管", "SSE", "手机端 --> 服务端", "设备 %s 的新连接 %s 接管了旧连接 %s", key, client.ID, old.ID)
+		}
+	}
+}
+
+// Kick 断开指定设备（按 DeviceKey 区分）的所有连接并撤销其配对（deviceID 为空时断开所有手机端），返回断开的连接数
+// Kick disconnects every connection of the device (told apart by DeviceKey) and revokes its pairing
+// (all phones when deviceID is empty); returns how many connections were disconnected
+func (s *SSEServer) Kick(deviceID string) int {
+	s.mu.Lock()
+	kicked := 0
+	var keys []string
+	for _, c := range s.Clients {
+		if isLocalIP(c.IP) || (deviceID != "" && c.DeviceKey() != deviceID) {
+			continue
+		}
+		if s.endSessionLocked(c, SessionKicked) {
+			kicked++
+			if !slices.Contains(keys, c.DeviceKey()) {
+				keys = append(keys, c.DeviceKey())
+			}
+		}
+	}
+	devices := s.devicesMessageLocked()
+	full := s.mobileFullLocked("")
+	s.mu.Unlock()
+
+	if kicked == 0 {
+		return 0
+	}
+	// 撤销配对，否则手机端重新连接即可绕过 / Revoke the pairing, otherwise the phone just reconnects
+	if s.onKick != nil {
+		for _, key := range keys {
+			s.onKick(key)
+		}
+	}
+	LogFormat("断开", "SSE", "服务端 --> 手机端", "电脑端断开了 %d 个手机端连接", kicked)
+	s.SendToRole(RolePC, devices)
+	if !full {
+		s.SendToRole(RolePC, Message{Type: TypeShowQR, Data: "true"})
+	}
+	return kicked
+}
+
+// HandleKick 处理 /api/devices/kick：电脑端断开指定手机（deviceId 为空时断开所有手机），仅允许本机访问
+// HandleKick handles /api/devices/kick: the PC disconnects a phone (every phone when deviceId is empty); local requests only
+func (s *SSEServer) HandleKick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopbackRequest(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		DeviceID string `json:"deviceId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	kicked := s.Kick(req.DeviceID)
+	if kicked == 0 {
+		http.Error(w, "手机端未连接", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"kicked": kicked})
+}
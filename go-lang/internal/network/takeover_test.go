@@ -0,0 +1,143 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// addMobile 直接向服务中加入一个手机端连接 / addMobile puts a mobile connection straight into the server
+func addMobile(s *SSEServer, id, ip, deviceID string) *SSEClient {
+	c := &SSEClient{
+		ID:       id,
+		IP:       ip,
+		DeviceID: deviceID,
+		Role:     RoleMobile,
+		state:    SessionActive,
+		Send:     make(chan Message, 8),
+		Close:    make(chan struct{}, 1),
+	}
+	s.Clients[id] = c
+	return c
+}
+
+func TestTakeoverUsesDeviceKey(t *testing.T) {
+	s := NewSSEServer()
+	withCookie := addMobile(s, "a1", "192.168.1.20", "dev_phone")
+	noCookie := addMobile(s, "b1", "192.168.1.30", "")
+	other := addMobile(s, "c1", "192.168.1.40", "")
+
+	// 同一设备 ID 换了 IP，以及没有 Cookie 的手机从同一 IP 重新连接
+	// The same device ID on a new IP, and a phone without the cookie reconnecting from the same IP
+	s.takeoverLocked(addMobile(s, "a2", "192.168.1.21", "dev_phone"))
+	s.takeoverLocked(addMobile(s, "b2", "192.168.1.30", ""))
+
+	if got := withCookie.State(); got != SessionReplaced {
+		t.Errorf("device ID connection state = %s, want %s", got, SessionReplaced)
+	}
+	if got := noCookie.State(); got != SessionReplaced {
+		t.Errorf("IP keyed connection state = %s, want %s", got, SessionReplaced)
+	}
+	if got := other.State(); got != SessionActive {
+		t.Errorf("other phone state = %s, want %s", got, SessionActive)
+	}
+}
+
+func TestKickRevokesPairing(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	pair := func(ip, deviceID string) string {
+		pin, _ := pm.CurrentPIN()
+		token, err := pm.Pair(pin, ip, deviceID)
+		if err != nil {
+			t.Fatalf("Pair: %v", err)
+		}
+		return token
+	}
+	cookieToken := pair("192.168.1.20", "dev_phone")
+	ipToken := pair("192.168.1.30", "")
+	otherToken := pair("192.168.1.40", "dev_other")
+
+	s := NewSSEServer()
+	s.SetOnKick(pm.RevokeDevice)
+	addMobile(s, "a1", "192.168.1.20", "dev_phone")
+	addMobile(s, "b1", "192.168.1.30", "")
+	addMobile(s, "c1", "192.168.1.40", "dev_other")
+
+	// 设备列表中没有 Cookie 的手机以 IP 为 ID / A phone without the cookie is listed with its IP as the ID
+	if n := s.Kick("192.168.1.30"); n != 1 {
+		t.Fatalf("Kick(ip) = %d, want 1", n)
+	}
+	if n := s.Kick("dev_phone"); n != 1 {
+		t.Fatalf("Kick(device ID) = %d, want 1", n)
+	}
+	if pm.Validate(ipToken) || pm.Validate(cookieToken) {
+		t.Error("kicked devices are still paired")
+	}
+	if !pm.Validate(otherToken) {
+		t.Error("the phone that was not kicked lost its pairing")
+	}
+
+	// 踢出后重新连接需要重新配对 / Reconnecting after a kick needs a new pairing
+	r := httptest.NewRequest(http.MethodGet, "/ws?type=mobile", nil)
+	r.RemoteAddr = "192.168.1.30:40000"
+	r.AddCookie(&http.Cookie{Name: PairingCookieName, Value: ipToken})
+	if pm.IsTrusted(r) {
+		t.Error("kicked phone is still trusted")
+	}
+}
+
+func TestPairRequestRecordsDeviceID(t *testing.T) {
+	pm := NewPairingManager(time.Minute, time.Hour)
+	pin, _ := pm.CurrentPIN()
+	r := httptest.NewRequest(http.MethodGet, "/?pin="+pin, nil)
+	r.RemoteAddr = "192.168.1.20:40000"
+	r.AddCookie(&http.Cookie{Name: DeviceCookieName, Value: "dev_0011223344556677"})
+	if err := pm.PairRequest(httptest.NewRecorder(), r); err != nil {
+		t.Fatalf("PairRequest: %v", err)
+	}
+
+	pm.RevokeDevice("dev_0011223344556677")
+	if n := pm.SessionCount(); n != 0 {
+		t.Errorf("SessionCount after RevokeDevice = %d, want 0", n)
+	}
+}
+
+func TestHandleDevicesIsLocalOnly(t *testing.T) {
+	s := NewSSEServer()
+	addMobile(s, "a1", "192.168.1.20", "dev_phone")
+
+	tests := []struct {
+		remote string
+		want   int
+	}{
+		{"127.0.0.1:40000", http.StatusOK},
+		{"192.168.1.30:40000", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/devices", nil)
+		r.Host = "127.0.0.1:5000"
+		r.RemoteAddr = tt.remote
+		w := httptest.NewRecorder()
+		s.HandleDevices(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.remote, w.Code, tt.want)
+		}
+	}
+}
+
+func TestClientIPIgnoresForwardedFor(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	r.RemoteAddr = "192.168.1.30:40000"
+	r.Header.Set("X-Forwarded-For", "127.0.0.1")
+
+	if got := ClientIP(r); got != "192.168.1.30" {
+		t.Errorf("ClientIP = %q, want 192.168.1.30", got)
+	}
+	if isLocalIP(ClientIP(r)) {
+		t.Error("a spoofed X-Forwarded-For made a LAN client local")
+	}
+	if got := clientRole(r, ClientIP(r)); got != RoleMobile {
+		t.Errorf("clientRole = %s, want %s", got, RoleMobile)
+	}
+}
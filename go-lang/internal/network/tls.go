@@ -0,0 +1,235 @@
+// Package network 提供 HTTPS 所需的自签名证书生成与缓存
+// Package network provides generation and caching of the self-signed certificates needed for HTTPS
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	caCertFile     = "ca.pem"
+	caKeyFile      = "ca-key.pem"
+	leafCertFile   = "cert.pem"
+	leafKeyFile    = "key.pem"
+	caValidity     = 10 * 365 * 24 * time.Hour // 本地 CA 有效期
+	leafValidity   = 397 * 24 * time.Hour      // 服务端证书有效期（浏览器上限 398 天）
+	leafRenewAhead = 30 * 24 * time.Hour       // 提前续期时间
+)
+
+// DefaultCertDir 返回证书缓存目录（用户配置目录下的 airinputlan/tls）
+// DefaultCertDir returns the certificate cache directory (airinputlan/tls under the user config dir)
+func DefaultCertDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户配置目录失败: %w", err)
+	}
+	return filepath.Join(configDir, "airinputlan", "tls"), nil
+}
+
+// LoadOrCreateCertificate 加载或生成覆盖指定 IP 的服务端证书，返回证书及其 SHA-256 指纹
+// 本地 CA 只生成一次；当缓存的服务端证书未覆盖当前 IP 或即将过期时重新签发
+// LoadOrCreateCertificate loads or generates a server certificate covering the given IPs and returns it with its SHA-256 fingerprint
+// The local CA is generated once; the server certificate is reissued when it misses a current IP or is about to expire
+func LoadOrCreateCertificate(dir string, ips []string) (tls.Certificate, string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, "", fmt.Errorf("创建证书目录失败: %w", err)
+	}
+
+	caCert, caKey, err := loadOrCreateCA(dir)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+
+	// 证书始终包含本机地址，便于电脑端通过 127.0.0.1 访问 / Always include loopback so the PC page can use 127.0.0.1
+	hosts := append([]string{"127.0.0.1", "::1", "localhost"}, ips...)
+
+	certPath := filepath.Join(dir, leafCertFile)
+	keyPath := filepath.Join(dir, leafKeyFile)
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err == nil && leafUsable(leaf, caCert, hosts) {
+			cert.Certificate = append(cert.Certificate, caCert.Raw)
+			LogFormat("加载", "TLS", "系统", "使用缓存的服务端证书: %s", certPath)
+			return cert, CertificateFingerprint(leaf.Raw), nil
+		}
+	}
+
+	LogInfo("正在签发新的服务端证书...")
+	leafDER, leafKey, err := issueLeaf(caCert, caKey, hosts)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	if err := writePEM(certPath, "CERTIFICATE", leafDER); err != nil {
+		return tls.Certificate{}, "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(leafKey)
+	if err != nil {
+		return tls.Certificate{}, "", err
+	}
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDER); err != nil {
+		return tls.Certificate{}, "", err
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{leafDER, caCert.Raw},
+		PrivateKey:  leafKey,
+	}
+	return cert, CertificateFingerprint(leafDER), nil
+}
+
+// CertificateFingerprint 返回证书 DER 数据的 SHA-256 指纹（冒号分隔的大写十六进制）
+// CertificateFingerprint returns the SHA-256 fingerprint of certificate DER data (colon-separated uppercase hex)
+func CertificateFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// loadOrCreateCA 加载或生成本地 CA
+// loadOrCreateCA loads or generates the local CA
+func loadOrCreateCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath := filepath.Join(dir, caCertFile)
+	keyPath := filepath.Join(dir, caKeyFile)
+
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		caCert, err := x509.ParseCertificate(pair.Certificate[0])
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if err == nil && ok && time.Now().Before(caCert.NotAfter) {
+			return caCert, key, nil
+		}
+	}
+
+	LogInfo("正在生成本地 CA 证书...")
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成 CA 私钥失败: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"AirInputLan"}, CommonName: "AirInputLan Local CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成 CA 证书失败: %w", err)
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writePEM(certPath, "CERTIFICATE", der); err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(keyPath, "EC PRIVATE KEY", keyDER); err != nil {
+		return nil, nil, err
+	}
+	return caCert, key, nil
+}
+
+// issueLeaf 使用本地 CA 签发覆盖指定主机的服务端证书
+// issueLeaf issues a server certificate covering the given hosts, signed by the local CA
+func issueLeaf(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, hosts []string) ([]byte, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("生成服务端私钥失败: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"AirInputLan"}, CommonName: "AirInputLan"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("签发服务端证书失败: %w", err)
+	}
+	return der, key, nil
+}
+
+// leafUsable 判断缓存的服务端证书是否仍可使用
+// leafUsable reports whether the cached server certificate is still usable
+func leafUsable(leaf, caCert *x509.Certificate, hosts []string) bool {
+	if time.Now().Add(leafRenewAhead).After(leaf.NotAfter) {
+		return false
+	}
+	if err := leaf.CheckSignatureFrom(caCert); err != nil {
+		return false
+	}
+	for _, h := range hosts {
+		if err := leaf.VerifyHostname(h); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// randomSerial 生成随机证书序列号
+// randomSerial generates a random certificate serial number
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("生成证书序列号失败: %w", err)
+	}
+	return serial, nil
+}
+
+// writePEM 以 PEM 格式写入文件（私钥仅当前用户可读）
+// writePEM writes data to a file in PEM format (readable by the current user only)
+func writePEM(path, blockType string, der []byte) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if data == nil {
+		return errors.New("PEM 编码失败")
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return nil
+}
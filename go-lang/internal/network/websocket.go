@@ -0,0 +1,296 @@
+// Package network 提供最小化的 WebSocket (RFC 6455) 服务端实现，仅依赖标准库
+// Package network provides a minimal WebSocket (RFC 6455) server implementation using only the standard library
+package network
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket 操作码 / WebSocket opcodes
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+const (
+	wsGUID           = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11" // RFC 6455 固定 GUID
+	wsMaxMessageSize = 1 << 20                                // 单条消息最大 1MB
+	wsWriteTimeout   = 5 * time.Second                        // 写超时
+)
+
+// errWSClosed 表示对端发送了关闭帧
+// errWSClosed indicates the peer sent a close frame
+var errWSClosed = errors.New("websocket: 连接已关闭")
+
+// errWSCrossOrigin 表示握手请求来自其他站点的页面
+// errWSCrossOrigin indicates the handshake came from a page on another site
+var errWSCrossOrigin = errors.New("websocket: 拒绝跨站请求")
+
+// wsConn 表示一个已完成握手的 WebSocket 连接
+// wsConn represents a WebSocket connection that has completed the handshake
+type wsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// isWebSocketUpgrade 判断请求是否为 WebSocket 升级请求
+// isWebSocketUpgrade reports whether the request is a WebSocket upgrade request
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// upgradeWebSocket 完成 WebSocket 握手并接管底层连接
+// upgradeWebSocket completes the WebSocket handshake and hijacks the underlying connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("websocket: 不支持的请求方法 %s", r.Method)
+	}
+	if !isWebSocketUpgrade(r) {
+		return nil, errors.New("websocket: 缺少 Upgrade 请求头")
+	}
+	// 浏览器不限制跨站 WebSocket，本机连接又免配对，必须检查 Origin / Browsers allow cross-site WebSockets and loopback skips pairing, so Origin must be checked
+	if !sameOrigin(r) {
+		return nil, errWSCrossOrigin
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("websocket: 不支持的协议版本")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("websocket: 缺少 Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: 响应不支持 Hijack")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: Hijack 失败: %w", err)
+	}
+
+	// 清除 HTTP 服务设置的超时 / Clear deadlines set by the HTTP server
+	conn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + computeAcceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// sameOrigin 判断请求的 Origin 与 Host 是否一致；浏览器总会发送 Origin，没有 Origin 的请求来自非浏览器客户端，视为同源
+// sameOrigin reports whether the request's Origin matches its Host; browsers always send Origin,
+// so a request without one comes from a non-browser client and counts as same-origin
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		// 包括沙箱页面发送的 "null" / Includes the "null" sent by sandboxed pages
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// computeAcceptKey 根据客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+// computeAcceptKey computes Sec-WebSocket-Accept from the client's Sec-WebSocket-Key
+func computeAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含指定值（忽略大小写）
+// headerContainsToken reports whether a comma-separated header contains the token (case-insensitive)
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage 读取一条完整的数据消息，自动处理分片、Ping 和关闭帧
+// ReadMessage reads a complete data message, handling fragmentation, pings and close frames
+func (c *wsConn) ReadMessage() (int, []byte, error) {
+	var (
+		messageOp int
+		message   []byte
+	)
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			// 回应关闭帧 / Echo the close frame
+			c.writeFrame(wsOpClose, payload)
+			return 0, nil, errWSClosed
+		case wsOpText, wsOpBinary:
+			if message != nil {
+				return 0, nil, errors.New("websocket: 分片消息未结束")
+			}
+			messageOp = opcode
+			message = payload
+		case wsOpContinuation:
+			if message == nil {
+				return 0, nil, errors.New("websocket: 意外的延续帧")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, fmt.Errorf("websocket: 未知操作码 %d", opcode)
+		}
+
+		if len(message) > wsMaxMessageSize {
+			return 0, nil, errors.New("websocket: 消息过大")
+		}
+		if fin {
+			return messageOp, message, nil
+		}
+	}
+}
+
+// readFrame 读取单个帧并解除掩码
+// readFrame reads a single frame and unmasks its payload
+func (c *wsConn) readFrame() (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	// 客户端发送的帧必须带掩码 / Frames from the client must be masked
+	if !masked {
+		return false, 0, nil, errors.New("websocket: 客户端帧未使用掩码")
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageSize {
+		return false, 0, nil, errors.New("websocket: 帧过大")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteText 发送一条文本消息
+// WriteText sends a text message
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// WritePing 发送 Ping 控制帧
+// WritePing sends a ping control frame
+func (c *wsConn) WritePing() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+// writeFrame 写入单个未分片、无掩码的帧（服务端帧不使用掩码）
+// writeFrame writes a single unfragmented, unmasked frame (server frames are not masked)
+func (c *wsConn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|byte(opcode))
+	switch length := len(payload); {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	if len(payload) > 0 {
+		if _, err := c.conn.Write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 发送关闭帧并关闭底层连接
+// Close sends a close frame and closes the underlying connection
+func (c *wsConn) Close() error {
+	c.writeFrame(wsOpClose, []byte{0x03, 0xE8}) // 1000: 正常关闭 / normal closure
+	return c.conn.Close()
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   bool
+	}{
+		{"no origin", "127.0.0.1:5000", "", true},
+		{"same host and port", "127.0.0.1:5000", "http://127.0.0.1:5000", true},
+		{"https page", "192.168.1.2:5000", "https://192.168.1.2:5000", true},
+		{"host case", "AirInput.local:5000", "http://airinput.local:5000", true},
+		{"other site", "127.0.0.1:5000", "https://evil.example", false},
+		{"other port", "127.0.0.1:5000", "http://127.0.0.1:8080", false},
+		{"sandboxed page", "127.0.0.1:5000", "null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ws/v2", nil)
+			r.Host = tt.host
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := sameOrigin(r); got != tt.want {
+				t.Errorf("sameOrigin(Host %q, Origin %q) = %v, want %v", tt.host, tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeWebSocketRejectsCrossOrigin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws/v2?type=pc", nil)
+	r.Host = "127.0.0.1:5000"
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	r.Header.Set("Origin", "https://evil.example")
+
+	if _, err := upgradeWebSocket(httptest.NewRecorder(), r); err != errWSCrossOrigin {
+		t.Fatalf("upgradeWebSocket error = %v, want %v", err, errWSCrossOrigin)
+	}
+}
+
+func TestComputeAcceptKey(t *testing.T) {
+	// RFC 6455 第 1.3 节的示例 / Example from RFC 6455 section 1.3
+	if got, want := computeAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("computeAcceptKey = %q, want %q", got, want)
+	}
+}
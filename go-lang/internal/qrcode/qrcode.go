@@ -0,0 +1,538 @@
+// Package qrcode 提供纯 Go 实现的二维码编码器（字节模式，版本 1-40）
+// Package qrcode provides a pure Go QR code encoder (byte mode, versions 1-40)
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level 表示纠错等级
+// Level represents the error correction level
+type Level int
+
+const (
+	Low      Level = iota // 约 7% 纠错 / About 7% recovery
+	Medium                // 约 15% 纠错 / About 15% recovery
+	Quartile              // 约 25% 纠错 / About 25% recovery
+	High                  // 约 30% 纠错 / About 30% recovery
+)
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+// ErrTooLong 表示数据超出版本 40 的容量
+// ErrTooLong indicates the data exceeds the capacity of version 40
+var ErrTooLong = errors.New("数据过长，无法编码为二维码")
+
+// formatBits 返回纠错等级在格式信息中的 2 位编码
+// formatBits returns the 2-bit encoding of the level in the format information
+func (l Level) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+// eccCodewordsPerBlock 为每个块的纠错码字数，按 [等级][版本] 索引
+// eccCodewordsPerBlock is the number of ECC codewords per block, indexed by [level][version]
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// numErrorCorrectionBlocks 为纠错块数量，按 [等级][版本] 索引
+// numErrorCorrectionBlocks is the number of ECC blocks, indexed by [level][version]
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code 表示一个已编码的二维码
+// Code represents an encoded QR code
+type Code struct {
+	Version int   // 版本 1-40 / Version 1-40
+	Level   Level // 纠错等级 / Error correction level
+	Size    int   // 每边模块数 / Modules per side
+
+	modules    [][]bool // true 为深色 / true is dark
+	isFunction [][]bool // 功能图形区域，不参与数据填充和掩码 / Function pattern areas, excluded from data and masking
+}
+
+// Encode 以字节模式编码文本，自动选择最小的版本和最佳掩码
+// Encode encodes text in byte mode, choosing the smallest version and the best mask
+func Encode(text string, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, fmt.Errorf("无效的纠错等级: %d", level)
+	}
+	data := []byte(text)
+
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if segmentBits(len(data), version) <= dataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, ErrTooLong
+	}
+
+	// 模式指示符 + 字符计数 + 数据 / Mode indicator + character count + data
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	// 终止符、字节对齐和填充字节 / Terminator, byte alignment and pad bytes
+	capacity := dataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+
+	c := newCode(version, level)
+	c.drawCodewords(c.addECCAndInterleave(codewords))
+	c.applyBestMask()
+	return c, nil
+}
+
+// Dark 返回指定模块是否为深色，坐标越界时返回 false（静区）
+// Dark reports whether the module is dark; out-of-range coordinates return false (quiet zone)
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
+
+// newCode 创建指定版本的空白二维码并绘制功能图形
+// newCode creates a blank code of the given version and draws the function patterns
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17
+	c := &Code{
+		Version:    version,
+		Level:      level,
+		Size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range size {
+		c.modules[i] = make([]bool, size)
+		c.isFunction[i] = make([]bool, size)
+	}
+	c.drawFunctionPatterns()
+	return c
+}
+
+// setFunction 设置功能图形模块
+// setFunction sets a function pattern module
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns 绘制定时图形、定位图形、校正图形、格式和版本信息
+// drawFunctionPatterns draws the timing, finder and alignment patterns plus format and version information
+func (c *Code) drawFunctionPatterns() {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	positions := alignmentPositions(c.Version)
+	last := len(positions) - 1
+	for i, px := range positions {
+		for j, py := range positions {
+			// 跳过与定位图形重叠的三个角 / Skip the three corners overlapping the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(px, py)
+		}
+	}
+
+	// 先占位格式信息，掩码确定后再重绘 / Reserve the format area now, redraw once the mask is chosen
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinder 绘制以 (x, y) 为中心的定位图形及其分隔符
+// drawFinder draws a finder pattern and its separator centred at (x, y)
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment 绘制以 (x, y) 为中心的校正图形
+// drawAlignment draws an alignment pattern centred at (x, y)
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits 绘制两份格式信息（纠错等级 + 掩码，BCH 编码）
+// drawFormatBits draws both copies of the format information (level + mask, BCH coded)
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatInfo(c.Level, mask)
+
+	// 左上角 / Top-left corner
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bitAt(bits, i))
+	}
+	c.setFunction(8, 7, bitAt(bits, 6))
+	c.setFunction(8, 8, bitAt(bits, 7))
+	c.setFunction(7, 8, bitAt(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bitAt(bits, i))
+	}
+
+	// 右上角和左下角 / Top-right and bottom-left corners
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bitAt(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bitAt(bits, i))
+	}
+	c.setFunction(8, c.Size-8, true) // 固定深色模块 / Always-dark module
+}
+
+// formatInfo 返回纠错等级和掩码的 15 位格式信息（BCH(15,5) 编码后与 0x5412 异或）
+// formatInfo returns the 15-bit format information for the level and mask (BCH(15,5) coded, XORed with 0x5412)
+func formatInfo(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion 绘制版本信息（版本 7 及以上）
+// drawVersion draws the version information (version 7 and above)
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionInfo(c.Version)
+	for i := 0; i < 18; i++ {
+		dark := bitAt(bits, i)
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// versionInfo 返回 18 位版本信息（BCH(18,6) 编码）
+// versionInfo returns the 18-bit version information (BCH(18,6) coded)
+func versionInfo(version int) int {
+	rem := version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// addECCAndInterleave 将数据分块、追加 Reed-Solomon 纠错码并交织
+// addECCAndInterleave splits the data into blocks, appends Reed-Solomon ECC and interleaves them
+func (c *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := numRawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0) // 短块占位，交织时跳过 / Short block placeholder, skipped when interleaving
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords 按之字形顺序填充数据模块
+// drawCodewords fills the data modules in zigzag order
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // 跳过竖直定时图形 / Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = data[i>>3]>>(7-uint(i&7))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask 对数据模块应用（或撤销）指定掩码
+// applyMask applies (or undoes) the given mask to the data modules
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask 依次尝试 8 种掩码，选择惩罚分最低的一种
+// applyBestMask tries all 8 masks and keeps the one with the lowest penalty
+func (c *Code) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // 异或两次即撤销 / XOR twice undoes it
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+}
+
+// penalty 按规范的四条规则计算惩罚分
+// penalty computes the penalty score using the four rules of the specification
+func (c *Code) penalty() int {
+	const (
+		penaltyN1 = 3
+		penaltyN2 = 3
+		penaltyN3 = 40
+		penaltyN4 = 10
+	)
+	score := 0
+
+	// 规则 1：行列中连续 5 个以上同色模块 / Rule 1: runs of 5 or more same-colour modules in rows and columns
+	for i := 0; i < c.Size; i++ {
+		rowRun, colRun := 1, 1
+		for j := 1; j < c.Size; j++ {
+			rowRun = runStep(rowRun, c.modules[i][j] == c.modules[i][j-1], &score, penaltyN1)
+			colRun = runStep(colRun, c.modules[j][i] == c.modules[j-1][i], &score, penaltyN1)
+		}
+		score += runPenalty(rowRun, penaltyN1) + runPenalty(colRun, penaltyN1)
+	}
+
+	// 规则 2：2x2 同色块 / Rule 2: 2x2 blocks of the same colour
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			color := c.modules[y][x]
+			if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+				score += penaltyN2
+			}
+		}
+	}
+
+	// 规则 3：类似定位图形的 1:1:3:1:1 序列 / Rule 3: finder-like 1:1:3:1:1 sequences
+	patterns := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for i := 0; i < c.Size; i++ {
+		for j := 0; j+11 <= c.Size; j++ {
+			for _, pattern := range patterns {
+				rowMatch, colMatch := true, true
+				for k, dark := range pattern {
+					rowMatch = rowMatch && c.modules[i][j+k] == dark
+					colMatch = colMatch && c.modules[j+k][i] == dark
+				}
+				if rowMatch {
+					score += penaltyN3
+				}
+				if colMatch {
+					score += penaltyN3
+				}
+			}
+		}
+	}
+
+	// 规则 4：深色模块比例偏离 50% / Rule 4: dark module ratio deviating from 50%
+	dark := 0
+	for _, row := range c.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * penaltyN4
+	return score
+}
+
+// runStep 更新连续同色长度，颜色变化时累加上一段的惩罚分
+// runStep updates the same-colour run length, adding the previous run's penalty when the colour changes
+func runStep(run int, same bool, score *int, weight int) int {
+	if same {
+		return run + 1
+	}
+	*score += runPenalty(run, weight)
+	return 1
+}
+
+// runPenalty 返回一段连续同色模块的惩罚分
+// runPenalty returns the penalty for a run of same-colour modules
+func runPenalty(run, weight int) int {
+	if run < 5 {
+		return 0
+	}
+	return weight + run - 5
+}
+
+// alignmentPositions 返回校正图形中心的坐标列表
+// alignmentPositions returns the centre coordinates of the alignment patterns
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// numRawDataModules 返回除功能图形外可用于数据和纠错的模块数
+// numRawDataModules returns the number of modules available for data and ECC after the function patterns
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// dataCodewords 返回指定版本和纠错等级下的数据码字数
+// dataCodewords returns the number of data codewords for the version and level
+func dataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 -
+		eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// charCountBits 返回字节模式下字符计数字段的位数
+// charCountBits returns the width of the character count field in byte mode
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// segmentBits 返回编码 n 字节所需的总位数
+// segmentBits returns the total number of bits needed to encode n bytes
+func segmentBits(n, version int) int {
+	return 4 + charCountBits(version) + n*8
+}
+
+// bitBuffer 是按位追加的缓冲区
+// bitBuffer is a buffer that bits are appended to
+type bitBuffer []bool
+
+// append 追加 val 的低 n 位（高位在前）
+// append appends the low n bits of val, most significant first
+func (bb *bitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, val>>uint(i)&1 == 1)
+	}
+}
+
+// bitAt 返回 x 的第 i 位
+// bitAt returns bit i of x
+func bitAt(x, i int) bool {
+	return x>>uint(i)&1 != 0
+}
+
+// abs 返回整数的绝对值
+// abs returns the absolute value of an integer
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,47 @@
+// Package qrcode 提供 GF(256) 上的 Reed-Solomon 纠错码计算
+// Package qrcode provides Reed-Solomon error correction over GF(256)
+package qrcode
+
+// reedSolomonDivisor 返回指定次数的生成多项式系数（最高次项系数 1 省略）
+// reedSolomonDivisor returns the generator polynomial coefficients for the degree (the leading 1 is omitted)
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder 返回数据除以生成多项式的余数，即纠错码字
+// reedSolomonRemainder returns the remainder of the data divided by the generator, i.e. the ECC codewords
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply 在 GF(2^8) 上相乘（本原多项式 0x11D）
+// gfMultiply multiplies in GF(2^8) with the primitive polynomial 0x11D
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
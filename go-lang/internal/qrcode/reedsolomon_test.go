@@ -0,0 +1,47 @@
+package qrcode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			// ISO/IEC 18004 附录 I："01234567"，版本 1-M / ISO/IEC 18004 Annex I: "01234567", version 1-M
+			name: "iso 01234567 1-M",
+			data: []byte{16, 32, 12, 86, 97, 128, 236, 17, 236, 17, 236, 17, 236, 17, 236, 17},
+			want: []byte{165, 36, 212, 193, 237, 54, 199, 135, 44, 85},
+		},
+		{
+			// 字母数字模式 "HELLO WORLD"，版本 1-M / Alphanumeric "HELLO WORLD", version 1-M
+			name: "HELLO WORLD 1-M",
+			data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			want: []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+	}
+	for _, tt := range tests {
+		got := reedSolomonRemainder(tt.data, reedSolomonDivisor(len(tt.want)))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: remainder = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGFMultiply(t *testing.T) {
+	tests := []struct{ x, y, want byte }{
+		{0, 0x53, 0},
+		{1, 0x53, 0x53},
+		{2, 0x80, 0x1D},    // α^8 = x^4 + x^3 + x^2 + 1
+		{0x8E, 0x02, 0x01}, // α^254 · α = 1
+	}
+	for _, tt := range tests {
+		if got := gfMultiply(tt.x, tt.y); got != tt.want {
+			t.Errorf("gfMultiply(%#x, %#x) = %#x, want %#x", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package qrcode 提供二维码的终端、PNG 和 SVG 渲染
+// Package qrcode provides terminal, PNG and SVG rendering of QR codes
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+const (
+	QuietZone         = 4 // 图片静区宽度（模块数），规范要求至少 4 / Image quiet zone in modules, the spec requires at least 4
+	TerminalQuietZone = 2 // 终端静区宽度，节省屏幕空间 / Terminal quiet zone, smaller to save screen space
+)
+
+// Terminal 使用 Unicode 半块字符渲染二维码，每个字符表示上下两个模块
+// 浅色模块绘制为字符块、深色模块留空，适用于深色背景的终端
+// Terminal renders the code with Unicode half blocks, each character covering two modules vertically
+// Light modules are drawn as blocks and dark ones left blank, which suits dark-background terminals
+func (c *Code) Terminal() string {
+	var sb strings.Builder
+	start, end := -TerminalQuietZone, c.Size+TerminalQuietZone
+	for y := start; y < end; y += 2 {
+		for x := start; x < end; x++ {
+			top, bottom := !c.Dark(x, y), !c.Dark(x, y+1) && y+1 < end
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Image 返回二维码图像，scale 为每个模块的像素数，包含标准静区
+// Image returns the code as an image with scale pixels per module, including the standard quiet zone
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	width := (c.Size + QuietZone*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if c.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG 将二维码以 PNG 格式写入 w
+// WritePNG writes the code to w as a PNG
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+// SVG 返回二维码的 SVG 文本（以模块为单位的 viewBox，可任意缩放）
+// SVG returns the code as SVG text (viewBox in modules, so it scales freely)
+func (c *Code) SVG() string {
+	width := c.Size + QuietZone*2
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%s" fill="#000000"/></svg>`+"\n",
+		width, width, path.String())
+}
@@ -0,0 +1,95 @@
+// Package session 管理输入会话的状态机：输入模式及触发模式转换的事件
+// Package session manages the input session state machine: the input mode and the events that drive its transitions
+package session
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownMode 表示不支持的输入模式
+// ErrUnknownMode indicates an unsupported input mode
+var ErrUnknownMode = errors.New("未知的输入模式")
+
+// Mode 表示输入模式，决定由谁触发分段
+// Mode is the input mode, which decides who triggers segmentation
+type Mode string
+
+const (
+	ModeSingle     Mode = "single"     // 单次输入：手机端点击按钮分段 / Single input: the phone segments with a button
+	ModeContinuous Mode = "continuous" // 连续输入：服务端按分段间隔自动分段 / Continuous input: the server segments on the segment interval
+)
+
+// DefaultMode 启动时的输入模式
+// DefaultMode is the input mode at startup
+const DefaultMode = ModeSingle
+
+// ParseMode 解析输入模式名称
+// ParseMode parses an input mode name
+func ParseMode(name string) (Mode, error) {
+	switch Mode(name) {
+	case ModeSingle, ModeContinuous:
+		return Mode(name), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMode, name)
+}
+
+// Description 返回用于日志的模式说明
+// Description returns the mode description used in logs
+func (m Mode) Description() string {
+	if m == ModeContinuous {
+		return "连续输入模式（服务端控制分段）"
+	}
+	return "单次输入模式（手机控制分段）"
+}
+
+// Event 表示触发状态转换的事件
+// Event is an event that drives a state transition
+type Event string
+
+const (
+	EventSelectSingle     Event = "select_single"     // 手机端选择单次输入 / A phone selects single input
+	EventSelectContinuous Event = "select_continuous" // 手机端选择连续输入 / A phone selects continuous input
+	EventInput            Event = "input"             // 收到增量输入 / Incremental input arrives
+	EventSegment          Event = "segment"           // 手机端请求分段 / A phone requests a segment
+	EventTick             Event = "tick"              // 分段定时器触发 / The segment timer fires
+)
+
+// transitions 状态转换表：当前模式收到事件后进入的模式。手机端请求分段说明它在单次输入模式下工作，
+// 因此在任何模式下都会切换到单次输入；输入和定时器不改变模式
+// transitions is the state transition table: the mode entered when the current mode receives an event.
+// A segment request shows the phone works in single input, so it switches to single from any mode; input and ticks keep the mode
+var transitions = map[Mode]map[Event]Mode{
+	ModeSingle: {
+		EventSelectSingle:     ModeSingle,
+		EventSelectContinuous: ModeContinuous,
+		EventInput:            ModeSingle,
+		EventSegment:          ModeSingle,
+		EventTick:             ModeSingle,
+	},
+	ModeContinuous: {
+		EventSelectSingle:     ModeSingle,
+		EventSelectContinuous: ModeContinuous,
+		EventInput:            ModeContinuous,
+		EventSegment:          ModeSingle,
+		EventTick:             ModeContinuous,
+	},
+}
+
+// Next 返回模式收到事件后进入的模式，未定义的转换保持原模式
+// Next returns the mode entered when the mode receives the event; undefined transitions keep the mode
+func Next(mode Mode, event Event) Mode {
+	if next, ok := transitions[mode][event]; ok {
+		return next
+	}
+	return mode
+}
+
+// selectEvent 返回选择指定模式的事件
+// selectEvent returns the event that selects the given mode
+func selectEvent(mode Mode) Event {
+	if mode == ModeContinuous {
+		return EventSelectContinuous
+	}
+	return EventSelectSingle
+}
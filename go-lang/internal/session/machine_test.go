@@ -0,0 +1,58 @@
+package session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		mode  Mode
+		event Event
+		want  Mode
+	}{
+		{ModeSingle, EventSelectSingle, ModeSingle},
+		{ModeSingle, EventSelectContinuous, ModeContinuous},
+		{ModeSingle, EventInput, ModeSingle},
+		{ModeSingle, EventSegment, ModeSingle},
+		{ModeSingle, EventTick, ModeSingle},
+		{ModeContinuous, EventSelectSingle, ModeSingle},
+		{ModeContinuous, EventSelectContinuous, ModeContinuous},
+		{ModeContinuous, EventInput, ModeContinuous},
+		{ModeContinuous, EventSegment, ModeSingle},
+		{ModeContinuous, EventTick, ModeContinuous},
+		// 未定义的转换保持原模式 / Undefined transitions keep the mode
+		{ModeSingle, Event("bogus"), ModeSingle},
+		{ModeContinuous, Event("bogus"), ModeContinuous},
+		{Mode("bogus"), EventSelectContinuous, Mode("bogus")},
+		{Mode(""), EventTick, Mode("")},
+	}
+	for _, tt := range tests {
+		if got := Next(tt.mode, tt.event); got != tt.want {
+			t.Errorf("Next(%q, %q) = %q, want %q", tt.mode, tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Mode
+		wantErr bool
+	}{
+		{"single", ModeSingle, false},
+		{"continuous", ModeContinuous, false},
+		{"", "", true},
+		{"Single", "", true},
+		{"auto", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMode(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrUnknownMode) {
+			t.Errorf("ParseMode(%q) error %v is not ErrUnknownMode", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package session 提供输入会话：持有输入模式和各设备的输入缓冲，统一处理增量输入、手机端分段请求、模式选择和定时分段
+// Package session provides the input session: it owns the input mode and each device's input buffer and handles
+// incremental input, segment requests from phones, mode selection and timed segmentation in one place
+package session
+
+import (
+	"sync"
+
+	"airinputlan/internal/network"
+	"airinputlan/internal/state"
+)
+
+// Segment 表示一次分段的结果
+// Segment is the result of one segmentation
+type Segment struct {
+	Key    string           // 输入来源标识 / Input source key
+	Source state.CardSource // 输入来源（含触发原因） / Input source (with the cause)
+	Cards  []state.Card     // 生成的卡片，内容无意义或过滤后为空时为空 / Cards created; empty when the content was meaningless or filtered away
+}
+
+// Session 表示输入会话，所有状态转换都在锁内按事件进行
+// Session is the input session; every state transition happens under its lock, driven by an event
+type Session struct {
+	mu      sync.Mutex
+	mode    Mode
+	content *state.ContentState
+}
+
+// New 创建输入会话，输入缓冲和卡片保存在 content 中
+// New creates an input session whose input buffers and cards live in content
+func New(content *state.ContentState) *Session {
+	return &Session{
+		mode:    DefaultMode,
+		content: content,
+	}
+}
+
+// Mode 返回当前输入模式
+// Mode returns the current input mode
+func (s *Session) Mode() Mode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mode
+}
+
+// Inputs 返回各设备尚未分段的当前输入
+// Inputs returns each device's current input that has not been segmented yet
+func (s *Session) Inputs() []state.Input {
+	return s.content.GetCurrentInputs()
+}
+
+// SelectMode 处理手机端选择输入模式：清空所有设备的输入缓冲后切换模式
+// SelectMode handles a phone selecting the input mode: every device's input buffer is cleared before switching
+func (s *Session) SelectMode(mode Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content.ClearInput()
+	s.fireLocked(selectEvent(mode))
+}
+
+// Input 将增量输入累加到来源设备的输入缓冲，返回该设备的完整输入
+// Input appends incremental input to the source device's buffer and returns that device's full input
+func (s *Session) Input(content string, source state.CardSource) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fireLocked(EventInput)
+	return s.content.UpdateContent(content, source)
+}
+
+// Segment 处理手机端的分段请求：切换到单次输入模式，把内容生成卡片并清空该设备的输入缓冲
+// Segment handles a segment request from a phone: switches to single input, turns the content into cards and clears the device's buffer
+func (s *Session) Segment(content string, source state.CardSource) Segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fireLocked(EventSegment)
+	return s.segmentLocked(content, source)
+}
+
+// Tick 处理分段定时器：连续输入模式下把到达分段间隔的输入生成卡片，单次输入模式下不做任何事
+// Tick handles the segment timer: in continuous input, inputs past the segment interval become cards; in single input it does nothing
+func (s *Session) Tick() []Segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fireLocked(EventTick)
+	if s.mode != ModeContinuous {
+		return nil
+	}
+	var segments []Segment
+	for _, input := range s.content.DueInputs() {
+		if input.Content == "" {
+			continue
+		}
+		source := input.Source
+		source.Cause = state.CauseTimer
+		segments = append(segments, s.segmentLocked(input.Content, source))
+	}
+	return segments
+}
+
+// fireLocked 按状态转换表处理事件，模式变化时记录日志（调用方需持有锁）
+// fireLocked applies the event through the transition table and logs mode changes (the caller holds the lock)
+func (s *Session) fireLocked(event Event) {
+	from := s.mode
+	s.mode = Next(from, event)
+	if s.mode != from {
+		network.LogFormat("处理", "系统", "服务端", "切换到%s（事件: %s）", s.mode.Description(), event)
+	}
+}
+
+// segmentLocked 把内容生成卡片；内容无意义时只清空该设备的输入缓冲（调用方需持有锁）
+// segmentLocked turns the content into cards; meaningless content only clears the device's buffer (the caller holds the lock)
+func (s *Session) segmentLocked(content string, source state.CardSource) Segment {
+	segment := Segment{Key: source.Key(), Source: source}
+	if !s.content.Accepts(content) {
+		network.LogDebug("过滤无意义内容: %q", content)
+		s.content.ClearDeviceInput(segment.Key)
+		return segment
+	}
+	// AddCard 同时清空该设备的输入缓冲 / AddCard also clears the device's buffer
+	segment.Cards = s.content.AddCard(content, source)
+	return segment
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"airinputlan/internal/state"
+)
+
+// newTestSession 创建分段间隔很短的会话，便于测试定时分段
+// newTestSession creates a session with a tiny segment interval so timed segmentation can be tested
+func newTestSession() (*Session, *state.ContentState) {
+	content := state.NewContentState(time.Millisecond, 50, 1000)
+	return New(content), content
+}
+
+var phone = state.CardSource{DeviceID: "phone-1", DeviceName: "Phone", Cause: state.CauseMobile}
+
+func TestNewStartsInDefaultMode(t *testing.T) {
+	s, _ := newTestSession()
+	if got := s.Mode(); got != DefaultMode {
+		t.Fatalf("Mode() = %q, want %q", got, DefaultMode)
+	}
+}
+
+func TestSelectModeClearsInput(t *testing.T) {
+	s, _ := newTestSession()
+	s.Input("你好", phone)
+
+	s.SelectMode(ModeContinuous)
+	if got := s.Mode(); got != ModeContinuous {
+		t.Fatalf("Mode() = %q, want %q", got, ModeContinuous)
+	}
+	if inputs := s.Inputs(); len(inputs) != 0 {
+		t.Fatalf("Inputs() = %v, want none after selecting a mode", inputs)
+	}
+
+	s.SelectMode(ModeSingle)
+	if got := s.Mode(); got != ModeSingle {
+		t.Fatalf("Mode() = %q, want %q", got, ModeSingle)
+	}
+}
+
+func TestInputAccumulates(t *testing.T) {
+	s, _ := newTestSession()
+	s.Input("你好", phone)
+	if got := s.Input("世界", phone); got != "你好世界" {
+		t.Fatalf("Input() = %q, want %q", got, "你好世界")
+	}
+	inputs := s.Inputs()
+	if len(inputs) != 1 || inputs[0].Key != phone.Key() || inputs[0].Content != "你好世界" {
+		t.Fatalf("Inputs() = %+v, want one input %q for %q", inputs, "你好世界", phone.Key())
+	}
+	if got := s.Mode(); got != ModeSingle {
+		t.Fatalf("Mode() = %q, input must not change the mode", got)
+	}
+}
+
+func TestSegment(t *testing.T) {
+	s, content := newTestSession()
+	s.SelectMode(ModeContinuous)
+	s.Input("今天开会", phone)
+
+	segment := s.Segment("今天开会", phone)
+	if got := s.Mode(); got != ModeSingle {
+		t.Errorf("Mode() = %q, a segment request must switch to %q", got, ModeSingle)
+	}
+	if segment.Key != phone.Key() || len(segment.Cards) != 1 || segment.Cards[0].Text != "今天开会" {
+		t.Fatalf("Segment() = %+v, want one card %q", segment, "今天开会")
+	}
+	if inputs := s.Inputs(); len(inputs) != 0 {
+		t.Errorf("Inputs() = %v, want the buffer cleared", inputs)
+	}
+	if cards := content.GetHistoryCards(); len(cards) != 1 {
+		t.Errorf("history has %d cards, want 1", len(cards))
+	}
+}
+
+func TestSegmentEmptyBuffer(t *testing.T) {
+	s, content := newTestSession()
+	for _, text := range []string{"", "   ", "。"} {
+		segment := s.Segment(text, phone)
+		if len(segment.Cards) != 0 {
+			t.Errorf("Segment(%q) created cards %+v, want none", text, segment.Cards)
+		}
+	}
+	if cards := content.GetHistoryCards(); len(cards) != 0 {
+		t.Errorf("history has %d cards, want none", len(cards))
+	}
+}
+
+func TestTickSingleModeDoesNothing(t *testing.T) {
+	s, content := newTestSession()
+	s.Input("还在说", phone)
+	time.Sleep(5 * time.Millisecond)
+
+	if segments := s.Tick(); segments != nil {
+		t.Fatalf("Tick() = %+v in single mode, want nil", segments)
+	}
+	if inputs := s.Inputs(); len(inputs) != 1 {
+		t.Errorf("Inputs() = %v, the buffer must be kept in single mode", inputs)
+	}
+	if cards := content.GetHistoryCards(); len(cards) != 0 {
+		t.Errorf("history has %d cards, want none", len(cards))
+	}
+}
+
+func TestTickContinuousMode(t *testing.T) {
+	s, _ := newTestSession()
+	s.SelectMode(ModeContinuous)
+	s.Input("第一句话", phone)
+	time.Sleep(5 * time.Millisecond)
+
+	segments := s.Tick()
+	if len(segments) != 1 {
+		t.Fatalf("Tick() = %+v, want one segment", segments)
+	}
+	segment := segments[0]
+	if segment.Source.Cause != state.CauseTimer {
+		t.Errorf("segment cause = %q, want %q", segment.Source.Cause, state.CauseTimer)
+	}
+	if len(segment.Cards) != 1 || segment.Cards[0].Text != "第一句话" {
+		t.Errorf("segment cards = %+v, want one card %q", segment.Cards, "第一句话")
+	}
+	if got := s.Mode(); got != ModeContinuous {
+		t.Errorf("Mode() = %q, ticks must keep the mode", got)
+	}
+	if segments := s.Tick(); len(segments) != 0 {
+		t.Errorf("second Tick() = %+v, want nothing left to segment", segments)
+	}
+}
@@ -0,0 +1,57 @@
+// Package state 定义结构化的历史卡片
+// Package state defines structured history cards
+package state
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"airinputlan/internal/card"
+)
+
+// SegmentCause 表示卡片的分段原因（定义在 card 包中，便于 network 引用）
+// SegmentCause represents why a card was segmented (defined in package card so network can use it)
+type SegmentCause = card.SegmentCause
+
+const (
+	CauseMobile = card.CauseMobile // 手机端触发分段（单次输入模式） / Triggered by mobile (single input mode)
+	CauseTimer  = card.CauseTimer  // 服务端定时器分段（连续输入模式） / Triggered by server timer (continuous input mode)
+	CauseManual = card.CauseManual // 通过 REST API 手动创建 / Created manually via the REST API
+)
+
+// Card 表示一张历史卡片（定义在 card 包中，便于 network 引用）
+// Card represents a history card (defined in package card so network can use it)
+type Card = card.Card
+
+// CardSource 描述卡片的来源信息
+// CardSource describes where a card came from
+type CardSource struct {
+	ClientID   string
+	IP         string
+	DeviceID   string
+	DeviceName string
+	Cause      SegmentCause
+}
+
+// Key 返回区分输入来源的标识：优先使用设备 ID，其次连接 ID，最后 IP
+// Key returns the key that tells input sources apart: the device ID first, then the client ID, then the IP
+func (s CardSource) Key() string {
+	switch {
+	case s.DeviceID != "":
+		return s.DeviceID
+	case s.ClientID != "":
+		return s.ClientID
+	}
+	return s.IP
+}
+
+// cardSeq 用于保证同一时刻生成的卡片 ID 唯一
+// cardSeq keeps card IDs unique when generated at the same instant
+var cardSeq atomic.Uint64
+
+// newCardID 生成按时间排序的卡片 ID
+// newCardID generates a time-ordered card ID
+func newCardID(t time.Time) string {
+	return fmt.Sprintf("card_%x_%x", t.UnixNano(), cardSeq.Add(1))
+}
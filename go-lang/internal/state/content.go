@@ -3,155 +3,452 @@
 package state
 
 import (
+	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
 
+	"airinputlan/internal/dictionary"
 	"airinputlan/internal/network"
+	"airinputlan/internal/textfilter"
 )
 
+// ErrCardNotFound 表示指定 ID 的卡片不存在
+// ErrCardNotFound indicates no card exists with the given ID
+var ErrCardNotFound = errors.New("卡片不存在")
+
+// ErrEmptyCard 表示卡片内容为空或无意义
+// ErrEmptyCard indicates the card content is empty or meaningless
+var ErrEmptyCard = errors.New("卡片内容为空")
+
 // ContentState 表示内容状态管理器
 // ContentState represents the content state manager
 type ContentState struct {
-	mu               sync.RWMutex
-	currentContent   string
-	lastInputTime    time.Time
-	historyCards     []string
-	segmentInterval  time.Duration
-	maxCardCount     int
-	maxCardLength    int
+	mu              sync.RWMutex
+	inputs          map[string]*deviceInput // 各设备的当前输入，键为 CardSource.Key() / Current input per device, keyed by CardSource.Key()
+	historyCards    []Card
+	segmentInterval time.Duration
+	maxCardCount    int
+	maxCardLength   int
+	splitMode       SplitMode // 超长卡片的分割方式
+	store           CardStore // 持久化存储（可选）
+	appendedCount   int       // 上次压缩后追加的卡片数
+	filter          FilterOptions
+	dictionary      *dictionary.Dictionary // 用户词典（可选），在过滤器之前执行
+	dictionaryLive  bool                   // 实时输入也应用用户词典
+}
+
+// deviceInput 表示一个设备正在输入的内容
+// deviceInput is the content one device is currently typing
+type deviceInput struct {
+	content       string
+	lastInputTime time.Time
+	source        CardSource
+}
+
+// Input 表示某个设备当前正在输入的内容（只读副本）
+// Input is the content a device is currently typing (a read-only copy)
+type Input struct {
+	Key       string     // 来源标识 / Source key
+	Content   string     // 完整的当前输入 / Full current input
+	Source    CardSource // 最近一次输入的来源 / Source of the latest input
+	UpdatedAt time.Time  // 最近一次输入的时间 / Time of the latest input
+}
+
+// FilterOptions 表示内容过滤选项
+// FilterOptions represents the content filtering options
+type FilterOptions struct {
+	Pipeline        *textfilter.Pipeline // 生成卡片前执行的过滤器 / Filters run before cards are created
+	DropMeaningless bool                 // 丢弃单独的标点等无意义内容 / Drop meaningless content such as a lone punctuation mark
 }
 
 // NewContentState 创建并返回一个新的内容状态管理器
 // NewContentState creates and returns a new content state manager
 func NewContentState(segmentInterval time.Duration, maxCardCount, maxCardLength int) *ContentState {
 	return &ContentState{
-		currentContent: "",
-		lastInputTime:   time.Now(),
-		historyCards:    make([]string, 0),
+		inputs:          make(map[string]*deviceInput),
+		historyCards:    make([]Card, 0),
 		segmentInterval: segmentInterval,
 		maxCardCount:    maxCardCount,
 		maxCardLength:   maxCardLength,
+		splitMode:       SplitSentence,
+		filter:          FilterOptions{Pipeline: defaultPipeline(), DropMeaningless: true},
+	}
+}
+
+// SetSegmentInterval 修改连续输入模式的分段间隔（立即生效）
+// SetSegmentInterval changes the segmentation interval of continuous mode (takes effect immediately)
+func (cs *ContentState) SetSegmentInterval(interval time.Duration) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.segmentInterval = interval
+}
+
+// SetMaxCardCount 修改最大卡片数量；变小时立即删除最旧的卡片
+// SetMaxCardCount changes the maximum card count; shrinking it drops the oldest cards right away
+func (cs *ContentState) SetMaxCardCount(count int) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.maxCardCount = count
+	if len(cs.historyCards) > count {
+		cs.historyCards = cs.historyCards[len(cs.historyCards)-count:]
+		cs.rewriteStoreLocked()
 	}
 }
 
-// UpdateContent 将新内容追加到当前输入内容中
-// UpdateContent appends new content to the current input content
-func (cs *ContentState) UpdateContent(content string) {
+// SetMaxCardLength 修改最大卡片长度（只影响之后生成的卡片）
+// SetMaxCardLength changes the maximum card length (only affects cards created afterwards)
+func (cs *ContentState) SetMaxCardLength(length int) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
+	cs.maxCardLength = length
+}
+
+// SetSplitMode 修改超长卡片的分割方式（只影响之后生成的卡片）
+// SetSplitMode changes how over-long cards are split (only affects cards created afterwards)
+func (cs *ContentState) SetSplitMode(mode SplitMode) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.splitMode = mode
+}
+
+// SetFilter 修改内容过滤选项
+// SetFilter changes the content filtering options
+func (cs *ContentState) SetFilter(filter FilterOptions) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.filter = filter
+}
+
+// SetDictionary 修改用户词典（只影响之后生成的卡片和实时输入），nil 表示不替换
+// SetDictionary changes the user dictionary (only affects later cards and live input); nil means no replacement
+func (cs *ContentState) SetDictionary(d *dictionary.Dictionary) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.dictionary = d
+}
+
+// SetDictionaryLive 设置实时输入是否也应用用户词典
+// SetDictionaryLive sets whether the user dictionary is also applied to live input
+func (cs *ContentState) SetDictionaryLive(live bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.dictionaryLive = live
+}
+
+// Accepts 按当前过滤选项判断内容是否应生成卡片
+// 关闭无意义内容过滤时仍会丢弃纯空白内容
+// Accepts reports whether the content should become a card under the current filtering options
+// Whitespace-only content is still dropped when meaningless-content filtering is off
+func (cs *ContentState) Accepts(content string) bool {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.acceptsLocked(content)
+}
+
+// acceptsLocked 是 Accepts 的内部实现（调用方需持有锁）
+// acceptsLocked implements Accepts (caller must hold the lock)
+func (cs *ContentState) acceptsLocked(content string) bool {
+	if cs.filter.DropMeaningless {
+		return IsContentMeaningful(content)
+	}
+	return strings.TrimSpace(content) != ""
+}
+
+// SetStore 设置持久化存储，并加载其中已保存的卡片（仅保留最近 maxCardCount 张）
+// SetStore sets the persistent store and loads the cards saved in it (keeping only the latest maxCardCount)
+func (cs *ContentState) SetStore(store CardStore) error {
+	cards, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	loaded := len(cards)
+	if len(cards) > cs.maxCardCount {
+		cards = cards[len(cards)-cs.maxCardCount:]
+	}
+	cs.historyCards = cards
+	cs.store = store
+	cs.appendedCount = 0
+
+	// 启动时压缩一次，丢弃超出数量或保留时长的旧卡片 / Compact once at startup, dropping cards beyond the count or retention
+	if err := store.Rewrite(cs.historyCards); err != nil {
+		network.LogInfo("压缩历史记录失败: %v", err)
+	}
+
+	network.LogInfo("已加载 %d 张历史卡片（共读取 %d 张）", len(cs.historyCards), loaded)
+	return nil
+}
+
+// UpdateContent 将新内容追加到来源设备的当前输入中，返回该设备的完整输入
+// UpdateContent appends new content to the source device's current input and returns that device's full input
+func (cs *ContentState) UpdateContent(content string, source CardSource) string {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	key := source.Key()
+	input, ok := cs.inputs[key]
+	if !ok {
+		input = &deviceInput{}
+		cs.inputs[key] = input
+	}
 
 	// 累加内容（增量发送）
-	cs.currentContent += content
-	cs.lastInputTime = time.Now()
+	input.content += content
+	input.lastInputTime = time.Now()
+	input.source = source
+
+	// 清理开头的标点符号（其余过滤器在生成卡片时执行） / Clean leading punctuation (the other filters run when cards are created)
+	if cs.filter.Pipeline.Has(textfilter.TrimLeadingPunct) {
+		input.content = textfilter.TrimLeading(input.content)
+	}
+	// 只替换返回的副本，缓冲保持原文，生成卡片时才不会重复替换 / Only the returned copy is replaced; the buffer keeps the original so cards are not replaced twice
+	if cs.dictionaryLive {
+		return cs.dictionary.Replace(input.content)
+	}
+	return input.content
+}
+
+// GetCurrentContent 返回指定设备正在输入的完整内容
+// GetCurrentContent returns the full input the given device is typing
+func (cs *ContentState) GetCurrentContent(key string) string {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	if input, ok := cs.inputs[key]; ok {
+		return input.content
+	}
+	return ""
+}
 
-	// 清理开头的标点符号 / Clean leading punctuation
-	cs.currentContent = CleanLeadingPunctuation(cs.currentContent)
+// GetCurrentInputs 返回所有设备非空的当前输入（按最近输入时间排序）
+// GetCurrentInputs returns every device's non-empty current input (ordered by latest input time)
+func (cs *ContentState) GetCurrentInputs() []Input {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.inputsLocked(func(*deviceInput) bool { return true })
 }
 
-// GetCurrentContent 返回当前正在输入的完整内容
-// GetCurrentContent returns the current input content
-func (cs *ContentState) GetCurrentContent() string {
+// DueInputs 返回已超过分段间隔未再输入、应自动分段的设备输入
+// DueInputs returns the device inputs idle for longer than the segmentation interval, which should be auto-segmented
+func (cs *ContentState) DueInputs() []Input {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
-	return cs.currentContent
+	return cs.inputsLocked(func(input *deviceInput) bool {
+		return time.Since(input.lastInputTime) > cs.segmentInterval
+	})
+}
+
+// inputsLocked 返回满足条件的非空输入（调用方需持有锁）
+// inputsLocked returns the non-empty inputs matching the predicate (caller must hold the lock)
+func (cs *ContentState) inputsLocked(match func(*deviceInput) bool) []Input {
+	var inputs []Input
+	for key, input := range cs.inputs {
+		if input.content == "" || !match(input) {
+			continue
+		}
+		inputs = append(inputs, Input{Key: key, Content: input.content, Source: input.source, UpdatedAt: input.lastInputTime})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].UpdatedAt.Before(inputs[j].UpdatedAt) })
+	return inputs
 }
 
 // GetHistoryCards 返回所有历史卡片的副本
 // GetHistoryCards returns a copy of all history cards
-func (cs *ContentState) GetHistoryCards() []string {
+func (cs *ContentState) GetHistoryCards() []Card {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
 
 	// 返回副本
-	cards := make([]string, len(cs.historyCards))
+	cards := make([]Card, len(cs.historyCards))
 	copy(cards, cs.historyCards)
 	return cards
 }
 
 // AddCard 将内容添加到历史卡片列表中
 // AddCard adds content to the history card list
-// 返回过滤后的内容 / Returns filtered content
-func (cs *ContentState) AddCard(content string) string {
+// 返回新生成的卡片（内容超长时会分割为多张），内容被过滤时返回空 / Returns the new cards (split into several when too long); empty if the content was filtered
+func (cs *ContentState) AddCard(content string, source CardSource) []Card {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	newCards := cs.appendCardsLocked(content, source)
+
+	// 清空该设备的当前内容（无论是否生成卡片，避免重复触发分段）
+	delete(cs.inputs, source.Key())
+
+	return newCards
+}
+
+// CreateCard 直接创建卡片（不影响当前输入内容），用于 REST API
+// CreateCard creates cards directly (without touching the current input), used by the REST API
+func (cs *ContentState) CreateCard(content string, source CardSource) ([]Card, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	newCards := cs.appendCardsLocked(content, source)
+	if len(newCards) == 0 {
+		return nil, ErrEmptyCard
+	}
+	return newCards, nil
+}
+
+// GetCard 按 ID 返回卡片
+// GetCard returns the card with the given ID
+func (cs *ContentState) GetCard(id string) (Card, error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	index := cs.indexOfLocked(id)
+	if index < 0 {
+		return Card{}, ErrCardNotFound
+	}
+	return cs.historyCards[index], nil
+}
+
+// UpdateCard 修改卡片文本并更新修改时间
+// UpdateCard changes the card text and bumps its update time
+func (cs *ContentState) UpdateCard(id, text string) (Card, error) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	// 清理开头的标点符号 / Clean leading punctuation
-	content = CleanLeadingPunctuation(content)
+	if !cs.acceptsLocked(text) {
+		return Card{}, ErrEmptyCard
+	}
+	index := cs.indexOfLocked(id)
+	if index < 0 {
+		return Card{}, ErrCardNotFound
+	}
+
+	card := &cs.historyCards[index]
+	card.Text = text
+	card.UpdatedAt = time.Now()
+	cs.rewriteStoreLocked()
+	return *card, nil
+}
+
+// DeleteCard 删除指定 ID 的卡片
+// DeleteCard deletes the card with the given ID
+func (cs *ContentState) DeleteCard(id string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	index := cs.indexOfLocked(id)
+	if index < 0 {
+		return ErrCardNotFound
+	}
+	cs.historyCards = append(cs.historyCards[:index], cs.historyCards[index+1:]...)
+	cs.rewriteStoreLocked()
+	return nil
+}
+
+// indexOfLocked 返回卡片在历史列表中的位置，不存在时返回 -1（调用方需持有锁）
+// indexOfLocked returns the card's position in the history, or -1 if absent (caller must hold the lock)
+func (cs *ContentState) indexOfLocked(id string) int {
+	for i, card := range cs.historyCards {
+		if card.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendCardsLocked 过滤、分割内容并追加为新卡片（调用方需持有锁）
+// appendCardsLocked filters and splits content and appends it as new cards (caller must hold the lock)
+func (cs *ContentState) appendCardsLocked(content string, source CardSource) []Card {
+	rawContent := content
+
+	// 按用户词典替换，再按过滤方案规范化文本 / Apply the user dictionary, then normalize the text with the filter profile
+	content = cs.dictionary.Replace(content)
+	content = strings.TrimSpace(cs.filter.Pipeline.Apply(content))
+	if content != strings.TrimSpace(rawContent) && content != "" {
+		network.LogFormat("过滤", "内容", "服务端", "文本已规范化: %s", content)
+	}
 
 	// 过滤无意义内容 / Filter meaningless content
-	if !IsContentMeaningful(content) {
-		// 清空当前内容，避免重复触发分段
-		cs.currentContent = ""
-		return ""
+	if !cs.acceptsLocked(content) {
+		return nil
 	}
 
 	// 检查是否需要分段（按字符数计算）
+	segments := []string{content}
 	if utf8.RuneCountInString(content) > cs.maxCardLength {
-		segments := cs.splitContent(content, cs.maxCardLength)
-		cs.historyCards = append(cs.historyCards, segments...)
-	} else {
-		cs.historyCards = append(cs.historyCards, content)
+		segments = splitContent(content, cs.maxCardLength, cs.splitMode)
+	}
+	now := time.Now()
+	newCards := make([]Card, len(segments))
+	for i, segment := range segments {
+		newCards[i] = Card{
+			ID:             newCardID(now),
+			Text:           segment,
+			RawText:        rawContent,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			SourceClientID: source.ClientID,
+			SourceIP:       source.IP,
+			DeviceID:       source.DeviceID,
+			DeviceName:     source.DeviceName,
+			Cause:          source.Cause,
+		}
 	}
+	cs.historyCards = append(cs.historyCards, newCards...)
 
 	// 限制卡片数量
 	if len(cs.historyCards) > cs.maxCardCount {
 		cs.historyCards = cs.historyCards[len(cs.historyCards)-cs.maxCardCount:]
 	}
 
-	// 清空当前内容
-	cs.currentContent = ""
+	cs.persistLocked(newCards)
 
-	return content
+	return newCards
 }
 
-// splitContent 将内容按指定最大长度分割成多个片段
-// splitContent splits content into multiple segments by specified maximum length
-func (cs *ContentState) splitContent(content string, maxLength int) []string {
-	var segments []string
-	// 按字符数（rune count）计算长度，而不是字节长度
-	runes := []rune(content)
-	for len(runes) > maxLength {
-		segments = append(segments, string(runes[:maxLength]))
-		runes = runes[maxLength:]
+// persistLocked 将新卡片写入持久化存储，追加过多时压缩（调用方需持有锁）
+// persistLocked writes new cards to the persistent store and compacts when too many were appended (caller must hold the lock)
+func (cs *ContentState) persistLocked(cards []Card) {
+	if cs.store == nil {
+		return
 	}
-	if len(runes) > 0 {
-		segments = append(segments, string(runes))
+	if err := cs.store.Append(cards...); err != nil {
+		network.LogInfo("保存历史卡片失败: %v", err)
+		return
 	}
-	return segments
-}
-
-// CleanLeadingPunctuation 清理开头的中文标点符号（。！？，）
-// CleanLeadingPunctuation removes leading Chinese punctuation marks (。！？，)
-func CleanLeadingPunctuation(content string) string {
-	// 去除开头的空白字符 / Remove leading whitespace
-	content = strings.TrimSpace(content)
+	cs.appendedCount += len(cards)
 
-	// 去除开头的 。！？，
-	runes := []rune(content)
-	originalLen := len(runes)
-
-	for len(runes) > 0 {
-		firstChar := runes[0]
-		if firstChar == '。' || firstChar == '！' || firstChar == '？' || firstChar == '，' {
-			runes = runes[1:]
-			// 去除空白字符 / Remove whitespace
-			for len(runes) > 0 && unicode.IsSpace(runes[0]) {
-				runes = runes[1:]
-			}
-		} else {
-			break
+	// 日志只追加不删除，超过一倍数量上限时按内存中的卡片重写 / The log only grows; rewrite from memory once it doubles the limit
+	if cs.appendedCount >= cs.maxCardCount {
+		if err := cs.store.Rewrite(cs.historyCards); err != nil {
+			network.LogInfo("压缩历史记录失败: %v", err)
+			return
 		}
+		cs.appendedCount = 0
 	}
+}
 
-	// 如果确实过滤了标点符号，输出日志
-	if len(runes) != originalLen && len(runes) > 0 {
-		network.LogFormat("过滤", "内容", "服务端", "开头标点已过滤: %s", string(runes))
+// rewriteStoreLocked 修改或删除卡片后按内存内容重写存储（调用方需持有锁）
+// rewriteStoreLocked rewrites the store from memory after a card is edited or deleted (caller must hold the lock)
+func (cs *ContentState) rewriteStoreLocked() {
+	if cs.store == nil {
+		return
 	}
+	if err := cs.store.Rewrite(cs.historyCards); err != nil {
+		network.LogInfo("保存历史卡片失败: %v", err)
+		return
+	}
+	cs.appendedCount = 0
+}
 
-	return string(runes)
+// defaultPipeline 返回默认过滤方案的管线
+// defaultPipeline returns the pipeline of the default filter profile
+func defaultPipeline() *textfilter.Pipeline {
+	names, _ := textfilter.Profile(textfilter.DefaultProfile, nil)
+	pipeline, _ := textfilter.New(names)
+	return pipeline
 }
 
 // IsContentMeaningful 检查内容是否有意义
@@ -193,26 +490,44 @@ func IsContentMeaningful(content string) bool {
 	return true
 }
 
-// ShouldSegment 判断当前内容是否应该触发自动分段
-// ShouldSegment determines if the current content should trigger automatic segmentation
-func (cs *ContentState) ShouldSegment() bool {
-	cs.mu.RLock()
-	defer cs.mu.RUnlock()
+// ClearInput 清空所有设备的当前输入内容，保留历史卡片
+// ClearInput clears every device's current input content, keeping history cards
+func (cs *ContentState) ClearInput() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
 
-	if cs.currentContent == "" {
-		return false
-	}
+	cs.inputs = make(map[string]*deviceInput)
+}
+
+// ClearDeviceInput 仅清空指定设备的当前输入内容
+// ClearDeviceInput clears only the given device's current input content
+func (cs *ContentState) ClearDeviceInput(key string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
 
-	return time.Since(cs.lastInputTime) > cs.segmentInterval
+	delete(cs.inputs, key)
 }
 
-// Clear 清空所有内容
-// Clear clears all content
+// Clear 清空内存中的所有内容（不影响持久化存储）
+// Clear clears all in-memory content (the persistent store is not affected)
 func (cs *ContentState) Clear() {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	cs.currentContent = ""
-	cs.historyCards = make([]string, 0)
-	cs.lastInputTime = time.Now()
+	cs.inputs = make(map[string]*deviceInput)
+	cs.historyCards = make([]Card, 0)
+}
+
+// Close 关闭持久化存储
+// Close closes the persistent store
+func (cs *ContentState) Close() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.store == nil {
+		return nil
+	}
+	err := cs.store.Close()
+	cs.store = nil
+	return err
 }
\ No newline at end of file
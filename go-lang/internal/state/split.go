@@ -0,0 +1,108 @@
+// Package state 提供超长卡片的分割：按句子分割时优先在句末、分句、空白处断开，找不到时才按最大长度截断
+// Package state provides splitting of over-long cards: sentence splitting prefers sentence ends, then clauses,
+// then whitespace, and cuts at the maximum length only as a last resort
+package state
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitMode 表示超长卡片的分割方式
+// SplitMode is how over-long cards are split
+type SplitMode string
+
+const (
+	SplitSentence SplitMode = "sentence" // 优先在句子、分句、空白处分割 / Prefer sentence, clause and whitespace boundaries
+	SplitFixed    SplitMode = "fixed"    // 按最大长度截断 / Cut at exactly the maximum length
+)
+
+const (
+	sentenceBreaks = "。！？.!?\n"  // 句末标点和换行 / Sentence-ending punctuation and newlines
+	clauseBreaks   = "，,；;"      // 分句标点 / Clause punctuation
+	closingMarks   = "”’」』）)\"'" // 紧跟在句末标点后的引号和括号 / Quotes and brackets that follow the ending punctuation
+)
+
+// splitContent 将内容分割成不超过 maxLength 个字符的片段
+// splitContent splits content into segments of at most maxLength characters
+func splitContent(content string, maxLength int, mode SplitMode) []string {
+	if mode == SplitSentence {
+		return splitSentences(content, maxLength)
+	}
+	return splitFixed(content, maxLength)
+}
+
+// splitFixed 按字符数（而不是字节数）截断内容
+// splitFixed cuts the content by character count (not byte length)
+func splitFixed(content string, maxLength int) []string {
+	var segments []string
+	runes := []rune(content)
+	for len(runes) > maxLength {
+		segments = append(segments, string(runes[:maxLength]))
+		runes = runes[maxLength:]
+	}
+	if len(runes) > 0 {
+		segments = append(segments, string(runes))
+	}
+	return segments
+}
+
+// splitSentences 依次在句末、分句、空白处分割内容，都找不到时按最大长度截断；片段两端的空白会被去掉
+// splitSentences splits the content at sentence ends, then clauses, then whitespace, cutting at the maximum length
+// when none is found; whitespace around each segment is trimmed
+func splitSentences(content string, maxLength int) []string {
+	var segments []string
+	runes := []rune(strings.TrimSpace(content))
+	for len(runes) > maxLength {
+		cut := sentenceCut(runes, maxLength)
+		if segment := strings.TrimSpace(string(runes[:cut])); segment != "" {
+			segments = append(segments, segment)
+		}
+		runes = runes[cut:]
+		for len(runes) > 0 && unicode.IsSpace(runes[0]) {
+			runes = runes[1:]
+		}
+	}
+	if len(runes) > 0 {
+		segments = append(segments, string(runes))
+	}
+	return segments
+}
+
+// sentenceCut 返回前 maxLength 个字符内最合适的分割位置（调用方保证 len(runes) > maxLength）
+// sentenceCut returns the best cut position within the first maxLength characters (the caller ensures len(runes) > maxLength)
+func sentenceCut(runes []rune, maxLength int) int {
+	for _, breaks := range []string{sentenceBreaks, clauseBreaks} {
+		for i := maxLength - 1; i > 0; i-- {
+			if isBreak(runes, i, breaks) {
+				cut := i + 1
+				for cut < maxLength && strings.ContainsRune(closingMarks, runes[cut]) {
+					cut++
+				}
+				return cut
+			}
+		}
+	}
+	// 第 maxLength 个字符之后就是空白时，整段都是完整的词 / A space right after the window means the whole window is complete words
+	for i := maxLength; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return maxLength
+}
+
+// isBreak 判断 runes[i] 是否为 breaks 中的分割标点；英文标点后紧跟字母或数字时（如 3.14、a,b）不算
+// isBreak reports whether runes[i] is a break in breaks; ASCII punctuation directly followed by a letter or digit (3.14, a,b) does not count
+func isBreak(runes []rune, i int, breaks string) bool {
+	r := runes[i]
+	if !strings.ContainsRune(breaks, r) {
+		return false
+	}
+	if r < utf8.RuneSelf && r != '\n' && i+1 < len(runes) {
+		next := runes[i+1]
+		return !(next < utf8.RuneSelf && (unicode.IsLetter(next) || unicode.IsDigit(next)))
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package state
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		max     int
+		want    []string
+	}{
+		{
+			name:    "chinese sentence ends",
+			content: "今天天气很好。我们去公园吧！好不好？",
+			max:     8,
+			want:    []string{"今天天气很好。", "我们去公园吧！", "好不好？"},
+		},
+		{
+			name:    "ascii sentence ends followed by a space",
+			content: "Go is fast. Rust is safe. Both are fine",
+			max:     16,
+			want:    []string{"Go is fast.", "Rust is safe.", "Both are fine"},
+		},
+		{
+			name:    "mixed chinese and english dictation",
+			content: "我用 Go 写了一个服务。It works well! 下一步是部署",
+			max:     15,
+			want:    []string{"我用 Go 写了一个服务。", "It works well!", "下一步是部署"},
+		},
+		{
+			name:    "closing quote stays with its sentence",
+			content: "他说：“好的。”然后走了",
+			max:     8,
+			want:    []string{"他说：“好的。”", "然后走了"},
+		},
+		{
+			name:    "chinese clause fallback",
+			content: "我们今天讨论了预算，然后确定了时间表",
+			max:     12,
+			want:    []string{"我们今天讨论了预算，", "然后确定了时间表"},
+		},
+		{
+			name:    "ascii clause fallback",
+			content: "first item, second item; third item",
+			max:     14,
+			want:    []string{"first item,", "second item;", "third item"},
+		},
+		{
+			name:    "full-width semicolon",
+			content: "第一步准备材料；第二步开始施工",
+			max:     10,
+			want:    []string{"第一步准备材料；", "第二步开始施工"},
+		},
+		{
+			name:    "whitespace fallback keeps decimals together",
+			content: "pi is 3.14159 and e is 2.71828",
+			max:     12,
+			want:    []string{"pi is", "3.14159 and", "e is 2.71828"},
+		},
+		{
+			name:    "hard cut on a latin run",
+			content: "abcdefghijklmnopqrstuvwxyz",
+			max:     10,
+			want:    []string{"abcdefghij", "klmnopqrst", "uvwxyz"},
+		},
+		{
+			name:    "hard cut on a chinese run",
+			content: "一二三四五六七八九十",
+			max:     4,
+			want:    []string{"一二三四", "五六七八", "九十"},
+		},
+		{
+			name:    "short content is kept",
+			content: "  你好，世界  ",
+			max:     10,
+			want:    []string{"你好，世界"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitSentences(tt.content, tt.max)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences(%q, %d) = %q, want %q", tt.content, tt.max, got, tt.want)
+			}
+			for _, segment := range got {
+				if n := len([]rune(segment)); n > tt.max {
+					t.Errorf("segment %q has %d characters, more than %d", segment, n, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsBreak(t *testing.T) {
+	tests := []struct {
+		text string
+		i    int
+		want bool
+	}{
+		{"好。下", 1, true},
+		{"end. Next", 3, true},
+		{"end.", 3, true},
+		{"3.14", 1, false},
+		{"a,b", 1, false},
+		{"a, b", 1, true},
+		{"行\n行", 1, true},
+		{"abc", 1, false},
+	}
+	for _, tt := range tests {
+		breaks := sentenceBreaks + clauseBreaks
+		if got := isBreak([]rune(tt.text), tt.i, breaks); got != tt.want {
+			t.Errorf("isBreak(%q, %d) = %v, want %v", tt.text, tt.i, got, tt.want)
+		}
+	}
+}
+
+// oldSplitContent 是加入按句子分割之前的实现，用于确认 fixed 方式保持原有行为
+// oldSplitContent is the implementation from before sentence splitting, to confirm fixed mode keeps the old behavior
+func oldSplitContent(content string, maxLength int) []string {
+	var segments []string
+	runes := []rune(content)
+	for len(runes) > maxLength {
+		segments = append(segments, string(runes[:maxLength]))
+		runes = runes[maxLength:]
+	}
+	if len(runes) > 0 {
+		segments = append(segments, string(runes))
+	}
+	return segments
+}
+
+func TestSplitFixedMatchesOldBehavior(t *testing.T) {
+	inputs := []string{
+		"今天天气很好。我们去公园吧！好不好？",
+		"Go is fast. Rust is safe. Both are fine",
+		"  leading and trailing spaces  ",
+		"一二三四五六七八九十",
+		"短",
+		strings.Repeat("混合 mixed 文本，", 20),
+	}
+	for _, content := range inputs {
+		for _, max := range []int{1, 3, 7, 16, 100} {
+			got := splitContent(content, max, SplitFixed)
+			want := oldSplitContent(content, max)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("splitContent(%q, %d, fixed) = %q, want %q", content, max, got, want)
+			}
+		}
+	}
+	if got, want := splitFixed("一二三四五", 2), []string{"一二", "三四", "五"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitFixed = %q, want %q", got, want)
+	}
+}
+
+func TestAddCardUsesSplitMode(t *testing.T) {
+	content := "今天天气很好。我们去公园吧！"
+	source := CardSource{IP: "192.168.1.20", Cause: CauseMobile}
+
+	tests := []struct {
+		mode SplitMode
+		want []string
+	}{
+		{SplitSentence, []string{"今天天气很好。", "我们去公园吧！"}},
+		{SplitFixed, []string{"今天天气很好。我", "们去公园吧！"}},
+	}
+	for _, tt := range tests {
+		cs := NewContentState(time.Second, 50, 8)
+		cs.SetSplitMode(tt.mode)
+		var got []string
+		for _, card := range cs.AddCard(content, source) {
+			got = append(got, card.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AddCard with %s split = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package state 提供历史卡片的持久化存储（追加写入的 JSONL 日志）
+// Package state provides persistent storage for history cards (an append-only JSONL log)
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"airinputlan/internal/network"
+)
+
+// CardStore 表示历史卡片的持久化存储
+// CardStore represents persistent storage for history cards
+type CardStore interface {
+	// Load 读取已保存的卡片（按时间顺序），已应用保留策略
+	// Load reads saved cards in chronological order, with the retention policy applied
+	Load() ([]Card, error)
+	// Append 追加新卡片
+	// Append appends new cards
+	Append(cards ...Card) error
+	// Rewrite 使用给定卡片整体重写存储（用于压缩）
+	// Rewrite rewrites the whole store with the given cards (used for compaction)
+	Rewrite(cards []Card) error
+	// Close 关闭存储
+	// Close closes the store
+	Close() error
+}
+
+// DefaultHistoryPath 返回默认的历史记录文件路径（用户配置目录下的 airinputlan/history.jsonl）
+// DefaultHistoryPath returns the default history file path (airinputlan/history.jsonl under the user config dir)
+func DefaultHistoryPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户配置目录失败: %w", err)
+	}
+	return filepath.Join(configDir, "airinputlan", "history.jsonl"), nil
+}
+
+// JSONLStore 是基于追加写入 JSONL 文件的 CardStore 实现
+// JSONLStore is a CardStore implementation backed by an append-only JSONL file
+type JSONLStore struct {
+	mu        sync.Mutex
+	path      string
+	file      *os.File
+	retention time.Duration // 保留时长，0 表示永久保留
+}
+
+// NewJSONLStore 打开（必要时创建）指定路径的 JSONL 存储
+// retention 为卡片保留时长，0 表示不按时间清理
+// NewJSONLStore opens (creating if needed) the JSONL store at the given path
+// retention is how long cards are kept; 0 disables time-based cleanup
+func NewJSONLStore(path string, retention time.Duration) (*JSONLStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建历史记录目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开历史记录文件失败: %w", err)
+	}
+	return &JSONLStore{
+		path:      path,
+		file:      file,
+		retention: retention,
+	}, nil
+}
+
+// Load 读取已保存的卡片，跳过损坏的行和超出保留时长的卡片
+// Load reads saved cards, skipping corrupted lines and cards older than the retention period
+func (s *JSONLStore) Load() ([]Card, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %w", err)
+	}
+	defer file.Close()
+
+	var cards []Card
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var card Card
+		if err := json.Unmarshal(line, &card); err != nil {
+			// 崩溃时可能写入了半行，跳过即可 / A crash may leave a partial line; skip it
+			network.LogDebug("跳过损坏的历史记录（第 %d 行）: %v", lineNo, err)
+			continue
+		}
+		if s.expired(card) {
+			continue
+		}
+		// 兼容旧格式（仅有 text 和 createdAt） / Compatible with the old format (text and createdAt only)
+		if card.ID == "" {
+			card.ID = newCardID(card.CreatedAt)
+		}
+		if card.UpdatedAt.IsZero() {
+			card.UpdatedAt = card.CreatedAt
+		}
+		if card.RawText == "" {
+			card.RawText = card.Text
+		}
+		cards = append(cards, card)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %w", err)
+	}
+	return cards, nil
+}
+
+// Append 追加新卡片，每张卡片一行
+// Append appends new cards, one per line
+func (s *JSONLStore) Append(cards ...Card) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("历史记录已关闭")
+	}
+	for _, card := range cards {
+		data, err := json.Marshal(card)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("写入历史记录失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Rewrite 先写入临时文件再原子替换，避免压缩过程中崩溃导致数据丢失
+// Rewrite writes a temporary file and atomically replaces the log so a crash during compaction loses nothing
+func (s *JSONLStore) Rewrite(cards []Card) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("历史记录已关闭")
+	}
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("创建临时历史记录失败: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, card := range cards {
+		if s.expired(card) {
+			continue
+		}
+		data, err := json.Marshal(card)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时历史记录失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	// Windows 上不能替换仍被打开的文件，所以先关闭；替换失败时原文件不变，照样重新打开以便继续追加
+	// Windows cannot replace a file that is still open, so close it first; if the rename fails the original is
+	// untouched and is reopened all the same so appends keep working
+	s.file.Close()
+	renameErr := os.Rename(tmpPath, s.path)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+	}
+	s.file, err = os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if renameErr != nil {
+		return fmt.Errorf("替换历史记录失败: %w", renameErr)
+	}
+	if err != nil {
+		return fmt.Errorf("重新打开历史记录失败: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭底层文件
+// Close closes the underlying file
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// expired 判断卡片是否超出保留时长
+// expired reports whether the card is older than the retention period
+func (s *JSONLStore) expired(card Card) bool {
+	return s.retention > 0 && time.Since(card.CreatedAt) > s.retention
+}
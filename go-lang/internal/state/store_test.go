@@ -0,0 +1,167 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, retention time.Duration) (*JSONLStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history", "history.jsonl")
+	store, err := NewJSONLStore(path, retention)
+	if err != nil {
+		t.Fatalf("NewJSONLStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, path
+}
+
+func cardAt(text string, age time.Duration) Card {
+	created := time.Now().Add(-age)
+	return Card{ID: "card_" + text, Text: text, RawText: text, CreatedAt: created, UpdatedAt: created}
+}
+
+func cardTexts(cards []Card) []string {
+	texts := make([]string, len(cards))
+	for i, c := range cards {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+func loadTexts(t *testing.T, store *JSONLStore) []string {
+	t.Helper()
+	cards, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cardTexts(cards)
+}
+
+func TestStoreRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		want      []string
+	}{
+		{"keep forever", 0, []string{"old", "recent", "new"}},
+		{"one day", 24 * time.Hour, []string{"recent", "new"}},
+		{"one minute", time.Minute, []string{"new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStore(t, tt.retention)
+			cards := []Card{
+				cardAt("old", 48*time.Hour),
+				cardAt("recent", time.Hour),
+				cardAt("new", 0),
+			}
+			if err := store.Append(cards...); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+			if got := loadTexts(t, store); !slices.Equal(got, tt.want) {
+				t.Errorf("Load = %q, want %q", got, tt.want)
+			}
+
+			// 压缩时同样丢弃过期卡片 / Compaction drops expired cards as well
+			if err := store.Rewrite(cards); err != nil {
+				t.Fatalf("Rewrite: %v", err)
+			}
+			if got := loadTexts(t, store); !slices.Equal(got, tt.want) {
+				t.Errorf("Load after Rewrite = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreRewriteCompactsAndKeepsAppending(t *testing.T) {
+	store, path := newTestStore(t, 0)
+	if err := store.Append(cardAt("a", 0), cardAt("b", 0), cardAt("c", 0)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := store.Rewrite([]Card{cardAt("c", 0)}); err != nil {
+		t.Fatalf("Rewrite: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Rewrite left the temporary file behind")
+	}
+	if err := store.Append(cardAt("d", 0)); err != nil {
+		t.Fatalf("Append after Rewrite: %v", err)
+	}
+	if got, want := loadTexts(t, store), []string{"c", "d"}; !slices.Equal(got, want) {
+		t.Errorf("Load = %q, want %q", got, want)
+	}
+}
+
+func TestStoreRewriteFailureKeepsLog(t *testing.T) {
+	store, path := newTestStore(t, 0)
+	if err := store.Append(cardAt("a", 0)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// 临时文件路径被目录占用时无法创建，原日志保持可追加 / The temp path is taken by a directory, so the original log must stay appendable
+	if err := os.Mkdir(path+".tmp", 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rewrite(nil); err == nil {
+		t.Fatal("Rewrite succeeded with the temp path taken")
+	}
+	if err := store.Append(cardAt("b", 0)); err != nil {
+		t.Fatalf("Append after failed Rewrite: %v", err)
+	}
+	if got, want := loadTexts(t, store), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("Load = %q, want %q", got, want)
+	}
+}
+
+func TestStoreLoadSkipsPartialLine(t *testing.T) {
+	store, path := newTestStore(t, 0)
+	if err := store.Append(cardAt("a", 0)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	// 模拟写到一半时崩溃 / Simulate a crash halfway through a write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"card_b","text":"b","crea` + "\n")
+	f.Close()
+	if err := store.Append(cardAt("c", 0)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if got, want := loadTexts(t, store), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Load = %q, want %q", got, want)
+	}
+}
+
+func TestStoreLoadOldFormat(t *testing.T) {
+	store, path := newTestStore(t, 0)
+	created := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	line := `{"text":"旧卡片","createdAt":"2024-05-01T08:00:00Z"}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cards, err := store.Load()
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("Load = %d cards, %v; want 1", len(cards), err)
+	}
+	c := cards[0]
+	if c.ID == "" || !c.UpdatedAt.Equal(created) || c.RawText != "旧卡片" {
+		t.Errorf("old card = %+v, want an ID, UpdatedAt = CreatedAt and RawText = Text", c)
+	}
+}
+
+func TestStoreClosed(t *testing.T) {
+	store, _ := newTestStore(t, 0)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := store.Append(cardAt("a", 0)); err == nil {
+		t.Error("Append after Close succeeded")
+	}
+	if err := store.Rewrite(nil); err == nil {
+		t.Error("Rewrite after Close succeeded")
+	}
+}
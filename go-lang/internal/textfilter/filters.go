@@ -0,0 +1,143 @@
+// Package textfilter 提供可组合的文本规范化过滤器：标点修剪、空白合并、全角转半角、中英文间距、重复标点合并和口头语删除
+// Package textfilter provides composable text normalization filters: punctuation trimming, whitespace collapsing,
+// full-width to half-width conversion, CJK-Latin spacing, duplicate punctuation collapsing and filler word removal
+package textfilter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 过滤器名称，用于配置文件中的方案 / Filter names used by the profiles in the config file
+const (
+	TrimLeadingPunct  = "trim_leading_punct"  // 去掉开头的标点和空白 / Remove leading punctuation and whitespace
+	TrimTrailingPunct = "trim_trailing_punct" // 去掉结尾的标点和空白 / Remove trailing punctuation and whitespace
+	CollapseSpace     = "collapse_space"      // 合并连续空白 / Collapse runs of whitespace
+	HalfWidth         = "halfwidth"           // 全角字母、数字和符号转半角 / Full-width letters, digits and symbols to half-width
+	CJKSpacing        = "cjk_spacing"         // 中日韩文字与英文、数字之间加空格 / Space between CJK and Latin letters or digits
+	DedupePunct       = "dedupe_punct"        // 合并重复的标点 / Collapse repeated punctuation
+	RemoveFillers     = "remove_fillers"      // 删除口头语（嗯、呃、um、uh） / Remove filler words (嗯, 呃, um, uh)
+)
+
+// filters 按名称注册的过滤器
+// filters registers the filters by name
+var filters = map[string]func(string) string{
+	TrimLeadingPunct:  trimLeadingPunct,
+	TrimTrailingPunct: trimTrailingPunct,
+	CollapseSpace:     collapseSpace,
+	HalfWidth:         halfWidth,
+	CJKSpacing:        cjkSpacing,
+	DedupePunct:       dedupePunct,
+	RemoveFillers:     removeFillers,
+}
+
+// sentencePunct 句子和分句标点，引号、括号、#、@ 等不在其中
+// sentencePunct is sentence and clause punctuation; quotes, brackets, # and @ are not included
+const sentencePunct = "。！？，、；：….!?,;:"
+
+// isTrimmable 判断字符是否为首尾可去掉的空白或句子标点
+// isTrimmable reports whether the character is whitespace or sentence punctuation that may be trimmed from either end
+func isTrimmable(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(sentencePunct, r)
+}
+
+// trimLeadingPunct 去掉开头的中英文句子标点和空白（如语音输入在上一句之后补出的 。或 ,）
+// trimLeadingPunct removes leading CJK and ASCII sentence punctuation and whitespace (such as a 。 or , dictation adds after the previous sentence)
+func trimLeadingPunct(text string) string {
+	return strings.TrimLeftFunc(text, isTrimmable)
+}
+
+// trimTrailingPunct 去掉结尾的中英文句子标点和空白
+// trimTrailingPunct removes trailing CJK and ASCII sentence punctuation and whitespace
+func trimTrailingPunct(text string) string {
+	return strings.TrimRightFunc(text, isTrimmable)
+}
+
+var (
+	horizontalSpace = regexp.MustCompile(`[^\S\n]+`)
+	blankLines      = regexp.MustCompile(`\s*\n\s*`)
+)
+
+// collapseSpace 将连续的空格和制表符合并为一个空格，连续的空行合并为一个换行，并去掉首尾空白
+// collapseSpace turns runs of spaces and tabs into one space and runs of blank lines into one newline, trimming both ends
+func collapseSpace(text string) string {
+	text = blankLines.ReplaceAllString(text, "\n")
+	text = horizontalSpace.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// halfWidth 将全角字母、数字、符号和全角空格转为半角；中文常用的全角标点（，。！？：；（））保持不变
+// halfWidth converts full-width letters, digits, symbols and the ideographic space to half-width; full-width punctuation
+// common in Chinese (，。！？：；（）) is left alone
+func halfWidth(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '０' && r <= '９', r >= 'Ａ' && r <= 'Ｚ', r >= 'ａ' && r <= 'ｚ':
+			return r - 0xFEE0
+		case strings.ContainsRune("＂＃＄％＆＇＊＋－／＜＝＞＠［＼］＾＿｀｛｜｝～", r):
+			return r - 0xFEE0
+		}
+		return r
+	}, text)
+}
+
+// isCJK 判断字符是否为中日韩文字
+// isCJK reports whether the character is a CJK character
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isLatin 判断字符是否为英文字母或数字
+// isLatin reports whether the character is a Latin letter or digit
+func isLatin(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// cjkSpacing 在相邻的中日韩文字与英文字母、数字之间插入空格（盘古之白）
+// cjkSpacing inserts a space between adjacent CJK characters and Latin letters or digits
+func cjkSpacing(text string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range text {
+		if i > 0 && (isCJK(prev) && isLatin(r) || isLatin(prev) && isCJK(r)) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// repeatable 可以合法重复的标点：省略号和破折号
+// repeatable is punctuation that may legitimately repeat: ellipses and dashes
+const repeatable = ".…-—·"
+
+// dedupePunct 将连续重复的同一个标点合并为一个（如 ！！！ → ！），省略号和破折号除外
+// dedupePunct collapses a run of the same punctuation mark into one (！！！ becomes ！), except ellipses and dashes
+func dedupePunct(text string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range text {
+		if r == prev && unicode.IsPunct(r) && !strings.ContainsRune(repeatable, r) {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+var (
+	chineseFillers = regexp.MustCompile(`[嗯呃]+[，,、]?\s*`)
+	englishFillers = regexp.MustCompile(`(?i)\b(?:um+|uh+|uhm|erm|hmm+)\b[,，]?\s*`)
+)
+
+// removeFillers 删除口头语（嗯、呃、um、uh、hmm）及紧跟的逗号
+// removeFillers removes filler words (嗯, 呃, um, uh, hmm) and the comma right after them
+func removeFillers(text string) string {
+	text = chineseFillers.ReplaceAllString(text, "")
+	return englishFillers.ReplaceAllString(text, "")
+}
@@ -0,0 +1,109 @@
+package textfilter
+
+import (
+	"sort"
+	"testing"
+)
+
+type filterCase struct {
+	in, want string
+}
+
+// filterCases 每个已注册过滤器的用例
+// filterCases holds the cases for every registered filter
+var filterCases = map[string][]filterCase{
+	TrimLeadingPunct: {
+		{"。，你好", "你好"},
+		{"  , hello", "hello"},
+		{"！？ 好的。", "好的。"},
+		{"#标签", "#标签"},
+		{"@小王 你好", "@小王 你好"},
+		{"“引号”", "“引号”"},
+		{"", ""},
+	},
+	TrimTrailingPunct: {
+		{"你好。。", "你好"},
+		{"hello!  ", "hello"},
+		{"好的，", "好的"},
+		{"（括号）", "（括号）"},
+		{"你好？”", "你好？”"},
+		{"。", ""},
+	},
+	CollapseSpace: {
+		{"a  \t b", "a b"},
+		{"第一行\n\n\n第二行", "第一行\n第二行"},
+		{"行尾 \n  下一行", "行尾\n下一行"},
+		{"  前后  ", "前后"},
+		{"不变", "不变"},
+	},
+	HalfWidth: {
+		{"ＧＯ１２３", "GO123"},
+		{"ａｂｃ　ｄｅｆ", "abc def"},
+		{"＃＠％＋＝", "#@%+="},
+		{"你好，世界！（测试）：是；吗？。", "你好，世界！（测试）：是；吗？。"},
+		{"already ascii", "already ascii"},
+	},
+	CJKSpacing: {
+		{"用Go写", "用 Go 写"},
+		{"共3个", "共 3 个"},
+		{"hello世界", "hello 世界"},
+		{"日本語テストabc", "日本語テスト abc"},
+		{"한국어abc", "한국어 abc"},
+		{"用 Go 写", "用 Go 写"},
+		{"Ｇｏ中文", "Ｇｏ中文"},
+		{"你好，Go", "你好，Go"},
+	},
+	DedupePunct: {
+		{"好！！！", "好！"},
+		{"what??", "what?"},
+		{"，，。", "，。"},
+		{"！？！？", "！？！？"},
+		{"等等……", "等等……"},
+		{"wait...", "wait..."},
+		{"a--b——c", "a--b——c"},
+		{"aa  bb", "aa  bb"},
+	},
+	RemoveFillers: {
+		{"嗯，我觉得可以", "我觉得可以"},
+		{"呃呃 那个", "那个"},
+		{"我嗯觉得", "我觉得"},
+		{"um, I think so", "I think so"},
+		{"Uhm yes", "yes"},
+		{"hmmm ok", "ok"},
+		{"I said uh, maybe", "I said maybe"},
+		{"umbrella and hummus", "umbrella and hummus"},
+		{"嗯", ""},
+	},
+}
+
+func TestFilters(t *testing.T) {
+	for name, cases := range filterCases {
+		filter := filters[name]
+		if filter == nil {
+			t.Errorf("filter %q is not registered", name)
+			continue
+		}
+		for _, c := range cases {
+			if got := filter(c.in); got != c.want {
+				t.Errorf("%s(%q) = %q, want %q", name, c.in, got, c.want)
+			}
+		}
+	}
+}
+
+func TestEveryFilterHasCases(t *testing.T) {
+	var tested []string
+	for name := range filterCases {
+		tested = append(tested, name)
+	}
+	sort.Strings(tested)
+	names := Names()
+	if len(tested) != len(names) {
+		t.Fatalf("tested filters %v, registered filters %v", tested, names)
+	}
+	for i := range names {
+		if tested[i] != names[i] {
+			t.Fatalf("tested filters %v, registered filters %v", tested, names)
+		}
+	}
+}
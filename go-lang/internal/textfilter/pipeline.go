@@ -0,0 +1,107 @@
+package textfilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile 默认方案，与之前只去掉开头标点的行为一致
+// DefaultProfile is the default profile, matching the earlier behavior of only trimming leading punctuation
+const DefaultProfile = "default"
+
+// builtinProfiles 内置方案
+// builtinProfiles are the built-in profiles
+var builtinProfiles = map[string][]string{
+	"raw":          {},
+	DefaultProfile: {TrimLeadingPunct},
+	"clean":        {HalfWidth, CollapseSpace, DedupePunct, TrimLeadingPunct, CJKSpacing},
+	"chat":         {HalfWidth, RemoveFillers, CollapseSpace, DedupePunct, TrimLeadingPunct, TrimTrailingPunct, CJKSpacing},
+}
+
+// Profile 返回方案包含的过滤器名称；custom 中的方案优先于内置方案
+// Profile returns the filter names of the profile; profiles in custom take precedence over the built-in ones
+func Profile(name string, custom map[string][]string) ([]string, error) {
+	if names, ok := custom[name]; ok {
+		return names, nil
+	}
+	if names, ok := builtinProfiles[name]; ok {
+		return names, nil
+	}
+	return nil, fmt.Errorf("未知的过滤方案: %s", name)
+}
+
+// Pipeline 按顺序执行的过滤器
+// Pipeline is a sequence of filters applied in order
+type Pipeline struct {
+	names []string
+	funcs []func(string) string
+}
+
+// New 按名称创建过滤管线，名称未注册时返回错误
+// New creates a pipeline from filter names, returning an error for an unregistered name
+func New(names []string) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, name := range names {
+		filter, ok := filters[name]
+		if !ok {
+			return nil, fmt.Errorf("未知的过滤器: %s（可用: %s）", name, strings.Join(Names(), ", "))
+		}
+		p.names = append(p.names, name)
+		p.funcs = append(p.funcs, filter)
+	}
+	return p, nil
+}
+
+// Apply 依次执行所有过滤器；nil 管线原样返回文本
+// Apply runs every filter in order; a nil pipeline returns the text unchanged
+func (p *Pipeline) Apply(text string) string {
+	if p == nil {
+		return text
+	}
+	for _, filter := range p.funcs {
+		text = filter(text)
+	}
+	return text
+}
+
+// Has 判断管线是否包含指定过滤器
+// Has reports whether the pipeline contains the filter
+func (p *Pipeline) Has(name string) bool {
+	if p == nil {
+		return false
+	}
+	for _, n := range p.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回管线中的过滤器名称，用于日志
+// String returns the filter names in the pipeline, for logs
+func (p *Pipeline) String() string {
+	if p == nil || len(p.names) == 0 {
+		return "（无）"
+	}
+	return strings.Join(p.names, " → ")
+}
+
+// Names 返回所有已注册的过滤器名称（按字母排序）
+// Names returns every registered filter name (sorted)
+func Names() []string {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TrimLeading 去掉开头的标点和空白，供逐字累加的实时输入使用（其余过滤器只在生成卡片时执行）
+// TrimLeading removes leading punctuation and whitespace, for live input that grows piece by piece
+// (the other filters only run when cards are created)
+func TrimLeading(text string) string {
+	return trimLeadingPunct(text)
+}
@@ -0,0 +1,77 @@
+package textfilter
+
+import "testing"
+
+func TestBuiltinProfiles(t *testing.T) {
+	const input = "。嗯，我们用ＧＯ写后端！！  "
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"raw", input},
+		{DefaultProfile, "嗯，我们用ＧＯ写后端！！  "},
+		{"clean", "嗯，我们用 GO 写后端！"},
+		{"chat", "我们用 GO 写后端"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			names, err := Profile(tt.profile, nil)
+			if err != nil {
+				t.Fatalf("Profile(%q): %v", tt.profile, err)
+			}
+			pipeline, err := New(names)
+			if err != nil {
+				t.Fatalf("New(%v): %v", names, err)
+			}
+			if got := pipeline.Apply(input); got != tt.want {
+				t.Errorf("%s.Apply(%q) = %q, want %q", tt.profile, input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileCustomOverridesBuiltin(t *testing.T) {
+	custom := map[string][]string{
+		DefaultProfile: {HalfWidth},
+		"meeting":      {RemoveFillers, CJKSpacing},
+	}
+	if names, _ := Profile(DefaultProfile, custom); len(names) != 1 || names[0] != HalfWidth {
+		t.Errorf("Profile(default) = %v, want the custom profile", names)
+	}
+	if names, _ := Profile("meeting", custom); len(names) != 2 {
+		t.Errorf("Profile(meeting) = %v, want the custom profile", names)
+	}
+	if names, _ := Profile("chat", custom); len(names) == 0 {
+		t.Errorf("Profile(chat) = %v, want the built-in profile", names)
+	}
+	if _, err := Profile("nope", custom); err == nil {
+		t.Error("Profile(nope) succeeded, want an error")
+	}
+}
+
+func TestNewRejectsUnknownFilter(t *testing.T) {
+	if _, err := New([]string{HalfWidth, "nope"}); err == nil {
+		t.Fatal("New with an unknown filter succeeded, want an error")
+	}
+}
+
+func TestPipelineHelpers(t *testing.T) {
+	var nilPipeline *Pipeline
+	if got := nilPipeline.Apply("。原样"); got != "。原样" {
+		t.Errorf("nil Apply = %q, want the text unchanged", got)
+	}
+	if nilPipeline.Has(TrimLeadingPunct) {
+		t.Error("nil Has = true, want false")
+	}
+
+	pipeline, _ := New([]string{HalfWidth, TrimLeadingPunct})
+	if !pipeline.Has(TrimLeadingPunct) || pipeline.Has(CJKSpacing) {
+		t.Errorf("Has reports the wrong filters for %s", pipeline)
+	}
+	if got, want := pipeline.String(), "halfwidth → trim_leading_punct"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := TrimLeading("，，继续"); got != "继续" {
+		t.Errorf("TrimLeading = %q, want %q", got, "继续")
+	}
+}
@@ -6,23 +6,29 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 
+	"airinputlan/internal/clipboard"
+	"airinputlan/internal/config"
+	"airinputlan/internal/inject"
 	"airinputlan/internal/netif"
 	"airinputlan/internal/network"
+	"airinputlan/internal/session"
 	"airinputlan/internal/singleinstance"
 	"airinputlan/internal/state"
 )
@@ -30,9 +36,6 @@ import (
 const (
 	ServiceStartupDelay    = 500 * time.Millisecond // 服务启动延迟
 	HeartbeatInterval      = 15 * time.Second        // 心跳间隔
-	DefaultSegmentInterval = 2 * time.Second         // 默认分段间隔
-	DefaultMaxCardCount    = 50                      // 默认最大卡片数量
-	DefaultMaxCardLength   = 1000                    // 默认最大卡片长度（字符数）
 )
 
 //go:embed web/pc web/mobile
@@ -42,9 +45,35 @@ var (
 	contentState      *state.ContentState
 	httpServer        *network.HttpServer
 	sseServer         *network.SSEServer
-	mobileSegmentMode bool = true // 是否使用手机控制分段模式（默认单次输入）
-	segmentModeMu     sync.RWMutex  // 保护 mobileSegmentMode 的读写锁
+	pairingManager    *network.PairingManager
+	inputSession      *session.Session // 输入会话：输入模式和各设备的输入缓冲
 	debugMode         bool
+	tlsMode           bool // 是否启用 HTTPS（自签名证书）
+	historyEnabled    bool          // 是否持久化历史卡片
+	historyFile       string        // 历史记录文件路径
+	historyRetention  time.Duration // 历史卡片保留时长
+	clipboardName     string           // 剪贴板后端名称
+	clipboardBackend  clipboard.Backend // 系统剪贴板后端（不可用时为 nil）
+	autoCopy          bool             // 是否自动复制新卡片到系统剪贴板
+	injectMode        string           // 键盘输入模式: off, card, live
+	injectBackendName string           // 键盘输入后端名称
+	typist            *inject.Typist   // 键盘输入（未启用时为 nil）
+	listenPort        int              // 监听端口，0 表示从 5000 开始自动选择
+	bindAddr          string           // 监听地址
+	ifaceName         string           // 指定默认网卡名称
+	preferredIP       string           // 指定默认访问 IP
+	noBrowser         bool             // 启动时不自动打开浏览器
+	mdnsEnabled       bool             // 是否通过 mDNS 发布服务
+	mdnsHostname      string           // mDNS 主机名（不含 .local）
+	qrHostname        bool             // 二维码是否使用 .local 主机名
+	maxPhones         int              // 同时连接的手机端数量上限，0 表示不限制
+	segmentInterval   time.Duration    // 连续输入模式的分段间隔
+	maxCardCount      int              // 最大卡片数量
+	maxCardLength     int              // 最大卡片长度（字符数）
+	splitMode         string           // 超长卡片的分割方式: sentence, fixed
+	filterProfile     string           // 文本过滤方案
+	dictionaryFile    string           // 用户词典文件路径
+	dictionaryLive    bool             // 实时输入也应用用户词典
 )
 
 // PC 端断开检测相关变量 / PC client disconnect detection variables
@@ -55,10 +84,42 @@ var (
 )
 
 func main() {
-	// 解析命令行参数 / Parse command line arguments
-	flag.BoolVar(&debugMode, "debug", false, "启用调试日志")
+	// 解析命令行参数（默认值来自环境变量，未指定时使用配置文件） / Parse command line arguments (defaults come from env vars; the config file applies when unset)
+	def := config.Default()
+	flag.BoolVar(&debugMode, "debug", envBool("DEBUG", false), "启用调试日志 [AIRINPUT_DEBUG]")
+	flag.StringVar(&configPath, "config", envString("CONFIG", ""), "配置文件路径（默认位于用户配置目录） [AIRINPUT_CONFIG]")
+	flag.IntVar(&listenPort, "port", envInt("PORT", def.Server.Port), "监听端口，0 表示从 5000 开始自动选择 [AIRINPUT_PORT]")
+	flag.StringVar(&bindAddr, "bind", envString("BIND", def.Server.Bind), "监听地址 [AIRINPUT_BIND]")
+	flag.StringVar(&ifaceName, "iface", envString("IFACE", def.Server.Iface), "默认使用的网卡名称（替代自动选择） [AIRINPUT_IFACE]")
+	flag.StringVar(&preferredIP, "ip", envString("IP", def.Server.IP), "默认使用的 IP 地址（替代自动选择，优先于 -iface） [AIRINPUT_IP]")
+	flag.BoolVar(&noBrowser, "no-browser", envBool("NO_BROWSER", def.Server.NoBrowser), "启动时不自动打开浏览器 [AIRINPUT_NO_BROWSER]")
+	flag.DurationVar(&segmentInterval, "segment-interval", envDuration("SEGMENT_INTERVAL", time.Duration(def.Segment.Interval)), "连续输入模式下的自动分段间隔 [AIRINPUT_SEGMENT_INTERVAL]")
+	flag.IntVar(&maxCardCount, "max-cards", envInt("MAX_CARDS", def.Segment.MaxCards), "最多保留的历史卡片数量 [AIRINPUT_MAX_CARDS]")
+	flag.IntVar(&maxCardLength, "max-card-length", envInt("MAX_CARD_LENGTH", def.Segment.MaxCardLength), "单张卡片最大长度（字符数），超出时自动分割 [AIRINPUT_MAX_CARD_LENGTH]")
+	flag.StringVar(&splitMode, "split", envString("SPLIT", def.Segment.Split), "超长卡片的分割方式: sentence（优先在句子、分句、空白处分割）, fixed（按最大长度截断） [AIRINPUT_SPLIT]")
+	flag.StringVar(&filterProfile, "filter-profile", envString("FILTER_PROFILE", def.Filter.Profile), "文本过滤方案: raw, default, clean, chat 或配置文件中的自定义方案 [AIRINPUT_FILTER_PROFILE]")
+	flag.StringVar(&dictionaryFile, "dictionary", envString("DICTIONARY", def.Dictionary.File), "用户词典文件路径（默认位于用户配置目录） [AIRINPUT_DICTIONARY]")
+	flag.BoolVar(&dictionaryLive, "dictionary-live", envBool("DICTIONARY_LIVE", def.Dictionary.Live), "实时输入也应用用户词典（默认只替换卡片） [AIRINPUT_DICTIONARY_LIVE]")
+	flag.BoolVar(&mdnsEnabled, "mdns", envBool("MDNS", def.Server.MDNS), "通过 mDNS 发布 <hostname>.local 地址和 _airinputlan._tcp 服务 [AIRINPUT_MDNS]")
+	flag.StringVar(&mdnsHostname, "hostname", envString("HOSTNAME", def.Server.Hostname), "mDNS 主机名（不含 .local） [AIRINPUT_HOSTNAME]")
+	flag.BoolVar(&qrHostname, "qr-hostname", envBool("QR_HOSTNAME", def.Server.QRHostname), "二维码和终端地址使用 .local 主机名代替 IP [AIRINPUT_QR_HOSTNAME]")
+	flag.IntVar(&maxPhones, "max-phones", envInt("MAX_PHONES", def.Server.MaxPhones), "同时连接的手机端数量上限，0 表示不限制 [AIRINPUT_MAX_PHONES]")
+	flag.BoolVar(&tlsMode, "tls", envBool("TLS", def.Server.TLS), "启用 HTTPS（自动生成自签名证书） [AIRINPUT_TLS]")
+	flag.BoolVar(&historyEnabled, "history", envBool("HISTORY", def.History.Enabled), "将历史卡片保存到磁盘，重启后恢复 [AIRINPUT_HISTORY]")
+	flag.StringVar(&historyFile, "history-file", envString("HISTORY_FILE", def.History.File), "历史记录文件路径（默认位于用户配置目录） [AIRINPUT_HISTORY_FILE]")
+	flag.DurationVar(&historyRetention, "history-retention", envDuration("HISTORY_RETENTION", time.Duration(def.History.Retention)), "历史卡片保留时长（0 表示永久保留） [AIRINPUT_HISTORY_RETENTION]")
+	flag.StringVar(&clipboardName, "clipboard", envString("CLIPBOARD", def.Output.Clipboard), "剪贴板后端: auto, wl-copy, xclip, xsel, pbcopy, win32, memory [AIRINPUT_CLIPBOARD]")
+	flag.BoolVar(&autoCopy, "auto-copy", envBool("AUTO_COPY", def.Output.AutoCopy), "自动将新卡片复制到系统剪贴板 [AIRINPUT_AUTO_COPY]")
+	flag.StringVar(&injectMode, "inject", envString("INJECT", def.Output.Inject), "键盘输入模式: off（不输入）, card（输入每张卡片）, live（实时输入） [AIRINPUT_INJECT]")
+	flag.StringVar(&injectBackendName, "inject-backend", envString("INJECT_BACKEND", def.Output.InjectBackend), "键盘输入后端: auto, uinput, xdotool, ydotool, sendinput, osascript, memory [AIRINPUT_INJECT_BACKEND]")
 	flag.Parse()
 
+	// 读取配置文件并合并环境变量和命令行参数 / Load the config file and merge env vars and flags
+	if err := loadSettings(); err != nil {
+		log.Fatalf("加载配置失败（%s）: %v", configPath, err)
+	}
+	warnBindAddr()
+
 	// 初始化日志系统 / Initialize logging system
 	network.InitLogger()
 
@@ -86,47 +147,142 @@ func main() {
 		log.Fatal("未找到有效网卡")
 	}
 
-	// 使用第一个 IP（已按优先级排序：以太网 > USB共享网卡 > WiFi） / Use first IP (sorted by priority: Ethernet > USB Shared > WiFi)
-	defaultIP := ips[0].IP
+	// 用户指定网卡或 IP 时将其作为默认地址 / Use the user-selected interface or IP as the default address
+	ips, err = netif.PreferIP(ips, ifaceName, preferredIP)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 使用第一个 IP（已按优先级排序：以太网 > USB共享网卡 > WiFi），运行中可通过 /api/ip/active 切换 / Use first IP (sorted by priority: Ethernet > USB Shared > WiFi); switchable at runtime via /api/ip/active
+	defaultIP := ips[0].Host()
+	setLocalIPs(ips)
 
 	// 显示网卡信息 / Display network interface information
 	fmt.Printf("扫描到 %d 个网卡\n", len(ips))
 	for _, ip := range ips {
-		fmt.Printf("  - IP: %-15s 类型: %-12s 网卡: %s", ip.IP, ip.NicType, ip.IfaceName)
-		if ip.IP == defaultIP {
+		fmt.Printf("  - IP: %-15s 类型: %-12s 网卡: %s", ip.Host(), ip.NicType, ip.IfaceName)
+		if ip.Host() == defaultIP {
 			fmt.Printf(" [默认]")
 		}
 		fmt.Println()
 	}
 	fmt.Printf("\n默认访问地址: %s\n", defaultIP)
+	warnIPv6Unreachable(ips)
 	fmt.Println()
 
 	// 初始化内容状态 / Initialize content state
-	contentState = state.NewContentState(DefaultSegmentInterval, DefaultMaxCardCount, DefaultMaxCardLength)
+	contentState = state.NewContentState(segmentInterval, maxCardCount, maxCardLength)
+	contentState.SetSplitMode(state.SplitMode(splitMode))
+	filter := filterOptions(appConfig)
+	contentState.SetFilter(filter)
+	network.LogInfo("文本过滤方案: %s（%s）", filterProfile, filter.Pipeline)
+	if err := loadDictionary(); err != nil {
+		log.Fatalf("加载用户词典失败: %v", err)
+	}
 
 	// 清空之前的内容（防止重启后保留旧内容） / Clear previous content (prevent old content retention)
 	contentState.Clear()
+	inputSession = session.New(contentState)
+
+	// 启用持久化时从磁盘恢复历史卡片 / Restore history cards from disk when persistence is enabled
+	if historyEnabled {
+		if historyFile == "" {
+			historyFile, err = state.DefaultHistoryPath()
+			if err != nil {
+				log.Fatalf("获取历史记录路径失败: %v", err)
+			}
+		}
+		store, err := state.NewJSONLStore(historyFile, historyRetention)
+		if err != nil {
+			log.Fatalf("打开历史记录失败: %v", err)
+		}
+		if err := contentState.SetStore(store); err != nil {
+			log.Fatalf("加载历史记录失败: %v", err)
+		}
+		network.LogInfo("历史记录文件: %s", historyFile)
+	}
+
+	// 初始化系统剪贴板（不可用时仅禁用服务端复制） / Initialize the system clipboard (only server-side copy is disabled if unavailable)
+	clipboardBackend, err = clipboard.New(clipboardName)
+	if err != nil {
+		network.LogInfo("服务端复制不可用: %v", err)
+		clipboardBackend = nil
+	} else {
+		network.LogInfo("剪贴板后端: %s", clipboardBackend.Name())
+	}
+
+	// 初始化键盘输入注入 / Initialize keystroke injection
+	setupTyping()
 
 	// 初始化 SSE 服务 / Initialize SSE service
 	sseServer = network.NewSSEServer()
+	sseServer.SetMaxMobileDevices(maxPhones)
 	sseServer.SetOnMessage(handleMessage)
 	sseServer.SetOnPCClientsCountChange(handlePCClientsCountChange)
+	sseServer.SetSnapshotProvider(handleSnapshot)
 	go sseServer.Run()
 
-	// 初始化 HTTP 服务（绑定到 0.0.0.0 以支持所有网卡访问） / Initialize HTTP service (bind to 0.0.0.0 for all interfaces)
-	httpServer = network.NewHttpServer(0, "0.0.0.0")
+	// 初始化配对管理（手机端需配对后才能连接） / Initialize pairing (mobile must pair before connecting)
+	pairingManager = network.NewPairingManager(time.Duration(appConfig.Security.PINTTL), time.Duration(appConfig.Security.SessionTTL))
+	pairingManager.SetOnChange(handlePairingChange)
+	sseServer.SetOnKick(pairingManager.RevokeDevice)
+	pairingManager.SetOnRevoke(func() {
+		sseServer.DisconnectRemoteClients(network.Message{
+			Type: network.TypeUnpaired,
+			Data: "",
+		})
+	})
+
+	// 初始化 HTTP 服务（默认绑定到 :: 以支持所有网卡的 IPv4 和 IPv6 访问） / Initialize HTTP service (binds to :: by default for IPv4 and IPv6 on all interfaces)
+	httpServer = network.NewHttpServer(listenPort, bindAddr)
+
+	// 启用 HTTPS：为扫描到的 IP 生成或加载证书 / Enable HTTPS: generate or load a certificate for the scanned IPs
+	var certFingerprint string
+	if tlsMode {
+		certDir, err := network.DefaultCertDir()
+		if err != nil {
+			log.Fatalf("获取证书目录失败: %v", err)
+		}
+		ipList := make([]string, len(ips))
+		for i, ip := range ips {
+			ipList[i] = ip.IP
+		}
+		if mdnsEnabled {
+			ipList = append(ipList, mdnsHostname+".local")
+		}
+		cert, fingerprint, err := network.LoadOrCreateCertificate(certDir, ipList)
+		if err != nil {
+			log.Fatalf("证书准备失败: %v", err)
+		}
+		certFingerprint = fingerprint
+		httpServer.SetTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+		network.LogInfo("HTTPS 已启用，证书目录: %s", certDir)
+	}
 
 	// 注册路由 / Register routes
 	httpServer.HandleFunc("/", handleMobileIndex) // 默认为手机端
 	httpServer.HandleFunc("/pc", handlePCIndex)
 	httpServer.HandleFunc("/mobile", handleMobileIndex)
 	httpServer.HandleFunc("/template-editor", handleTemplateEditor) // 模板编辑器页面
-	httpServer.HandleFunc("/ws", sseServer.HandleSSE) // Keep /ws for backward compatibility
-	httpServer.HandleFunc("/ws/message", sseServer.HandlePostMessage)
-	httpServer.HandleFunc("/api/ip", network.HandleGetIP(convertIps(ips)))
-	httpServer.HandleFunc("/api/segment", handleSegmentRequest)
-	httpServer.HandleFunc("/api/mode", handleModeChange)
-	httpServer.HandleFunc("/api/mode/query", handleModeQuery)
+	httpServer.HandleFunc("/ws", pairingManager.Require(sseServer.HandleSSE)) // Keep /ws for backward compatibility
+	httpServer.HandleFunc("/ws/message", pairingManager.Require(sseServer.HandlePostMessage))
+	httpServer.HandleFunc("/ws/v2", pairingManager.Require(sseServer.HandleWebSocket)) // 原生 WebSocket 双向通道
+	httpServer.HandleFunc("/api/ip", network.HandleGetIP(listLocalIPs))
+	httpServer.HandleFunc("/api/ip/active", pairingManager.Require(handleActiveIP))
+	httpServer.HandleFunc("/api/segment", pairingManager.Require(handleSegmentRequest))
+	httpServer.HandleFunc("/api/mode", pairingManager.Require(handleModeChange))
+	httpServer.HandleFunc("/api/mode/query", pairingManager.Require(handleModeQuery))
+	httpServer.HandleFunc("/api/cards", pairingManager.Require(handleCards))
+	httpServer.HandleFunc("/api/cards/{id}", pairingManager.Require(handleCard))
+	httpServer.HandleFunc("/api/dictionary", pairingManager.Require(handleDictionary))
+	httpServer.HandleFunc("/api/dictionary/{index}", pairingManager.Require(handleDictionaryRule))
+	httpServer.HandleFunc("/api/copy", pairingManager.Require(handleCopy))
+	httpServer.HandleFunc("/api/pairing", pairingManager.HandlePairingInfo)
+	httpServer.HandleFunc("/api/pairing/revoke", pairingManager.HandleRevoke)
+	httpServer.HandleFunc("/api/tls", network.HandleGetTLS(tlsMode, certFingerprint))
 
 	// 注册静态文件服务器 / Register static file server
 	// 用于处理 /pc/ 路径下的所有静态文件（JS、CSS、图片等）
@@ -142,10 +298,27 @@ func main() {
 	if err != nil {
 		log.Fatalf("HTTP 服务启动失败: %v", err)
 	}
+	// IPv6 不可用时已改为只监听 IPv4 / Falls back to IPv4 only when IPv6 is unavailable
+	bindAddr = httpServer.IP()
 
 	// 注册端口 API（需要在端口确定后） / Register port API (after port is determined)
 	httpServer.HandleFunc("/api/port", network.HandleGetPort(port))
 
+	// 注册二维码图片 API（默认使用当前访问地址） / Register the QR image API (defaults to the current access address)
+	setConnectBase(network.ConnectInfo{
+		Scheme:      httpServer.Scheme(),
+		Port:        port,
+		Fingerprint: certFingerprint,
+	})
+	httpServer.HandleFunc("/api/qr.png", pairingManager.Require(network.HandleQRCode("png", connectInfo, pairingManager)))
+	httpServer.HandleFunc("/api/qr.svg", pairingManager.Require(network.HandleQRCode("svg", connectInfo, pairingManager)))
+
+	// 通过 mDNS 发布 <hostname>.local 和服务实例 / Publish <hostname>.local and the service instance over mDNS
+	startMDNS(port, httpServer.Scheme())
+	httpServer.HandleFunc("/api/mdns", handleMDNSInfo)
+	httpServer.HandleFunc("/api/devices", sseServer.HandleDevices)
+	httpServer.HandleFunc("/api/devices/kick", sseServer.HandleKick)
+
 	// 等待服务启动 / Wait for service startup
 	time.Sleep(ServiceStartupDelay)
 
@@ -154,19 +327,31 @@ func main() {
 	fmt.Println()
 
 	// 自动打开浏览器访问电脑端界面 / Auto-open browser to access PC interface
-	pcURL := fmt.Sprintf("http://127.0.0.1:%d/pc", port)
-	network.LogInfo("自动打开浏览器: %s", pcURL)
-	openBrowser(pcURL)
+	pcURL := fmt.Sprintf("%s://127.0.0.1:%d/pc", httpServer.Scheme(), port)
+	if noBrowser {
+		fmt.Printf("电脑端地址: %s\n", pcURL)
+	} else {
+		network.LogInfo("自动打开浏览器: %s", pcURL)
+		openBrowser(pcURL)
+	}
 
 	// 启动自动分段定时器（旧逻辑，兼容模式） / Start auto-segmentation timer (old logic, compatibility mode)
 	go segmentTimer()
 
+	// 监听配置文件，保存后自动应用 / Watch the config file and apply it on save
+	configWatcher := watchSettings()
+	defer configWatcher.Stop()
+
+	// 监听用户词典文件，保存后自动重新加载 / Watch the user dictionary file and reload it on save
+	dictionaryWatcher := watchDictionary()
+	defer dictionaryWatcher.Stop()
+
+	// 监听网卡变化，自动刷新访问地址和二维码 / Watch interfaces and refresh the access address and QR code automatically
+	netWatcher := watchNetwork()
+	defer netWatcher.Stop()
+
 	// 显示二维码 / Display QR code
-	qrData := network.GenerateQRCodeData(defaultIP, port)
-	fmt.Println("=== 连接信息 ===")
-	fmt.Printf("手机端访问地址: %s\n", qrData["url"])
-	fmt.Printf("二维码文本: %s\n", qrData["text"])
-	fmt.Println()
+	printConnectInfo()
 
 	// 等待退出信号 / Wait for exit signal
 	waitForExit(httpServer)
@@ -182,7 +367,6 @@ func handlePCIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Write(content)
 }
 
@@ -190,30 +374,41 @@ func handlePCIndex(w http.ResponseWriter, r *http.Request) {
 func handleMobileIndex(w http.ResponseWriter, r *http.Request) {
 	network.LogFormat("接收", "HTTP", "手机端 --> 服务端", "请求 Mobile 页面: %s", r.URL.Path)
 
-	// 检查是否已有手机端连接（只检查远程客户端）
-	sseServer.RLock()
-	hasRemoteClient := false
-	for _, c := range sseServer.Clients {
-		// 只检查远程客户端（非本地 IP）
-		if c.IP != "127.0.0.1" && c.IP != "::1" && c.IP != "localhost" {
-			hasRemoteClient = true
-			break
+	// 未配对的远程设备：携带配对码则尝试配对，否则显示配对页面
+	// Unpaired remote device: try to pair if a PIN is present, otherwise show the pairing page
+	if !pairingManager.IsTrusted(r) {
+		if r.URL.Query().Get("pin") != "" {
+			if err := pairingManager.PairRequest(w, r); err == nil {
+				// 配对成功，去掉地址中的配对码 / Paired; drop the PIN from the address
+				http.Redirect(w, r, "/", http.StatusSeeOther)
+				return
+			}
+		}
+		content, err := webFS.ReadFile("web/mobile/pair.html")
+		if err != nil {
+			network.LogFormat("错误", "HTTP", "服务端", "读取配对页面失败: %v", err)
+			http.Error(w, "未配对，请扫描电脑端二维码", http.StatusUnauthorized)
+			return
 		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write(content)
+		return
 	}
-	sseServer.RUnlock()
 
-	// 如果已有远程客户端，拒绝连接
-	if hasRemoteClient {
-		network.LogFormat("拒绝", "HTTP", "服务端", "拒绝连接：已有手机端连接")
+	// 分配持久的设备 ID，并检查手机端数量是否已达上限（同一设备重新打开页面不受限制）
+	// Assign a persistent device ID and check the mobile cap (the same device reopening the page is not limited)
+	deviceID := network.EnsureDeviceID(w, r)
+	if !sseServer.CanAcceptMobile(deviceID) {
+		network.LogFormat("拒绝", "HTTP", "服务端", "拒绝连接：手机端数量已达上限")
 		// 返回错误页面
 		content, err := webFS.ReadFile("web/mobile/error.html")
 		if err != nil {
 			network.LogFormat("错误", "HTTP", "服务端", "读取错误页面失败: %v", err)
-			http.Error(w, "已有手机端连接，请稍后再试", http.StatusServiceUnavailable)
+			http.Error(w, "手机端数量已达上限，请稍后再试", http.StatusServiceUnavailable)
 			return
 		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Write(content)
 		return
 	}
@@ -225,7 +420,6 @@ func handleMobileIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Write(content)
 }
 
@@ -239,25 +433,63 @@ func handleTemplateEditor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Write(content)
 }
 
 // handleMessage 处理接收到的消息（增量内容）
-func handleMessage(content string) {
-	// 更新当前输入内容（累加） / Update current input content (accumulate)
+func handleMessage(content string, source network.MessageSource) {
+	// 更新来源设备的当前输入内容（累加） / Update the source device's current input content (accumulate)
 	if content != "" {
-		contentState.UpdateContent(content)
+		cardSource := state.CardSource{
+			ClientID:   source.ClientID,
+			IP:         source.IP,
+			DeviceID:   source.DeviceID,
+			DeviceName: source.DeviceName,
+		}
+		fullContent := inputSession.Input(content, cardSource)
 
-		// 立即发送到PC端底部显示（type: "text"） / Immediately send to PC bottom display (type: "text")
-		// 注意：这里使用广播 / Note: using broadcast，因为 SendToCurrent 只发送给最后连接的客户端 / Note: using broadcast, SendToCurrent only sends to last connected client
-		fullContent := contentState.GetCurrentContent()
+		// 立即发送到PC端底部显示（type: "text"，Payload 标明设备） / Immediately send to PC bottom display (type: "text", Payload names the device)
+		// 只发送给电脑端，手机端不需要回显 / Only PC pages get it; phones need no echo
 		if fullContent != "" {
-			sseServer.Broadcast(network.Message{
-				Type: network.TypeText,
-				Data: fullContent,
+			sseServer.SendToRole(network.RolePC, network.Message{
+				Type:    network.TypeText,
+				Data:    fullContent,
+				Payload: inputPayload(cardSource),
 			})
 		}
+
+		// 实时模式：同步输入到焦点应用 / Live mode: sync the input to the focused application
+		typeLiveContent(cardSource.Key(), fullContent)
+	}
+}
+
+// handlePairingChange 配对码变化时通知 PC 端刷新二维码
+// handlePairingChange notifies PC clients to refresh the QR code when the PIN changes
+func handlePairingChange(pin string) {
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type: network.TypePairing,
+		Data: pin,
+	})
+	network.LogFormat("发送", "SSE", "服务端 --> PC端", "配对码已更换")
+}
+
+// handleSnapshot 提供电脑端连接时完整状态中的历史卡片、各设备的当前输入和输入模式
+// handleSnapshot supplies the history cards, each device's current input and the input mode for a connecting PC's snapshot
+func handleSnapshot() network.Snapshot {
+	inputs := inputSession.Inputs()
+	snapshotInputs := make([]network.SnapshotInput, 0, len(inputs))
+	for _, input := range inputs {
+		snapshotInputs = append(snapshotInputs, network.SnapshotInput{
+			Key:        input.Key,
+			DeviceID:   input.Source.DeviceID,
+			DeviceName: input.Source.DeviceName,
+			Text:       input.Content,
+		})
+	}
+	return network.Snapshot{
+		Cards:  contentState.GetHistoryCards(),
+		Inputs: snapshotInputs,
+		Mode:   string(inputSession.Mode()),
 	}
 }
 
@@ -298,7 +530,8 @@ func handleSegmentRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Content string `json:"content"`
+		Content  string `json:"content"`
+		ClientID string `json:"clientId"` // 手机端连接 ID（可选）
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -306,43 +539,45 @@ func handleSegmentRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 标记为手机控制分段模式 / Mark as mobile-controlled segmentation mode
-	segmentModeMu.Lock()
-	mobileSegmentMode = true
-	segmentModeMu.Unlock()
-
-	// 检查内容是否有意义 / Check if content is meaningful
-	if !state.IsContentMeaningful(req.Content) {
-		network.LogDebug("过滤无意义内容: %q", req.Content)
-		contentState.Clear()
-		w.WriteHeader(http.StatusOK)
-		return
+	// 来源设备：优先使用设备 Cookie，名称取自已连接的设备 / Source device: the device cookie first, the name from the connected device
+	source := state.CardSource{
+		ClientID: req.ClientID,
+		IP:       network.ClientIP(r),
+		DeviceID: network.DeviceID(r),
+		Cause:    state.CauseMobile,
+	}
+	if device, ok := sseServer.LookupDevice(source.DeviceID); ok {
+		source.DeviceName = device.Name
 	}
 
-	// 生成卡片，获取过滤后的内容 / Add to history cards, get filtered content
-	filteredContent := contentState.AddCard(req.Content)
-	if filteredContent == "" {
-		// 如果过滤后内容为空，跳过发送 / Skip sending if filtered content is empty
+	// 切换到单次输入模式并生成卡片（同时清空该设备的输入） / Switch to single input and create the cards (also clears the device's input)
+	previousMode := inputSession.Mode()
+	cards := inputSession.Segment(req.Content, source).Cards
+	if mode := inputSession.Mode(); mode != previousMode {
+		sseServer.SendToRole(network.RolePC, network.Message{Type: "mode_sync", Data: string(mode)})
+	}
+	if len(cards) == 0 {
+		// 内容无意义或过滤后为空，跳过发送 / Skip sending if the content is meaningless or filtered away
+		typeSegment(source.Key(), nil)
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
 	// 发送卡片消息给PC端（type: "card"） / Send card message to PC (type: "card")
-	sseServer.Broadcast(network.Message{
-		Type: network.TypeCard,
-		Data: filteredContent,
-	})
-
-	// 发送清空输入框信号（type: "clear_input"） / Send clear input signal (type: "clear_input")
-	sseServer.Broadcast(network.Message{
-		Type: network.TypeClearInput,
-		Data: "",
+	for _, card := range cards {
+		sseServer.SendToRole(network.RolePC, newCardMessage(network.TypeCard, card))
+	}
+	autoCopyCards(cards)
+	typeSegment(source.Key(), cards)
+
+	// 发送清空输入框信号（type: "clear_input"，只清空该设备的输入） / Send clear input signal (type: "clear_input", only this device's input)
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type:    network.TypeClearInput,
+		Data:    "",
+		Payload: inputPayload(source),
 	})
 
-	// 清空服务端累积的内容 / Clear accumulated content on server
-	contentState.Clear()
-
-	network.LogInfo("收到分段（手机控制）: %s", filteredContent)
+	network.LogInfo("收到分段（手机控制）: %s", joinCardTexts(cards))
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -355,28 +590,19 @@ func handleModeQuery(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 获取当前模式 / Get current mode
-	mode := "continuous"
-	segmentModeMu.RLock()
-	if mobileSegmentMode {
-		mode = "single"
-	}
-	segmentModeMu.RUnlock()
+	mode := inputSession.Mode()
 
-	// 通过 SSE 发送模式同步消息给所有客户端 / Send mode sync via SSE
-	sseServer.Broadcast(network.Message{
+	// 通过 SSE 发送模式同步消息给所有手机端 / Send mode sync to every phone via SSE
+	sseServer.SendToRole(network.RoleMobile, network.Message{
 		Type: "mode_sync",
-		Data: mode,
+		Data: string(mode),
 	})
-	if mode == "single" {
-		network.LogFormat("查询", "HTTP", "手机端 --> 服务端", "当前为单次输入模式（手机控制分段）")
-	} else {
-		network.LogFormat("查询", "HTTP", "手机端 --> 服务端", "当前为连续输入模式（服务端控制分段）")
-	}
+	network.LogFormat("查询", "HTTP", "手机端 --> 服务端", "当前为%s", mode.Description())
 
 	// 返回当前模式 / Return current mode
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"mode": mode,
+		"mode": string(mode),
 	})
 }
 
@@ -396,177 +622,130 @@ func handleModeChange(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
+	mode, err := session.ParseMode(req.Mode)
+	if err != nil {
+		http.Error(w, "Invalid mode", http.StatusBadRequest)
+		return
+	}
 
-	// 清空服务端累积的内容 / Clear accumulated content on server
-
-		contentState.Clear()
-
-		network.LogFormat("处理", "系统", "服务端", "清空服务端累积的内容")
-
-	
-
-		// 发送清空输入框信号给电脑端 / Send clear input signal to PC
-
-		sseServer.Broadcast(network.Message{
-
-			Type: network.TypeClearInput,
-
-			Data: "",
-
-		})
-
-		network.LogFormat("发送", "SSE", "服务端 --> PC端", "发送清空输入框信号")
-
-	
-
-	
-
-		// 更新模式标志 / Update mode flag
-
-	
-
-	
-
-		
-
-	
-
-	
-
-				if req.Mode == "single" {
-
-	
-
-	
-
-					segmentModeMu.Lock()
-
-	
-
-	
-
-					mobileSegmentMode = true
-
-	
-
-	
-
-					segmentModeMu.Unlock()
-
-	
-
-	
-
-					network.LogFormat("处理", "系统", "服务端", "切换到单次输入模式（手机控制分段）")
-
-	
-
-	
-
-		
-
-	
-
-	
-
-				} else if req.Mode == "continuous" {
-
-	
-
-	
-
-					segmentModeMu.Lock()
+	// 清空服务端累积的内容并切换模式 / Clear accumulated content on server and switch the mode
+	inputSession.SelectMode(mode)
+	typeSegment("", nil)
+	network.LogFormat("处理", "系统", "服务端", "清空服务端累积的内容")
 
-	
+	// 发送清空输入框信号给电脑端 / Send clear input signal to PC
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type: network.TypeClearInput,
+		Data: "",
+	})
+	network.LogFormat("发送", "SSE", "服务端 --> PC端", "发送清空输入框信号")
 
-	
+	// 发送确认信号给发起切换的手机端，其他手机端同步模式 / Acknowledge to the phone that switched, sync the mode to the other phones
+	ack := network.Message{
+		Type: "mode_ack",
+		Data: string(mode),
+	}
+	if deviceID := network.DeviceID(r); deviceID != "" {
+		sseServer.SendToDevice(deviceID, ack)
+		sseServer.SendToRole(network.RoleMobile, network.Message{Type: "mode_sync", Data: string(mode)})
+	} else {
+		sseServer.SendToRole(network.RoleMobile, ack)
+	}
+	// 电脑端显示当前模式 / The PC page shows the current mode
+	sseServer.SendToRole(network.RolePC, network.Message{Type: "mode_sync", Data: string(mode)})
+	network.LogFormat("发送", "SSE", "服务端 --> 手机端", "发送确认信号: %s", mode.Description())
 
-					mobileSegmentMode = false
+	w.WriteHeader(http.StatusOK)
+}
 
-	
+// segmentTimer 自动分段定时器（旧逻辑，服务端控制分段）
+func segmentTimer() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
 
-	
+	for range ticker.C {
+		// 连续输入模式下每个设备的输入各自按分段间隔分段 / In continuous input each device's input is segmented on its own interval
+		for _, segment := range inputSession.Tick() {
+			cards := segment.Cards
+			if len(cards) == 0 {
+				// 内容无意义或过滤后为空，跳过发送 / Skip sending if the content is meaningless or filtered away
+				typeSegment(segment.Key, nil)
+				continue
+			}
+			filteredContent := joinCardTexts(cards)
 
-					segmentModeMu.Unlock()
+			// 发送分段信号给PC端（type: "segment"，附带卡片数据） / Send segmentation signal to PC (type: "segment", with card payload)
+			for _, card := range cards {
+				sseServer.SendToRole(network.RolePC, newCardMessage(network.TypeSegment, card))
+			}
+			autoCopyCards(cards)
+			typeSegment(segment.Key, cards)
 
-			network.LogFormat("处理", "系统", "服务端", "切换到连续输入模式（服务端控制分段）")
+			// 发送模式同步信号给手机端（确保手机端按钮状态正确） / Send mode sync to mobile (ensure mobile button state is correct)
+			sseServer.SendToRole(network.RoleMobile, network.Message{
+				Type: "mode_sync",
+				Data: string(inputSession.Mode()),
+			})
 
+			network.LogFormat("处理", "系统", "服务端", "自动分段（服务端控制）: %s", filteredContent)
+			network.LogInfo("收到分段（服务端控制）: %s", filteredContent)
+			network.LogFormat("发送", "SSE", "服务端 --> 手机端", "发送模式同步信号: 连续输入模式")
 		}
+	}
+}
 
-	
-
-		// 发送确认信号给手机端 / Send acknowledgment to mobile
-
-		sseServer.Broadcast(network.Message{
-
-			Type: "mode_ack",
-
-			Data: req.Mode,
-
-		})
-
-		if req.Mode == "single" {
-
-			network.LogFormat("发送", "SSE", "服务端 --> 手机端", "发送确认信号: 单次输入模式")
-
-		} else {
-
-			network.LogFormat("发送", "SSE", "服务端 --> 手机端", "发送确认信号: 连续输入模式")
+// newCardMessage 构造携带卡片 JSON 的消息，Data 保留纯文本以兼容旧页面
+// newCardMessage builds a message carrying the card JSON; Data keeps the plain text for older pages
+func newCardMessage(msgType string, card state.Card) network.Message {
+	payload, _ := json.Marshal(card)
+	return network.Message{
+		Type:    msgType,
+		Data:    card.Text,
+		Payload: payload,
+	}
+}
 
-		}
+// inputPayload 返回标明输入来源设备的消息 Payload（text 和 clear_input 消息使用）
+// inputPayload returns the message Payload naming the input's source device (used by text and clear_input messages)
+func inputPayload(source state.CardSource) json.RawMessage {
+	payload, _ := json.Marshal(map[string]string{
+		"key":        source.Key(),
+		"deviceId":   source.DeviceID,
+		"deviceName": source.DeviceName,
+	})
+	return payload
+}
 
-	w.WriteHeader(http.StatusOK)
+// joinCardTexts 拼接卡片文本，用于日志输出
+// joinCardTexts joins card texts for logging
+func joinCardTexts(cards []state.Card) string {
+	texts := make([]string, len(cards))
+	for i, card := range cards {
+		texts[i] = card.Text
+	}
+	return strings.Join(texts, "")
 }
 
-// segmentTimer 自动分段定时器（旧逻辑，服务端控制分段）
-func segmentTimer() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// warnBindAddr 监听地址不包含本机回环地址时给出警告
+// warnBindAddr warns when the listen address does not include loopback
+func warnBindAddr() {
+	bindIP := net.ParseIP(bindAddr)
+	// 电脑端页面通过 127.0.0.1 访问并据此识别 / The PC page is reached via 127.0.0.1 and recognised by it
+	if !bindIP.IsUnspecified() && !bindIP.IsLoopback() {
+		network.LogInfo("警告: 监听地址 %s 不包含 127.0.0.1，电脑端页面将无法访问", bindAddr)
+	}
+}
 
-	for range ticker.C {
-		// 只在非手机控制模式下才自动分段 / Only auto-segment when not in mobile control mode
-		segmentModeMu.RLock()
-		shouldAutoSegment := !mobileSegmentMode && contentState.ShouldSegment()
-		segmentModeMu.RUnlock()
-		if shouldAutoSegment {
-			content := contentState.GetCurrentContent()
-			if content != "" {
-				// 检查内容是否有意义 / Check if content is meaningful
-				if !state.IsContentMeaningful(content) {
-					network.LogDebug("过滤无意义内容: %q", content)
-					contentState.Clear()
-					continue
-				}
-
-				// 添加到历史卡片，获取过滤后的内容 / Add to history cards, get filtered content
-				filteredContent := contentState.AddCard(content)
-				if filteredContent == "" {
-					// 如果过滤后内容为空，跳过发送 / Skip sending if filtered content is empty
-					continue
-				}
-
-				// 发送分段信号给PC端（type: "segment"） / Send segmentation signal to PC (type: "segment")
-				// 注意：这里使用广播 / Note: using broadcast
-				sseServer.Broadcast(network.Message{
-					Type: network.TypeSegment,
-					Data: filteredContent,
-				})
-
-				// 发送模式同步信号给手机端（确保手机端按钮状态正确） / Send mode sync to mobile (ensure mobile button state is correct)
-				mode := "continuous"
-				segmentModeMu.RLock()
-				if mobileSegmentMode {
-					mode = "single"
-				}
-				segmentModeMu.RUnlock()
-				sseServer.Broadcast(network.Message{
-					Type: "mode_sync",
-					Data: mode,
-				})
-
-				network.LogFormat("处理", "系统", "服务端", "自动分段（服务端控制）: %s", filteredContent)
-				network.LogInfo("收到分段（服务端控制）: %s", filteredContent)
-				network.LogFormat("发送", "SSE", "服务端 --> 手机端", "发送模式同步信号: 连续输入模式")			}
+// warnIPv6Unreachable 只监听 IPv4 地址但扫描到 IPv6 地址时给出提示
+// warnIPv6Unreachable warns when only IPv4 is listened on but IPv6 addresses were found
+func warnIPv6Unreachable(ips []netif.IpInfo) {
+	if net.ParseIP(bindAddr).To4() == nil {
+		return
+	}
+	for _, ip := range ips {
+		if ip.Family == netif.FamilyIPv6 {
+			network.LogInfo("提示: 监听地址 %s 仅支持 IPv4，IPv6 地址无法访问（使用 -bind :: 同时监听 IPv6）", bindAddr)
+			return
 		}
 	}
 }
@@ -579,6 +758,8 @@ func convertIps(ips []netif.IpInfo) []interface{} {
 			"ip":        ip.IP,
 			"nicType":   ip.NicType,
 			"ifaceName": ip.IfaceName,
+			"family":    ip.Family,
+			"zone":      ip.Zone,
 		}
 	}
 	return result
@@ -597,6 +778,11 @@ func waitForExit(httpServer *network.HttpServer) {
 	// 清理资源 / Clean up resources
 	network.LogInfo("清理资源...")
 	contentState.Clear()
+	if err := contentState.Close(); err != nil {
+		network.LogInfo("关闭历史记录失败: %v", err)
+	}
+	closeTyping()
+	stopMDNS()
 	network.LogInfo("资源清理完成，耗时: %v", time.Since(exitStartTime))
 
 	// 关闭所有 SSE 连接 / Close all SSE connections
@@ -0,0 +1,123 @@
+// Package main 通过 mDNS 发布服务：手机端可使用 <hostname>.local 访问，访问地址变化后自动更新记录
+// Package main publishes the service over mDNS: phones can use <hostname>.local, and the records follow the access address
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"airinputlan/internal/mdns"
+	"airinputlan/internal/network"
+)
+
+// MDNSServiceType 发布的 DNS-SD 服务类型
+// MDNSServiceType is the published DNS-SD service type
+const MDNSServiceType = "_airinputlan._tcp"
+
+var (
+	mdnsMu        sync.Mutex
+	mdnsResponder *mdns.Responder // 未启用或启动失败时为 nil / nil when disabled or failed to start
+)
+
+// startMDNS 在当前访问地址所在的网卡上发布主机名和服务
+// startMDNS publishes the host name and service on the interface of the current access address
+func startMDNS(port int, scheme string) {
+	if !mdnsEnabled {
+		return
+	}
+	instance := "AirInputLan"
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		instance += " @ " + hostname
+	}
+	iface, addrs := mdnsAddresses()
+	responder, err := mdns.Start(mdns.Service{
+		Host:     mdnsHostname,
+		Instance: instance,
+		Type:     MDNSServiceType,
+		Port:     port,
+		TXT:      []string{"scheme=" + scheme, "path=/"},
+	}, iface, addrs)
+	if err != nil {
+		network.LogInfo("mDNS 发布失败，请使用 IP 地址访问: %v", err)
+		return
+	}
+
+	mdnsMu.Lock()
+	mdnsResponder = responder
+	mdnsMu.Unlock()
+	network.LogInfo("mDNS 已发布: %s（网卡: %s）", responder.HostName(), iface)
+}
+
+// mdnsAddresses 返回当前访问地址所在的网卡及该网卡上的全部地址
+// mdnsAddresses returns the interface of the current access address and all of its addresses
+func mdnsAddresses() (string, []net.IP) {
+	addressMu.RLock()
+	defer addressMu.RUnlock()
+	iface := localIPs[0].IfaceName
+	var addrs []net.IP
+	for _, info := range localIPs {
+		if info.IfaceName != iface {
+			continue
+		}
+		if ip := net.ParseIP(info.IP); ip != nil {
+			addrs = append(addrs, ip)
+		}
+	}
+	return iface, addrs
+}
+
+// updateMDNS 在访问地址或网卡地址变化后更新发布的记录
+// updateMDNS refreshes the published records after the access address or interface addresses change
+func updateMDNS() {
+	mdnsMu.Lock()
+	defer mdnsMu.Unlock()
+	if mdnsResponder == nil {
+		return
+	}
+	iface, addrs := mdnsAddresses()
+	if err := mdnsResponder.SetInterface(iface, addrs); err != nil {
+		network.LogInfo("mDNS 更新失败: %v", err)
+	}
+}
+
+// stopMDNS 撤销发布的记录
+// stopMDNS withdraws the published records
+func stopMDNS() {
+	mdnsMu.Lock()
+	defer mdnsMu.Unlock()
+	if mdnsResponder != nil {
+		mdnsResponder.Stop()
+		mdnsResponder = nil
+	}
+}
+
+// mdnsHost 返回发布的 .local 主机名，未发布时返回空字符串
+// mdnsHost returns the published .local host name, or "" when nothing is published
+func mdnsHost() string {
+	mdnsMu.Lock()
+	defer mdnsMu.Unlock()
+	if mdnsResponder == nil {
+		return ""
+	}
+	return mdnsResponder.HostName()
+}
+
+// handleMDNSInfo 处理 /api/mdns：返回发布的主机名以及二维码是否使用主机名
+// handleMDNSInfo handles /api/mdns: returns the published host name and whether the QR code uses it
+func handleMDNSInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	host := mdnsHost()
+	settingsMu.RLock()
+	useHostname := qrHostname
+	settingsMu.RUnlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled": host != "",
+		"host":    host,
+		"qr":      useHostname && host != "",
+	})
+}
@@ -0,0 +1,260 @@
+// Package main 提供配置文件的加载与热重载
+// 优先级：内置默认值 < 配置文件 < 环境变量 < 命令行参数
+// Package main provides loading and hot reloading of the config file
+// Precedence: built-in defaults < config file < environment variables < command line flags
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"airinputlan/internal/clipboard"
+	"airinputlan/internal/config"
+	"airinputlan/internal/filewatch"
+	"airinputlan/internal/network"
+	"airinputlan/internal/state"
+	"airinputlan/internal/textfilter"
+)
+
+// ConfigPollInterval 配置文件检查间隔
+// ConfigPollInterval is how often the config file is checked
+const ConfigPollInterval = time.Second
+
+var (
+	configPath  string        // 配置文件路径
+	appConfig   config.Config // 当前生效的配置（已合并环境变量和命令行参数）
+	settingsMu  sync.RWMutex  // 保护 appConfig 及可热重载的全局变量
+	explicitSet map[string]bool
+)
+
+// flagOverride 描述一个可覆盖配置文件的命令行参数及其环境变量
+// flagOverride describes a command line flag (and its environment variable) that overrides the config file
+type flagOverride struct {
+	flag  string
+	env   string
+	apply func(cfg *config.Config) // 将参数值写入配置 / Writes the flag value into the config
+}
+
+// flagOverrides 列出所有与配置文件对应的命令行参数
+// flagOverrides lists every command line flag that maps to a config file setting
+var flagOverrides = []flagOverride{
+	{"port", "PORT", func(c *config.Config) { c.Server.Port = listenPort }},
+	{"bind", "BIND", func(c *config.Config) { c.Server.Bind = bindAddr }},
+	{"iface", "IFACE", func(c *config.Config) { c.Server.Iface = ifaceName }},
+	{"ip", "IP", func(c *config.Config) { c.Server.IP = preferredIP }},
+	{"no-browser", "NO_BROWSER", func(c *config.Config) { c.Server.NoBrowser = noBrowser }},
+	{"tls", "TLS", func(c *config.Config) { c.Server.TLS = tlsMode }},
+	{"mdns", "MDNS", func(c *config.Config) { c.Server.MDNS = mdnsEnabled }},
+	{"hostname", "HOSTNAME", func(c *config.Config) { c.Server.Hostname = mdnsHostname }},
+	{"qr-hostname", "QR_HOSTNAME", func(c *config.Config) { c.Server.QRHostname = qrHostname }},
+	{"max-phones", "MAX_PHONES", func(c *config.Config) { c.Server.MaxPhones = maxPhones }},
+	{"segment-interval", "SEGMENT_INTERVAL", func(c *config.Config) { c.Segment.Interval = config.Duration(segmentInterval) }},
+	{"max-cards", "MAX_CARDS", func(c *config.Config) { c.Segment.MaxCards = maxCardCount }},
+	{"max-card-length", "MAX_CARD_LENGTH", func(c *config.Config) { c.Segment.MaxCardLength = maxCardLength }},
+	{"split", "SPLIT", func(c *config.Config) { c.Segment.Split = splitMode }},
+	{"filter-profile", "FILTER_PROFILE", func(c *config.Config) { c.Filter.Profile = filterProfile }},
+	{"history", "HISTORY", func(c *config.Config) { c.History.Enabled = historyEnabled }},
+	{"history-file", "HISTORY_FILE", func(c *config.Config) { c.History.File = historyFile }},
+	{"history-retention", "HISTORY_RETENTION", func(c *config.Config) { c.History.Retention = config.Duration(historyRetention) }},
+	{"dictionary", "DICTIONARY", func(c *config.Config) { c.Dictionary.File = dictionaryFile }},
+	{"dictionary-live", "DICTIONARY_LIVE", func(c *config.Config) { c.Dictionary.Live = dictionaryLive }},
+	{"clipboard", "CLIPBOARD", func(c *config.Config) { c.Output.Clipboard = clipboardName }},
+	{"auto-copy", "AUTO_COPY", func(c *config.Config) { c.Output.AutoCopy = autoCopy }},
+	{"inject", "INJECT", func(c *config.Config) { c.Output.Inject = injectMode }},
+	{"inject-backend", "INJECT_BACKEND", func(c *config.Config) { c.Output.InjectBackend = injectBackendName }},
+}
+
+// loadSettings 在 flag.Parse 之后调用：读取配置文件，合并环境变量和命令行参数，并写入全局变量
+// loadSettings is called after flag.Parse: reads the config file, merges env vars and flags, and fills the globals
+func loadSettings() error {
+	explicitSet = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicitSet[f.Name] = true
+	})
+
+	if configPath == "" {
+		path, err := config.DefaultPath()
+		if err != nil {
+			return err
+		}
+		configPath = path
+	}
+	fileConfig, err := config.LoadOrCreate(configPath)
+	if err != nil {
+		return err
+	}
+
+	cfg := mergeOverrides(fileConfig)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	appConfig = cfg
+	applyGlobals(cfg)
+	return nil
+}
+
+// mergeOverrides 用命令行参数和环境变量覆盖配置文件中的值
+// mergeOverrides overrides config file values with command line flags and environment variables
+func mergeOverrides(cfg config.Config) config.Config {
+	for _, o := range flagOverrides {
+		if overridden(o.flag) {
+			o.apply(&cfg)
+		}
+	}
+	return cfg
+}
+
+// overridden 判断命令行参数是否显式指定（或通过环境变量设置），此时配置文件中的值不生效
+// overridden reports whether the flag was given explicitly (or via its env var), which masks the config file value
+func overridden(name string) bool {
+	for _, o := range flagOverrides {
+		if o.flag == name {
+			_, fromEnv := os.LookupEnv(envPrefix + o.env)
+			return fromEnv || explicitSet[name]
+		}
+	}
+	return false
+}
+
+// applyGlobals 将配置写入启动时使用的全局变量
+// applyGlobals copies the config into the globals used during startup
+func applyGlobals(cfg config.Config) {
+	listenPort = cfg.Server.Port
+	bindAddr = cfg.Server.Bind
+	ifaceName = cfg.Server.Iface
+	preferredIP = cfg.Server.IP
+	noBrowser = cfg.Server.NoBrowser
+	tlsMode = cfg.Server.TLS
+	mdnsEnabled = cfg.Server.MDNS
+	mdnsHostname = cfg.Server.Hostname
+	qrHostname = cfg.Server.QRHostname
+	maxPhones = cfg.Server.MaxPhones
+	segmentInterval = time.Duration(cfg.Segment.Interval)
+	maxCardCount = cfg.Segment.MaxCards
+	maxCardLength = cfg.Segment.MaxCardLength
+	splitMode = cfg.Segment.Split
+	filterProfile = cfg.Filter.Profile
+	historyEnabled = cfg.History.Enabled
+	historyFile = cfg.History.File
+	historyRetention = time.Duration(cfg.History.Retention)
+	dictionaryFile = cfg.Dictionary.File
+	dictionaryLive = cfg.Dictionary.Live
+	clipboardName = cfg.Output.Clipboard
+	autoCopy = cfg.Output.AutoCopy
+	injectMode = cfg.Output.Inject
+	injectBackendName = cfg.Output.InjectBackend
+}
+
+// filterOptions 返回配置对应的内容过滤选项
+// filterOptions returns the content filtering options for the config
+func filterOptions(cfg config.Config) state.FilterOptions {
+	// 方案和过滤器名称已在 Validate 中检查 / The profile and filter names were checked by Validate
+	names, _ := cfg.Filter.Filters()
+	pipeline, _ := textfilter.New(names)
+	return state.FilterOptions{
+		Pipeline:        pipeline,
+		DropMeaningless: cfg.Filter.DropMeaningless,
+	}
+}
+
+// watchSettings 开始监听配置文件的修改
+// watchSettings starts watching the config file for changes
+func watchSettings() *filewatch.Poller {
+	network.LogInfo("配置文件: %s（保存后自动重新加载）", configPath)
+	return config.Watch(configPath, ConfigPollInterval, handleConfigChange)
+}
+
+// handleConfigChange 配置文件变化时应用可实时生效的修改，并通过 SSE 通知电脑端
+// handleConfigChange applies the live changes when the config file changes and notifies the PC page over SSE
+func handleConfigChange(fileConfig config.Config, err error) {
+	if err != nil {
+		network.LogInfo("配置文件重新加载失败: %v", err)
+		broadcastConfigReload(nil, nil, err)
+		return
+	}
+
+	cfg := mergeOverrides(fileConfig)
+	if err := cfg.Validate(); err != nil {
+		network.LogInfo("配置文件重新加载失败: %v", err)
+		broadcastConfigReload(nil, nil, err)
+		return
+	}
+
+	settingsMu.Lock()
+	live, restart := config.Diff(appConfig, cfg)
+	old := appConfig
+	appConfig = cfg
+	settingsMu.Unlock()
+
+	if len(live) == 0 && len(restart) == 0 {
+		return
+	}
+
+	contentState.SetSegmentInterval(time.Duration(cfg.Segment.Interval))
+	contentState.SetMaxCardCount(cfg.Segment.MaxCards)
+	contentState.SetMaxCardLength(cfg.Segment.MaxCardLength)
+	contentState.SetSplitMode(state.SplitMode(cfg.Segment.Split))
+	contentState.SetFilter(filterOptions(cfg))
+	contentState.SetDictionaryLive(cfg.Dictionary.Live)
+	sseServer.SetMaxMobileDevices(cfg.Server.MaxPhones)
+	if cfg.Server.Iface != old.Server.Iface || cfg.Server.IP != old.Server.IP {
+		if _, err := selectAddress(cfg.Server.Iface, cfg.Server.IP, false); err != nil {
+			network.LogInfo("切换访问地址失败: %v", err)
+		}
+	}
+
+	settingsMu.Lock()
+	autoCopy = cfg.Output.AutoCopy
+	qrHostname = cfg.Server.QRHostname
+	if cfg.Output.Clipboard != old.Output.Clipboard {
+		backend, err := clipboard.New(cfg.Output.Clipboard)
+		if err != nil {
+			network.LogInfo("服务端复制不可用: %v", err)
+			backend = nil
+		}
+		clipboardName = cfg.Output.Clipboard
+		clipboardBackend = backend
+	}
+	settingsMu.Unlock()
+	if cfg.Server.QRHostname != old.Server.QRHostname {
+		printConnectInfo()
+	}
+
+	if len(live) > 0 {
+		network.LogInfo("配置已重新加载，已生效: %s", strings.Join(live, ", "))
+	}
+	if len(restart) > 0 {
+		network.LogInfo("以下配置需要重启后生效: %s", strings.Join(restart, ", "))
+	}
+	broadcastConfigReload(live, restart, nil)
+}
+
+// broadcastConfigReload 通知电脑端配置已重新加载
+// broadcastConfigReload tells the PC page that the config was reloaded
+func broadcastConfigReload(live, restart []string, err error) {
+	result := map[string]interface{}{
+		"path":    configPath,
+		"live":    live,
+		"restart": restart,
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	payload, _ := json.Marshal(result)
+	sseServer.SendToRole(network.RolePC, network.Message{
+		Type:    network.TypeConfigReload,
+		Payload: payload,
+	})
+}
+
+// outputSettings 返回当前的剪贴板后端和自动复制开关
+// outputSettings returns the current clipboard backend and auto-copy switch
+func outputSettings() (clipboard.Backend, bool) {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return clipboardBackend, autoCopy
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"airinputlan/internal/config"
+)
+
+// parseTestFlags 像 main 一样定义 -port、-split 和 -segment-interval（默认值来自环境变量）并解析 args
+// parseTestFlags defines -port, -split and -segment-interval the way main does (defaults from env vars) and parses args
+func parseTestFlags(t *testing.T, args []string) {
+	t.Helper()
+	oldPort, oldSplit, oldInterval, oldSet := listenPort, splitMode, segmentInterval, explicitSet
+	t.Cleanup(func() {
+		listenPort, splitMode, segmentInterval, explicitSet = oldPort, oldSplit, oldInterval, oldSet
+	})
+
+	def := config.Default()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.IntVar(&listenPort, "port", envInt("PORT", def.Server.Port), "")
+	fs.StringVar(&splitMode, "split", envString("SPLIT", def.Segment.Split), "")
+	fs.DurationVar(&segmentInterval, "segment-interval", envDuration("SEGMENT_INTERVAL", time.Duration(def.Segment.Interval)), "")
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	explicitSet = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicitSet[f.Name] = true
+	})
+}
+
+// unsetEnv 在测试期间清除环境变量，结束后恢复
+// unsetEnv clears an environment variable for the test and restores it afterwards
+func unsetEnv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+func TestMergeOverridesPrecedence(t *testing.T) {
+	// 配置文件中的值 / Values in the config file
+	file := config.Default()
+	file.Server.Port = 6000
+	file.Segment.Split = "fixed"
+	file.Segment.Interval = config.Duration(3 * time.Second)
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantPort     int
+		wantSplit    string
+		wantInterval time.Duration
+	}{
+		{
+			name:         "config file over defaults",
+			wantPort:     6000,
+			wantSplit:    "fixed",
+			wantInterval: 3 * time.Second,
+		},
+		{
+			name:         "env over config file",
+			env:          map[string]string{"AIRINPUT_PORT": "7000", "AIRINPUT_SEGMENT_INTERVAL": "500ms"},
+			wantPort:     7000,
+			wantSplit:    "fixed",
+			wantInterval: 500 * time.Millisecond,
+		},
+		{
+			name:         "flag over config file",
+			args:         []string{"-split", "sentence"},
+			wantPort:     6000,
+			wantSplit:    "sentence",
+			wantInterval: 3 * time.Second,
+		},
+		{
+			name:         "flag over env",
+			env:          map[string]string{"AIRINPUT_PORT": "7000"},
+			args:         []string{"-port", "8000"},
+			wantPort:     8000,
+			wantSplit:    "fixed",
+			wantInterval: 3 * time.Second,
+		},
+		{
+			// 显式设置为默认值同样覆盖配置文件 / Explicitly setting the default still masks the config file
+			name:         "env equal to the default",
+			env:          map[string]string{"AIRINPUT_SPLIT": "sentence"},
+			wantPort:     6000,
+			wantSplit:    "sentence",
+			wantInterval: 3 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"AIRINPUT_PORT", "AIRINPUT_SPLIT", "AIRINPUT_SEGMENT_INTERVAL"} {
+				if value, ok := tt.env[name]; ok {
+					t.Setenv(name, value)
+				} else {
+					unsetEnv(t, name)
+				}
+			}
+			parseTestFlags(t, tt.args)
+
+			cfg := mergeOverrides(file)
+			if cfg.Server.Port != tt.wantPort {
+				t.Errorf("port = %d, want %d", cfg.Server.Port, tt.wantPort)
+			}
+			if cfg.Segment.Split != tt.wantSplit {
+				t.Errorf("split = %q, want %q", cfg.Segment.Split, tt.wantSplit)
+			}
+			if got := time.Duration(cfg.Segment.Interval); got != tt.wantInterval {
+				t.Errorf("interval = %v, want %v", got, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestEnvParsing(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"1", true}, {"true", true}, {"yes", true}, {"on", true},
+		{"0", false}, {"false", false}, {"no", false}, {"off", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("AIRINPUT_TEST_BOOL", tt.value)
+		if got := envBool("TEST_BOOL", !tt.want); got != tt.want {
+			t.Errorf("envBool(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	unsetEnv(t, "AIRINPUT_TEST_VALUE")
+	if envString("TEST_VALUE", "def") != "def" || envInt("TEST_VALUE", 5) != 5 || envDuration("TEST_VALUE", time.Second) != time.Second {
+		t.Error("unset env var did not return the default")
+	}
+	// 设置为空字符串也算显式设置 / An empty value still counts as set
+	t.Setenv("AIRINPUT_TEST_VALUE", "")
+	if got := envString("TEST_VALUE", "def"); got != "" {
+		t.Errorf("envString = %q, want the empty value", got)
+	}
+}
@@ -0,0 +1,108 @@
+// Package main 提供键盘输入注入：将卡片或实时文本直接输入到电脑上获得焦点的应用
+// Package main provides keystroke injection: types cards or live text straight into the focused application on the PC
+package main
+
+import (
+	"sync"
+
+	"airinputlan/internal/inject"
+	"airinputlan/internal/network"
+	"airinputlan/internal/state"
+)
+
+// 输入注入模式 / Injection modes
+const (
+	InjectOff  = "off"  // 不注入，仅在浏览器中显示卡片 / No injection; cards are only shown in the browser
+	InjectCard = "card" // 每张卡片完成时输入整张卡片 / Type each card when it is finished
+	InjectLive = "live" // 实时输入，随手机端输入同步修改 / Type live, following every change on the phone
+)
+
+// setupTyping 根据命令行参数创建输入注入后端；失败时禁用注入
+// setupTyping creates the injection backend from the command line flags; injection is disabled on failure
+func setupTyping() {
+	switch injectMode {
+	case InjectOff:
+		return
+	case InjectCard, InjectLive:
+	default:
+		network.LogInfo("未知的键盘输入模式 %q，已禁用", injectMode)
+		injectMode = InjectOff
+		return
+	}
+
+	sink, err := inject.New(injectBackendName)
+	if err != nil {
+		network.LogInfo("键盘输入不可用: %v", err)
+		injectMode = InjectOff
+		return
+	}
+	typist = inject.NewTypist(sink)
+	network.LogInfo("键盘输入已启用，模式: %s，后端: %s", injectMode, sink.Name())
+}
+
+// liveDevice 实时模式下正在输入的设备（CardSource.Key()），其他设备的实时内容只显示不输入
+// liveDevice is the device being typed in live mode (CardSource.Key()); other devices' live text is shown but not typed
+var (
+	liveMu     sync.Mutex
+	liveDevice string
+)
+
+// typeLiveContent 实时模式下将设备的当前输入同步到焦点应用；同一时间只跟随一个设备，避免多台手机的输入交错
+// typeLiveContent syncs a device's current input to the focused application in live mode; only one device is followed at a time so phones do not interleave
+func typeLiveContent(key, content string) {
+	if injectMode != InjectLive || typist == nil {
+		return
+	}
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	if liveDevice == "" {
+		liveDevice = key
+	}
+	if key != liveDevice {
+		network.LogDebug("实时输入被 %s 占用，忽略 %s 的输入", liveDevice, key)
+		return
+	}
+	if err := typist.Update(content); err != nil {
+		network.LogInfo("实时输入失败: %v", err)
+	}
+}
+
+// typeSegment 在设备分段完成时调用（key 为空表示所有设备）：卡片模式输入新卡片，实时模式结束当前段落
+// typeSegment is called when a device's segment finishes (an empty key means every device): card mode types the new cards, live mode ends the current segment
+func typeSegment(key string, cards []state.Card) {
+	if typist == nil {
+		return
+	}
+	switch injectMode {
+	case InjectCard:
+		if len(cards) == 0 {
+			return
+		}
+		text := joinCardTexts(cards)
+		if err := typist.Type(text); err != nil {
+			network.LogInfo("键盘输入失败: %v", err)
+			return
+		}
+		network.LogDebug("已输入卡片: %s", text)
+	case InjectLive:
+		liveMu.Lock()
+		defer liveMu.Unlock()
+		if key != "" && liveDevice != "" && key != liveDevice {
+			return
+		}
+		// 文本已经实时输入，保留在应用中；下一个开始输入的设备接管实时输入 / The text was already typed live and stays; the next device to type takes over
+		typist.Reset()
+		liveDevice = ""
+	}
+}
+
+// closeTyping 释放输入注入后端（如 uinput 虚拟键盘）
+// closeTyping releases the injection backend (such as the uinput virtual keyboard)
+func closeTyping() {
+	if typist == nil {
+		return
+	}
+	if err := typist.Close(); err != nil {
+		network.LogInfo("关闭键盘输入失败: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"airinputlan/internal/inject"
+	"airinputlan/internal/network"
+)
+
+const (
+	phoneA = "dev_00000000000000aa"
+	phoneB = "dev_00000000000000bb"
+)
+
+// setupTypingTest 在 setupTestServer 的基础上启用指定模式的键盘输入，返回记录按键的内存后端
+// setupTypingTest enables keystroke injection in the given mode on top of setupTestServer and returns the memory sink recording the keys
+func setupTypingTest(t *testing.T, mode string) *inject.Memory {
+	t.Helper()
+	setupTestServer(t)
+	oldMode, oldTypist := injectMode, typist
+	t.Cleanup(func() {
+		injectMode, typist = oldMode, oldTypist
+		liveDevice = ""
+	})
+
+	sink := inject.NewMemory()
+	injectMode, typist, liveDevice = mode, inject.NewTypist(sink), ""
+	return sink
+}
+
+// segmentFrom 以指定设备的身份调用 /api/segment
+// segmentFrom calls /api/segment as the given device
+func segmentFrom(t *testing.T, deviceID, content string) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/segment", strings.NewReader(`{"content":"`+content+`"}`))
+	r.RemoteAddr = "192.168.1.20:40000"
+	r.AddCookie(&http.Cookie{Name: network.DeviceCookieName, Value: deviceID})
+	w := httptest.NewRecorder()
+	handleSegmentRequest(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("segment %q: status = %d, want %d", content, w.Code, http.StatusOK)
+	}
+}
+
+// inputFrom 模拟设备发送一段增量输入 / inputFrom simulates a device sending a piece of incremental input
+func inputFrom(deviceID, content string) {
+	handleMessage(content, network.MessageSource{IP: "192.168.1.20", DeviceID: deviceID})
+}
+
+func TestCardInjection(t *testing.T) {
+	sink := setupTypingTest(t, InjectCard)
+
+	// 卡片模式下实时输入不会触发按键 / Live input sends no keys in card mode
+	inputFrom(phoneA, "今天天气")
+	segmentFrom(t, phoneA, "今天天气很好。")
+	segmentFrom(t, phoneB, "明天见")
+	segmentFrom(t, phoneA, "   ")
+
+	want := []string{"type:今天天气很好。", "type:明天见"}
+	if got := sink.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestLiveInjection(t *testing.T) {
+	sink := setupTypingTest(t, InjectLive)
+
+	inputFrom(phoneA, "你好")
+	inputFrom(phoneA, "世界")
+	// 另一台手机的实时输入只显示不输入 / Another phone's live input is shown but not typed
+	inputFrom(phoneB, "别的")
+	inputFrom(phoneA, "。")
+
+	// 分段后文本留在应用中，下一个开始输入的设备接管实时输入
+	// After the segment the text stays in the application and the next device to type takes over
+	segmentFrom(t, phoneA, "你好世界。")
+	// 手机 B 接管后输入其完整的当前输入 / Phone B takes over and its whole current input is typed
+	inputFrom(phoneB, "再见")
+
+	want := []string{"type:你好", "type:世界", "type:。", "type:别的再见"}
+	if got := sink.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if got, want := sink.Text(), "你好世界。别的再见"; got != want {
+		t.Errorf("typed text = %q, want %q", got, want)
+	}
+}
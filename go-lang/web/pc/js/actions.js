@@ -79,26 +79,37 @@ function renderCardContent(text, templateId) {
 /**
  * 浏览器复制
  * 使用 navigator.clipboard API 复制文本到剪贴板
+ * 浏览器不支持或拒绝时（如非安全上下文）改用服务端复制
  */
 function copyToBrowser(text) {
+    if (!navigator.clipboard) {
+        copyToServer(text);
+        return;
+    }
     navigator.clipboard.writeText(text).catch(err => {
-        console.error('浏览器复制失败:', err);
+        console.error('浏览器复制失败，改用服务端复制:', err);
+        copyToServer(text);
     });
 }
 
 /**
  * 服务端复制
- * 通知服务端执行剪贴板操作（占位实现）
- * 未来将实现 WebSocket/HTTP 通信，调用 Go 服务端剪贴板 API
+ * 通过 HTTP POST 调用 /api/copy，由 Go 服务端写入系统剪贴板
  */
 function copyToServer(text) {
-    // 占位实现：输出控制台消息
-    console.log('[服务端复制]', text);
-
-    // 未来实现：
-    // 1. 通过 WebSocket 通知服务端
-    // 2. 或通过 HTTP POST 调用 /api/copy 端点
-    // 3. 服务端使用 github.com/atotto/clipboard 库执行系统剪贴板操作
+    return fetch('/api/copy', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ text: text })
+    }).then(response => {
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        console.log('[服务端复制]', text);
+    }).catch(err => {
+        console.error('服务端复制失败:', err);
+        showToast('复制失败：服务端剪贴板不可用', 'error');
+    });
 }
 
 /**
@@ -125,6 +136,57 @@ function saveCardEdit(card, newText, originalText) {
     card.classList.remove('editing');
 }
 
+/**
+ * 同步卡片文本到服务端
+ * 服务端保存后会广播 card_updated，其他页面随之更新
+ * @param {HTMLElement} card - 卡片元素
+ * @param {string} text - 新文本内容
+ */
+function syncCardText(card, text) {
+    const cardId = card.parentElement && card.parentElement.dataset.cardId;
+    if (!cardId) {
+        // 旧版本地卡片没有服务端 ID，无需同步
+        return;
+    }
+    fetch(`/api/cards/${encodeURIComponent(cardId)}`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ text: text })
+    }).then(response => {
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+    }).catch(err => {
+        console.error('同步卡片失败:', err);
+        showToast('卡片修改未能保存到服务端', 'warning');
+    });
+}
+
+/**
+ * 删除卡片
+ * 有服务端 ID 时通过 API 删除，由 card_deleted 消息移除所有页面中的卡片
+ * @param {HTMLElement} cardWrapper - 卡片包装器元素
+ */
+function deleteCard(cardWrapper) {
+    const cardId = cardWrapper.dataset.cardId;
+    if (!cardId) {
+        cardWrapper.remove();
+        return;
+    }
+    fetch(`/api/cards/${encodeURIComponent(cardId)}`, {
+        method: 'DELETE'
+    }).then(response => {
+        if (!response.ok && response.status !== 404) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        // 不等待广播，立即从当前页面移除
+        cardWrapper.remove();
+    }).catch(err => {
+        console.error('删除卡片失败:', err);
+        showToast('删除卡片失败', 'error');
+    });
+}
+
 /**
  * 调用 Ollama 本地 API（流式输出）
  * @param {string} prompt - 提示词
@@ -9,6 +9,7 @@ window.addEventListener('unhandledrejection', (event) => {
 
 // 防抖定时器
 let updateTimeout = null;
+let currentInputs = new Map(); // 各设备的当前输入（键为输入来源标识）
 
 // 主题切换
 function toggleTheme() {
@@ -22,10 +23,15 @@ function toggleTheme() {
 }
 
 // 添加卡片
-function addCard(text) {
+// card 为服务端发送的卡片对象（可选，包含 id 等信息）
+function addCard(text, cardData = null) {
     console.log('添加卡片:', text);
+    // 完整状态中已包含的卡片不重复添加
+    if (cardData && cardData.id && findCardWrapper(cardData.id)) {
+        return;
+    }
     const container = document.getElementById('history-cards');
-    const card = createCard(text);
+    const card = createCard(text, cardData);
     container.appendChild(card);
 
     // 限制卡片数量
@@ -40,10 +46,56 @@ function addCard(text) {
     EventBus.emit('card:added', card, text);
 }
 
+// 恢复历史卡片（服务端回放，不触发 card:added，避免重复 AI 修正）
+function restoreCards(cards) {
+    console.log('恢复历史卡片:', cards.length);
+    const container = document.getElementById('history-cards');
+    container.innerHTML = '';
+    cards.forEach((cardData) => {
+        container.appendChild(createCard(cardData.text, cardData));
+    });
+
+    // 滚动到底部
+    container.scrollTop = container.scrollHeight;
+}
+
+// 按 ID 更新卡片内容（服务端广播 card_updated 时调用）
+function updateCardById(cardData) {
+    const cardWrapper = findCardWrapper(cardData.id);
+    if (!cardWrapper) {
+        return;
+    }
+    const card = cardWrapper.querySelector('.card');
+    // 正在编辑的卡片不覆盖，避免打断输入
+    if (card.classList.contains('editing') || card.dataset.originalText === cardData.text) {
+        return;
+    }
+    card.dataset.originalText = cardData.text;
+    card.querySelector('.card-content').innerHTML = renderCardContent(cardData.text, aiConfig.aiPromptTemplateId);
+}
+
+// 按 ID 移除卡片（服务端广播 card_deleted 时调用）
+function removeCardById(cardId) {
+    const cardWrapper = findCardWrapper(cardId);
+    if (cardWrapper) {
+        cardWrapper.remove();
+    }
+}
+
+// 按服务端卡片 ID 查找卡片包装器
+function findCardWrapper(cardId) {
+    const container = document.getElementById('history-cards');
+    return Array.from(container.children).find((wrapper) => wrapper.dataset.cardId === cardId) || null;
+}
+
 // 创建卡片
-function createCard(text) {
+function createCard(text, cardData = null) {
     // 创建卡片包装器
     const cardWrapper = document.createElement('div');
+    if (cardData && cardData.id) {
+        // 记录服务端卡片 ID，便于按 ID 引用
+        cardWrapper.dataset.cardId = cardData.id;
+    }
     cardWrapper.style.display = 'flex';
     cardWrapper.style.alignItems = 'center';
     cardWrapper.style.gap = '10px';
@@ -74,6 +126,14 @@ function createCard(text) {
 
     card.appendChild(cardContent);
 
+    // 来源设备标签（多台手机同时连接时区分输入来源）
+    if (cardData && cardData.deviceName) {
+        const deviceTag = document.createElement('div');
+        deviceTag.className = 'card-device';
+        deviceTag.textContent = cardData.deviceName;
+        card.insertBefore(deviceTag, cardContent);
+    }
+
     // 保存原始文本，用于编辑
     card.dataset.originalText = text;
 
@@ -95,9 +155,20 @@ function createCard(text) {
         enterEditMode(card, currentText);
     };
 
-    // 将AI按钮和卡片添加到包装器
+    // 删除按钮 - 放在卡片外面
+    const deleteButton = document.createElement('button');
+    deleteButton.className = 'ai-correct-button card-delete-button';
+    deleteButton.textContent = '🗑️';
+    deleteButton.title = '删除';
+    deleteButton.onclick = (e) => {
+        e.stopPropagation();
+        deleteCard(cardWrapper);
+    };
+
+    // 将AI按钮、卡片和删除按钮添加到包装器
     cardWrapper.appendChild(aiButton);
     cardWrapper.appendChild(card);
+    cardWrapper.appendChild(deleteButton);
 
     // 触发 card:created 事件
     EventBus.emit('card:created', card, text);
@@ -342,14 +413,39 @@ function showControlPanel() {
 }
 
 // 更新当前输入（带防抖）
-function updateCurrentInput(text) {
+// source 为服务端消息的 payload（含 key 和 deviceName）；清空时不带 source 表示清空所有设备
+function updateCurrentInput(text, source = null) {
+    const key = (source && source.key) || '';
+    if (text) {
+        currentInputs.set(key, { name: (source && source.deviceName) || '', text: text });
+    } else if (source) {
+        currentInputs.delete(key);
+    } else {
+        currentInputs.clear();
+    }
+
     // 清除之前的定时器
     if (updateTimeout) {
         clearTimeout(updateTimeout);
     }
 
     // 防抖：50ms 后更新
-    updateTimeout = setTimeout(() => {
-        document.getElementById('current-input').textContent = text;
-    }, 50); // 50ms 防抖
+    updateTimeout = setTimeout(renderCurrentInputs, 50); // 50ms 防抖
+}
+
+// 显示各设备的当前输入：只有一台设备时与单手机时相同，多台时每行以设备名称开头
+function renderCurrentInputs() {
+    const inputs = Array.from(currentInputs.values());
+    let text = '';
+    if (inputs.length === 1) {
+        text = inputs[0].text;
+    } else if (inputs.length > 1) {
+        text = inputs.map(input => input.name ? `${input.name}：${input.text}` : input.text).join('\n');
+    }
+    document.getElementById('current-input').textContent = text;
+}
+
+// 卡片对应的输入来源标识（与服务端 CardSource.Key 一致）
+function cardInputKey(cardData) {
+    return cardData.deviceId || cardData.sourceClientId || cardData.sourceIp || '';
 }
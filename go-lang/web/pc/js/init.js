@@ -1,7 +1,13 @@
 // 全局状态变量
 let isConnected = false;
 let eventSource = null;
+let lastEventId = ''; // 最后收到的消息 ID，重建连接时带上以补收断线期间的消息
 let reconnectInterval = null;
+let pairingPin = ''; // 当前配对码
+let qrIP = ''; // 当前二维码使用的 IP
+let qrPort = ''; // 当前二维码使用的端口
+let mdnsInfo = { enabled: false }; // mDNS 主机名信息（host: 如 airinput.local，qr: 二维码是否使用主机名）
+let connectedDevices = []; // 已连接的手机端（来自服务端 devices 消息）
 
 // AI 配置默认值
 const DEFAULT_AI_CONFIG = {
@@ -131,6 +137,18 @@ function registerEventListeners() {
         }
     });
 
+    // 监听卡片编辑结束事件 - 同步到服务端
+    EventBus.on('card:edit:end', (card, newText, originalText) => {
+        if (newText && newText !== originalText) {
+            syncCardText(card, newText);
+        }
+    });
+
+    // 监听 AI 修正完成事件 - 同步到服务端
+    EventBus.on('ai:process:completed', (card, fullText) => {
+        syncCardText(card, fullText);
+    });
+
     // 监听 AI 测试开始事件
     EventBus.on('ai:test:start', (provider) => {
         // 事件触发，日志已在 actions.js 中输出
@@ -167,13 +185,20 @@ async function loadServerInfo() {
     portInfo.innerHTML = '加载中...';
 
     try {
-        const [ipsRes, portRes] = await Promise.all([
+        const [ipsRes, portRes, pairingRes, tlsRes, mdnsRes] = await Promise.all([
             fetch('/api/ip'),
-            fetch('/api/port')
+            fetch('/api/port'),
+            fetch('/api/pairing'),
+            fetch('/api/tls'),
+            fetch('/api/mdns')
         ]);
 
         const ipsData = await ipsRes.json();
         const portData = await portRes.json();
+        const pairingData = await pairingRes.json();
+        const tlsData = await tlsRes.json();
+        mdnsInfo = mdnsRes.ok ? await mdnsRes.json() : { enabled: false };
+        pairingPin = pairingData.pin || '';
 
         console.log('========== 服务器信息 ==========');
         console.log('IP数据:', ipsData);
@@ -183,6 +208,9 @@ async function loadServerInfo() {
 
         displayIPs(ipsData.ips);
         displayPort(portData.port);
+        displayPairingPin();
+        displayFingerprint(tlsData);
+        displayMDNSHost();
         generateQRCodeForIP(ipsData.ips, portData.port);
     } catch (error) {
         ipList.innerHTML = '加载失败';
@@ -191,9 +219,15 @@ async function loadServerInfo() {
     }
 }
 
+// 返回带 Zone 的地址（IPv6 链路本地地址需要 Zone，如 fe80::1%eth0）
+function ipHost(info) {
+    return info.zone ? `${info.ip}%${info.zone}` : info.ip;
+}
+
 // 显示 IP 列表
 function displayIPs(ips) {
     const container = document.getElementById('ip-list');
+    container.innerHTML = '';
     if (ips && ips.length > 0) {
         if (ips.length === 1) {
             // 只有一个IP，直接显示
@@ -201,7 +235,7 @@ function displayIPs(ips) {
             const strong = document.createElement('strong');
             strong.textContent = 'IP: ';
             container.appendChild(strong);
-            const text = document.createTextNode(ips[0].ip);
+            const text = document.createTextNode(ipHost(ips[0]));
             container.appendChild(text);
         } else {
             // 有多个IP，显示所有IP供选择
@@ -221,27 +255,58 @@ function displayIPs(ips) {
             // 优先显示第一个IP（已按优先级排序：以太网 > USB共享网卡 > WiFi）
             ips.forEach((ip, index) => {
                 const option = document.createElement('option');
-                option.value = ip.ip;
+                option.value = ipHost(ip);
                 option.selected = index === 0;
-                const label = document.createTextNode(`${ip.ip} (${ip.nicType})`);
+                const family = ip.family === 'ipv6' ? ', IPv6' : '';
+                const label = document.createTextNode(`${ipHost(ip)} (${ip.nicType}${family})`);
                 option.appendChild(label);
                 select.appendChild(option);
             });
 
             container.appendChild(select);
 
-            // 监听IP选择变化
+            // 监听IP选择变化：通知服务端切换访问地址（会记住网卡，下次启动继续使用）
             document.getElementById('ip-select').addEventListener('change', function() {
                 const selectedIP = this.value;
                 console.log('用户选择了IP:', selectedIP);
-                // 重新生成二维码
-                const port = document.getElementById('port-info').textContent.replace('端口: ', '');
-                generateQRCodeForIP(selectedIP, port);
+                selectActiveIP(selectedIP);
             });
         }
     }
 }
 
+// 切换服务端的访问地址，成功后重新生成二维码（其他电脑端页面通过 active_ip 消息同步）
+async function selectActiveIP(ip) {
+    try {
+        const response = await fetch('/api/ip/active', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ip })
+        });
+        if (!response.ok) {
+            throw new Error(await response.text() || `HTTP ${response.status}`);
+        }
+        const active = await response.json();
+        applyActiveIP(active);
+    } catch (error) {
+        console.error('切换访问地址失败:', error);
+        showToast('切换访问地址失败', 'error');
+    }
+}
+
+// 应用服务端的当前访问地址：同步下拉框并重新生成二维码
+function applyActiveIP(active) {
+    if (!active || !active.ip) return;
+    const host = ipHost(active);
+    const select = document.getElementById('ip-select');
+    if (select) {
+        select.value = host;
+    }
+    if (host !== qrIP && qrPort) {
+        generateQRCodeForIP(host, qrPort);
+    }
+}
+
 // 显示端口
 function displayPort(port) {
     const portInfo = document.getElementById('port-info');
@@ -253,6 +318,130 @@ function displayPort(port) {
     portInfo.appendChild(text);
 }
 
+// 显示配对码
+function displayPairingPin() {
+    const pinInfo = document.getElementById('pin-info');
+    if (!pinInfo) return;
+    pinInfo.innerHTML = '';
+    if (!pairingPin) return;
+    const strong = document.createElement('strong');
+    strong.textContent = '配对码: ';
+    pinInfo.appendChild(strong);
+    const text = document.createTextNode(pairingPin);
+    pinInfo.appendChild(text);
+}
+
+// 显示 HTTPS 证书指纹，供手机端核对
+function displayFingerprint(tlsData) {
+    const tlsInfo = document.getElementById('tls-info');
+    if (!tlsInfo) return;
+    tlsInfo.innerHTML = '';
+    if (!tlsData || !tlsData.enabled) return;
+    const strong = document.createElement('strong');
+    strong.textContent = '证书指纹 (SHA-256): ';
+    tlsInfo.appendChild(strong);
+    const text = document.createTextNode(tlsData.fingerprint);
+    tlsInfo.appendChild(text);
+}
+
+// 显示 mDNS 主机名（手机可通过 主机名.local 访问，不受 IP 变化影响）
+function displayMDNSHost() {
+    const mdnsEl = document.getElementById('mdns-info');
+    if (!mdnsEl) return;
+    mdnsEl.innerHTML = '';
+    if (!mdnsInfo.enabled) return;
+    const strong = document.createElement('strong');
+    strong.textContent = '主机名: ';
+    mdnsEl.appendChild(strong);
+    const text = document.createTextNode(mdnsInfo.qr ? `${mdnsInfo.host}（二维码使用主机名）` : mdnsInfo.host);
+    mdnsEl.appendChild(text);
+}
+
+// 显示已连接的手机端（允许多台手机时，便于确认哪些设备已连接）
+function displayDevices(devices) {
+    connectedDevices = devices;
+    const kickButton = document.getElementById('kick-phone');
+    if (kickButton) {
+        kickButton.style.display = devices.length > 0 ? 'block' : 'none';
+    }
+    const devicesEl = document.getElementById('devices-info');
+    if (!devicesEl) return;
+    devicesEl.innerHTML = '';
+    if (devices.length === 0) return;
+    const strong = document.createElement('strong');
+    strong.textContent = '已连接手机: ';
+    devicesEl.appendChild(strong);
+    devicesEl.appendChild(document.createTextNode(devices.map(device => device.name || device.ip).join('、')));
+}
+
+// 重新获取 mDNS 信息并刷新二维码（配置修改 server.qrHostname 后调用）
+async function refreshMDNSInfo() {
+    try {
+        const response = await fetch('/api/mdns');
+        mdnsInfo = await response.json();
+    } catch (error) {
+        console.error('获取 mDNS 信息失败:', error);
+        return;
+    }
+    displayMDNSHost();
+    if (qrIP && qrPort) {
+        generateQRCodeForIP(qrIP, qrPort);
+    }
+}
+
+// 断开手机：只有一台时确认后断开，多台时输入序号选择
+async function kickPhone() {
+    if (connectedDevices.length === 0) {
+        showToast('没有已连接的手机', 'info');
+        return;
+    }
+    let device = connectedDevices[0];
+    if (connectedDevices.length === 1) {
+        if (!confirm(`确定要断开 ${device.name || device.ip} 吗？`)) {
+            return;
+        }
+    } else {
+        const list = connectedDevices.map((d, i) => `${i + 1}. ${d.name || d.ip}`).join('\n');
+        const input = prompt(`请输入要断开的手机序号：\n${list}`);
+        if (input === null) {
+            return;
+        }
+        device = connectedDevices[parseInt(input, 10) - 1];
+        if (!device) {
+            showToast('无效的序号', 'warning');
+            return;
+        }
+    }
+    try {
+        const response = await fetch('/api/devices/kick', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ deviceId: device.id })
+        });
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        showToast(`已断开 ${device.name || device.ip}`, 'success');
+    } catch (error) {
+        console.error('断开手机失败:', error);
+        showToast('断开手机失败', 'error');
+    }
+}
+
+// 撤销所有手机端配对
+async function revokePairing() {
+    try {
+        const response = await fetch('/api/pairing/revoke', { method: 'POST' });
+        if (!response.ok) {
+            throw new Error(`HTTP ${response.status}`);
+        }
+        showToast('已撤销手机端配对', 'success');
+    } catch (error) {
+        console.error('撤销配对失败:', error);
+        showToast('撤销配对失败', 'error');
+    }
+}
+
 // 根据IP或IP列表生成二维码
 // 支持参数：
 // - ipOrIps: 
@@ -270,27 +459,31 @@ function generateQRCodeForIP(ipOrIps, port) {
         ip = ipOrIps;
     } else if (Array.isArray(ipOrIps) && ipOrIps.length > 0) {
         // 数组类型（初始化时传递，已按优先级排序：以太网 > USB共享网卡 > WiFi）
-        ip = ipOrIps[0].ip;
+        ip = ipHost(ipOrIps[0]);
     } else if (ipOrIps && ipOrIps.ip) {
         // 对象类型
-        ip = ipOrIps.ip;
+        ip = ipHost(ipOrIps);
     } else {
         return;
     }
     
-    const url = `http://${ip}:${port}`;
-    console.log('生成二维码，URL:', url);
+    qrIP = ip;
+    qrPort = port;
+    console.log('生成二维码，IP:', ip);
 
-    // 使用 QRCode.js 在本地生成二维码
+    // 由服务端生成二维码图片（本机访问时自动携带当前配对码），时间戳避免浏览器缓存旧配对码
     container.innerHTML = '';  // 清空容器
-    new QRCode(container, {
-        text: url,
-        width: 200,
-        height: 200,
-        colorDark: "#000000",
-        colorLight: "#ffffff",
-        correctLevel: QRCode.CorrectLevel.H
-    });
+    const img = document.createElement('img');
+    // 启用 qrHostname 时二维码使用 .local 主机名
+    const hostParam = mdnsInfo.qr ? `&host=${encodeURIComponent(mdnsInfo.host)}` : '';
+    img.src = `/api/qr.svg?ip=${encodeURIComponent(ip)}${hostParam}&t=${Date.now()}`;
+    img.width = 200;
+    img.height = 200;
+    img.alt = '手机扫码连接';
+    img.onerror = () => {
+        container.textContent = '二维码加载失败';
+    };
+    container.appendChild(img);
 }
 
 // 设置 SSE 连接
@@ -310,13 +503,22 @@ function setupEventSource() {
     console.log('建立 SSE 连接...');
     // type=pc 表示这是 PC 端连接，允许多个 PC 端同时连接
     // type=pc indicates this is a PC connection, allowing multiple PCs to connect simultaneously
-    eventSource = new EventSource('/ws?type=pc');
+    // 带上最后收到的消息 ID，服务端会重放断线期间错过的消息（缺口过大时回放完整历史）
+    // Carry the last message ID so the server replays what was missed while disconnected (full history when the gap is too large)
+    let url = '/ws?type=pc';
+    if (lastEventId) {
+        url += '&lastEventId=' + encodeURIComponent(lastEventId);
+    }
+    eventSource = new EventSource(url);
 
     eventSource.onopen = () => {
         console.log('SSE 连接已建立');
     };
 
     eventSource.addEventListener('message', (event) => {
+        if (event.lastEventId) {
+            lastEventId = event.lastEventId;
+        }
         try {
             const data = JSON.parse(event.data);
             handleMessage(data);
@@ -356,11 +558,17 @@ function setupEventSource() {
 // 处理消息
 function handleMessage(message) {
     if (message.type === 'text') {
-        // 收到文本消息：直接更新底部输入区
-        updateCurrentInput(message.data);
+        // 收到文本消息：更新该设备在底部输入区的内容
+        updateCurrentInput(message.data, message.payload || null);
     } else if (message.type === 'segment') {
         // 收到分段信号（旧逻辑）：把底部内容变成卡片，清空底部
         console.log('收到分段信号（旧逻辑）:', message.data);
+        if (message.payload) {
+            // 新版服务端附带卡片数据：直接使用服务端的卡片
+            addCard(message.payload.text, message.payload);
+            updateCurrentInput('', { key: cardInputKey(message.payload) });
+            return;
+        }
         const currentContent = document.getElementById('current-input').textContent;
         if (currentContent) {
             // 检查是否只包含空白字符
@@ -373,11 +581,34 @@ function handleMessage(message) {
     } else if (message.type === 'card') {
         // 收到卡片消息（新逻辑）：直接生成卡片（使用服务端发送的内容）
         console.log('收到卡片消息（新逻辑）:', message.data);
-        addCard(message.data);
+        const cardData = message.payload || null;
+        addCard(cardData ? cardData.text : message.data, cardData);
+    } else if (message.type === 'card_created') {
+        // 其他页面或 API 创建了卡片
+        console.log('收到卡片创建消息:', message.data);
+        addCard(message.payload.text, message.payload);
+    } else if (message.type === 'card_updated') {
+        // 卡片内容已在服务端修改：同步到当前页面
+        console.log('收到卡片修改消息:', message.data);
+        updateCardById(message.payload);
+    } else if (message.type === 'card_deleted') {
+        // 卡片已在服务端删除（data 为卡片 ID）
+        console.log('收到卡片删除消息:', message.data);
+        removeCardById(message.data);
+    } else if (message.type === 'config_reload') {
+        // 服务端配置文件已重新加载
+        handleConfigReload(message.payload || {});
+    } else if (message.type === 'snapshot') {
+        // 连接或刷新页面后收到完整状态：用服务端的状态替换页面内容
+        applySnapshot(message.payload || {});
+    } else if (message.type === 'mode_sync') {
+        // 输入模式已切换
+        console.log('收到模式同步消息:', message.data);
+        applySegmentMode(message.data);
     } else if (message.type === 'clear_input') {
-        // 收到清空输入框信号（新逻辑）：清空底部输入区
+        // 收到清空输入框信号（新逻辑）：清空该设备的输入，未指定设备时清空底部输入区
         console.log('收到清空输入框信号');
-        updateCurrentInput('');
+        updateCurrentInput('', message.payload || null);
     } else if (message.type === 'show_qr') {
         // 收到二维码显示/隐藏信号
         const showQR = message.data === 'true';
@@ -387,6 +618,32 @@ function handleMessage(message) {
         } else {
             hideControlPanel();
         }
+    } else if (message.type === 'pairing') {
+        // 配对码已更换：刷新配对码和二维码
+        console.log('收到配对码更换消息');
+        pairingPin = message.data;
+        displayPairingPin();
+        if (qrIP && qrPort) {
+            generateQRCodeForIP(qrIP, qrPort);
+        }
+    } else if (message.type === 'active_ip') {
+        // 访问地址已切换（可能来自其他电脑端页面或配置文件）
+        console.log('收到访问地址切换消息:', message.data);
+        applyActiveIP(message.payload);
+    } else if (message.type === 'network_change') {
+        // 网卡地址变化：刷新 IP 列表，当前访问地址变化时重新生成二维码
+        const change = message.payload || {};
+        console.log('收到网络变化消息:', change);
+        const previousIP = qrIP;
+        displayIPs(change.ips);
+        applyActiveIP(change.active);
+        if (change.active && ipHost(change.active) !== previousIP) {
+            showToast(`网络已变化，新的访问地址: ${ipHost(change.active)}，请重新扫码`, 'warning');
+        }
+    } else if (message.type === 'devices') {
+        // 已连接的手机端列表变化
+        console.log('收到设备列表:', message.payload);
+        displayDevices(message.payload || []);
     } else if (message.type === 'connected') {
         // 收到连接成功消息
         console.log('收到连接成功消息');
@@ -394,6 +651,49 @@ function handleMessage(message) {
     }
 }
 
+// 应用服务端的完整状态：历史卡片、各设备的当前输入、输入模式、二维码显示状态和已连接的手机
+function applySnapshot(snapshot) {
+    console.log('收到完整状态:', snapshot);
+    restoreCards(snapshot.cards || []);
+    currentInputs.clear();
+    (snapshot.inputs || []).forEach((input) => {
+        currentInputs.set(input.key, { name: input.deviceName || '', text: input.text });
+    });
+    renderCurrentInputs();
+    applySegmentMode(snapshot.mode || 'single');
+    displayDevices(snapshot.devices || []);
+    if (snapshot.showQR) {
+        showControlPanel();
+    } else {
+        hideControlPanel();
+    }
+}
+
+// 显示当前输入模式：single（手机控制分段，服务端默认）或 continuous（服务端控制分段）
+function applySegmentMode(mode) {
+    const label = document.getElementById('segment-mode-label');
+    if (label) {
+        label.textContent = mode === 'continuous' ? '连续输入模式（自动分段）' : '单次输入模式（手机控制分段）';
+    }
+}
+
+// 显示配置重新加载结果
+function handleConfigReload(result) {
+    console.log('配置已重新加载:', result);
+    if (result.error) {
+        showToast(`配置文件有误，未生效：${result.error}`, 'error');
+        return;
+    }
+    if (result.live && result.live.includes('server.qrHostname')) {
+        refreshMDNSInfo();
+    }
+    if (result.restart && result.restart.length > 0) {
+        showToast(`配置已更新，以下设置需要重启程序后生效：${result.restart.join('、')}`, 'warning');
+        return;
+    }
+    showToast('配置已更新并生效', 'success');
+}
+
 // 初始化
 async function init() {
     console.log('初始化...');